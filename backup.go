@@ -3,7 +3,10 @@ package grocksdb
 // #include <stdlib.h>
 // #include "rocksdb/c.h"
 import "C"
+
 import (
+	"sync"
+	"time"
 	"unsafe"
 )
 
@@ -13,28 +16,52 @@ type BackupInfo struct {
 	Timestamp int64
 	Size      uint64
 	NumFiles  uint32
+	// AppMetadata is the application metadata of the backup, which only the
+	// backup engines of the C++ API of RocksDB can set.
+	AppMetadata string
+	// Files of the backup, only listed by GetInfoWithFiles.
+	Files []BackupFileInfo
+}
+
+// BackupFileInfo represents a file of a backup.
+type BackupFileInfo struct {
+	// Name of the file, relative to the backup directory.
+	Name string
+	Size uint64
+	// Checksum is the crc32c checksum of the file.
+	Checksum uint32
 }
 
 // BackupEngine is a reusable handle to a RocksDB Backup, created by
 // OpenBackupEngine.
+//
+// GetInfoWithFiles and the application metadata of GetInfo read the metadata
+// of the backups from the backup directory, which must be on the local file
+// system.
 type BackupEngine struct {
-	c  *C.rocksdb_backup_engine_t
-	db *DB
+	c   *C.rocksdb_backup_engine_t
+	db  *DB
+	dir string
+
+	// mu serializes the operations of the backup engine.
+	mu sync.Mutex
+
+	progressInterval time.Duration
 }
 
 // OpenBackupEngine opens a backup engine with specified options.
 func OpenBackupEngine(opts *Options, path string) (be *BackupEngine, err error) {
 	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
 
 	var cErr *C.char
 	bEngine := C.rocksdb_backup_engine_open(opts.c, cpath, &cErr)
 	if err = fromCError(cErr); err == nil {
 		be = &BackupEngine{
-			c: bEngine,
+			c:   bEngine,
+			dir: path,
 		}
 	}
-
-	C.free(unsafe.Pointer(cpath))
 	return
 }
 
@@ -44,7 +71,8 @@ func OpenBackupEngineWithOpt(opts *BackupEngineOptions, env *Env) (be *BackupEng
 	bEngine := C.rocksdb_backup_engine_open_opts(opts.c, env.c, &cErr)
 	if err = fromCError(cErr); err == nil {
 		be = &BackupEngine{
-			c: bEngine,
+			c:   bEngine,
+			dir: opts.dir,
 		}
 	}
 
@@ -69,23 +97,47 @@ func CreateBackupEngineWithPath(db *DB, path string) (be *BackupEngine, err erro
 
 // CreateNewBackup takes a new backup from db.
 func (b *BackupEngine) CreateNewBackup() (err error) {
-	var cErr *C.char
-	C.rocksdb_backup_engine_create_new_backup(b.c, b.db.c, &cErr)
-	err = fromCError(cErr)
-	return
+	return b.createNewBackup(false, nil)
 }
 
 // CreateNewBackupFlush takes a new backup from db.
 // Backup would be created after flushing.
 func (b *BackupEngine) CreateNewBackupFlush(flushBeforeBackup bool) (err error) {
-	var cErr *C.char
-	C.rocksdb_backup_engine_create_new_backup_flush(b.c, b.db.c, boolToChar(flushBeforeBackup), &cErr)
-	err = fromCError(cErr)
-	return
+	return b.createNewBackup(flushBeforeBackup, nil)
+}
+
+// CreateNewBackupWithProgress takes a new backup from db, calling progress
+// periodically while the backup is taken.
+func (b *BackupEngine) CreateNewBackupWithProgress(flushBeforeBackup bool, progress BackupProgressFunc) (err error) {
+	return b.createNewBackup(flushBeforeBackup, progress)
+}
+
+func (b *BackupEngine) createNewBackup(flushBeforeBackup bool, progress BackupProgressFunc) (err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	create := func() error {
+		var cErr *C.char
+		C.rocksdb_backup_engine_create_new_backup_flush(b.c, b.db.c, boolToChar(flushBeforeBackup), &cErr)
+		return fromCError(cErr)
+	}
+
+	if progress == nil {
+		return create()
+	}
+
+	tracker, err := b.backupTracker(flushBeforeBackup)
+	if err != nil {
+		return
+	}
+	return b.runWithProgress(create, tracker, progress)
 }
 
 // PurgeOldBackups deletes old backups, where `numBackupsToKeep` is how many backups you’d like to keep.
 func (b *BackupEngine) PurgeOldBackups(numBackupsToKeep uint32) (err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
 	var cErr *C.char
 	C.rocksdb_backup_engine_purge_old_backups(b.c, C.uint32_t(numBackupsToKeep), &cErr)
 	err = fromCError(cErr)
@@ -94,6 +146,9 @@ func (b *BackupEngine) PurgeOldBackups(numBackupsToKeep uint32) (err error) {
 
 // VerifyBackup verifies a backup by its id.
 func (b *BackupEngine) VerifyBackup(backupID uint32) (err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
 	var cErr *C.char
 	C.rocksdb_backup_engine_verify_backup(b.c, C.uint32_t(backupID), &cErr)
 	err = fromCError(cErr)
@@ -103,6 +158,38 @@ func (b *BackupEngine) VerifyBackup(backupID uint32) (err error) {
 // GetInfo gets an object that gives information about
 // the backups that have already been taken
 func (b *BackupEngine) GetInfo() (infos []BackupInfo) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	infos = b.getInfo()
+	for i := range infos {
+		if meta, err := b.readBackupMeta(infos[i].ID); err == nil {
+			infos[i].AppMetadata = meta.appMetadata
+		}
+	}
+	return
+}
+
+// GetInfoWithFiles is like GetInfo, also listing the files of the backups.
+func (b *BackupEngine) GetInfoWithFiles() (infos []BackupInfo, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	infos = b.getInfo()
+	for i := range infos {
+		var meta *backupMeta
+		if meta, err = b.readBackupMeta(infos[i].ID); err != nil {
+			return nil, err
+		}
+		infos[i].AppMetadata = meta.appMetadata
+		if infos[i].Files, err = b.backupFiles(meta); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+func (b *BackupEngine) getInfo() (infos []BackupInfo) {
 	info := C.rocksdb_backup_engine_get_backup_info(b.c)
 
 	n := int(C.rocksdb_backup_engine_info_count(info))
@@ -122,6 +209,9 @@ func (b *BackupEngine) GetInfo() (infos []BackupInfo) {
 // RestoreDBFromLatestBackup restores the latest backup to dbDir. walDir
 // is where the write ahead logs are restored to and usually the same as dbDir.
 func (b *BackupEngine) RestoreDBFromLatestBackup(dbDir, walDir string, ro *RestoreOptions) (err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
 	cDbDir := C.CString(dbDir)
 	cWalDir := C.CString(walDir)
 
@@ -137,6 +227,31 @@ func (b *BackupEngine) RestoreDBFromLatestBackup(dbDir, walDir string, ro *Resto
 // RestoreDBFromBackup restores the backup (identified by its id) to dbDir. walDir
 // is where the write ahead logs are restored to and usually the same as dbDir.
 func (b *BackupEngine) RestoreDBFromBackup(dbDir, walDir string, ro *RestoreOptions, backupID uint32) (err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.restoreDBFromBackup(dbDir, walDir, ro, backupID)
+}
+
+// RestoreDBFromBackupWithProgress is like RestoreDBFromBackup, calling
+// progress periodically while the backup is restored. The restore is rate
+// limited by BackupEngineOptions.SetRestoreRateLimit, which the estimated
+// time remaining accounts for.
+func (b *BackupEngine) RestoreDBFromBackupWithProgress(dbDir, walDir string, ro *RestoreOptions, backupID uint32, progress BackupProgressFunc) (err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	tracker, err := b.restoreTracker(dbDir, walDir, backupID)
+	if err != nil {
+		return
+	}
+
+	return b.runWithProgress(func() error {
+		return b.restoreDBFromBackup(dbDir, walDir, ro, backupID)
+	}, tracker, progress)
+}
+
+func (b *BackupEngine) restoreDBFromBackup(dbDir, walDir string, ro *RestoreOptions, backupID uint32) (err error) {
 	cDbDir := C.CString(dbDir)
 	cWalDir := C.CString(walDir)
 
@@ -149,9 +264,22 @@ func (b *BackupEngine) RestoreDBFromBackup(dbDir, walDir string, ro *RestoreOpti
 	return
 }
 
+// SetProgressInterval sets the interval between the calls of the progress
+// callbacks of backups and restores.
+//
+// Default: 1s
+func (b *BackupEngine) SetProgressInterval(interval time.Duration) {
+	b.mu.Lock()
+	b.progressInterval = interval
+	b.mu.Unlock()
+}
+
 // Close close the backup engine and cleans up state
 // The backups already taken remain on storage.
 func (b *BackupEngine) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
 	C.rocksdb_backup_engine_close(b.c)
 	b.c = nil
 	b.db = nil
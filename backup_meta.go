@@ -0,0 +1,144 @@
+package grocksdb
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// backupMeta is the metadata of a backup, stored by the backup engine in
+// the meta directory of the backup directory.
+type backupMeta struct {
+	timestamp   int64
+	sequence    uint64
+	appMetadata string
+	files       []backupMetaFile
+}
+
+// backupMetaFile is a file of a backup, as listed by its metadata.
+type backupMetaFile struct {
+	name     string
+	size     uint64
+	hasSize  bool
+	checksum uint32
+}
+
+const (
+	backupMetaSchemaVersion = "schema_version "
+	backupMetaAppMetadata   = "metadata "
+)
+
+// parseBackupMeta parses the metadata of a backup. The metadata starts with
+// an optional schema version, the timestamp and the sequence number of the
+// backup, followed by fields such as the application metadata, the number
+// of files and a line for each file with its fields.
+func parseBackupMeta(data []byte) (*backupMeta, error) {
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	meta := &backupMeta{}
+	corrupted := fmt.Errorf("Corruption: invalid backup metadata")
+
+	i := 0
+	if strings.HasPrefix(lines[0], backupMetaSchemaVersion) {
+		i++
+	}
+	if len(lines) < i+2 {
+		return nil, corrupted
+	}
+	timestamp, err := strconv.ParseInt(lines[i], 10, 64)
+	if err != nil {
+		return nil, corrupted
+	}
+	sequence, err := strconv.ParseUint(lines[i+1], 10, 64)
+	if err != nil {
+		return nil, corrupted
+	}
+	meta.timestamp, meta.sequence = timestamp, sequence
+	i += 2
+
+	numFiles := -1
+	for ; i < len(lines) && numFiles < 0; i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, backupMetaAppMetadata) {
+			appMetadata, err := hex.DecodeString(strings.TrimPrefix(line, backupMetaAppMetadata))
+			if err != nil {
+				return nil, corrupted
+			}
+			meta.appMetadata = string(appMetadata)
+		} else if n, err := strconv.Atoi(line); err == nil {
+			numFiles = n
+		}
+	}
+	if numFiles < 0 || len(lines)-i < numFiles {
+		return nil, corrupted
+	}
+
+	meta.files = make([]backupMetaFile, numFiles)
+	for j := range meta.files {
+		fields := strings.Fields(lines[i+j])
+		if len(fields) == 0 {
+			return nil, corrupted
+		}
+
+		file := &meta.files[j]
+		file.name = fields[0]
+		for k := 1; k+1 < len(fields); k += 2 {
+			switch fields[k] {
+			case "crc32":
+				checksum, err := strconv.ParseUint(fields[k+1], 10, 32)
+				if err != nil {
+					return nil, corrupted
+				}
+				file.checksum = uint32(checksum)
+			case "size":
+				size, err := strconv.ParseUint(fields[k+1], 10, 64)
+				if err != nil {
+					return nil, corrupted
+				}
+				file.size, file.hasSize = size, true
+			}
+		}
+	}
+	return meta, nil
+}
+
+// formatBackupMeta formats the metadata of a backup, with the sizes of its files.
+func formatBackupMeta(timestamp int64, sequence uint64, files []backupMetaFile) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s2\n%d\n%d\n%d\n", backupMetaSchemaVersion, timestamp, sequence, len(files))
+	for _, file := range files {
+		fmt.Fprintf(&sb, "%s crc32 %d size %d\n", file.name, file.checksum, file.size)
+	}
+	return []byte(sb.String())
+}
+
+func (b *BackupEngine) metaPath(backupID uint32) string {
+	return filepath.Join(b.dir, "meta", strconv.FormatUint(uint64(backupID), 10))
+}
+
+func (b *BackupEngine) readBackupMeta(backupID uint32) (*backupMeta, error) {
+	data, err := os.ReadFile(b.metaPath(backupID))
+	if err != nil {
+		return nil, err
+	}
+	return parseBackupMeta(data)
+}
+
+// backupFiles returns the files of the backup, sized from the backup
+// directory when the metadata has no size.
+func (b *BackupEngine) backupFiles(meta *backupMeta) ([]BackupFileInfo, error) {
+	files := make([]BackupFileInfo, len(meta.files))
+	for i, file := range meta.files {
+		files[i] = BackupFileInfo{Name: file.name, Size: file.size, Checksum: file.checksum}
+		if !file.hasSize {
+			fi, err := os.Stat(filepath.Join(b.dir, file.name))
+			if err != nil {
+				return nil, err
+			}
+			files[i].Size = uint64(fi.Size())
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,222 @@
+package grocksdb
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const defaultBackupProgressInterval = time.Second
+
+// BackupProgress is the progress of a backup or of a restore.
+type BackupProgress struct {
+	// BytesCopied and FilesCopied are the bytes and the files copied so far.
+	BytesCopied uint64
+	FilesCopied int
+	// TotalBytes and TotalFiles are the bytes and the files to copy. They are
+	// estimated from the live table files of the database for backups, which
+	// do not copy the files already backed up again, but copy the MANIFEST,
+	// OPTIONS and WAL files too: the bytes and the files copied may differ.
+	TotalBytes uint64
+	TotalFiles int
+	Elapsed    time.Duration
+	// ETA is the estimated time remaining, 0 when unknown.
+	ETA time.Duration
+}
+
+// BackupProgressFunc is called when a backup or a restore starts, then
+// periodically with its progress, and once more when it completes.
+//
+// The progress callback of the backup engine is not exposed by the C API of
+// RocksDB, so that the progress is an estimate measured from the files
+// written to the backup directory, or to the restored database, and backups
+// and restores can't be stopped.
+type BackupProgressFunc func(progress BackupProgress)
+
+// progressTracker measures the progress of a backup or of a restore.
+type progressTracker struct {
+	totalBytes uint64
+	totalFiles int
+	measure    func() (bytes uint64, files int)
+}
+
+func (t *progressTracker) progress(start time.Time) (p BackupProgress) {
+	p.BytesCopied, p.FilesCopied = t.measure()
+	p.TotalBytes, p.TotalFiles = t.totalBytes, t.totalFiles
+	p.Elapsed = time.Since(start)
+	if p.BytesCopied > 0 && p.TotalBytes > p.BytesCopied {
+		p.ETA = time.Duration(float64(p.Elapsed) * float64(p.TotalBytes-p.BytesCopied) / float64(p.BytesCopied))
+	}
+	return
+}
+
+// runWithProgress runs op, reporting its progress until it returns.
+func (b *BackupEngine) runWithProgress(op func() error, tracker *progressTracker, progress BackupProgressFunc) error {
+	interval := b.progressInterval
+	if interval <= 0 {
+		interval = defaultBackupProgressInterval
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- op()
+	}()
+
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	progress(tracker.progress(start))
+
+	for {
+		select {
+		case err := <-done:
+			if err == nil {
+				p := tracker.progress(start)
+				p.ETA = 0
+				progress(p)
+			}
+			return err
+
+		case <-ticker.C:
+			progress(tracker.progress(start))
+		}
+	}
+}
+
+// backupTracker measures the progress of a backup from the files added to
+// the backup directory, temporary files included. The shared directories are
+// listed, and the new directories of private only are walked, as the files
+// already backed up don't change. The totals are the sizes of the live table
+// files of the database, flushed first when the backup flushes it.
+func (b *BackupEngine) backupTracker(flush bool) (*progressTracker, error) {
+	dirs := []string{
+		filepath.Join(b.dir, "shared"),
+		filepath.Join(b.dir, "shared_checksum"),
+		filepath.Join(b.dir, "private"),
+	}
+	existing := make(map[string]struct{})
+	for _, dir := range dirs {
+		for _, name := range dirEntries(dir) {
+			existing[filepath.Join(dir, name)] = struct{}{}
+		}
+	}
+
+	t := &progressTracker{
+		measure: func() (bytes uint64, files int) {
+			for _, dir := range dirs {
+				for _, name := range dirEntries(dir) {
+					name = filepath.Join(dir, name)
+					if _, ok := existing[name]; ok {
+						continue
+					}
+					for file, size := range dirFiles(name) {
+						bytes += size
+						if !strings.HasSuffix(file, ".tmp") {
+							files++
+						}
+					}
+				}
+			}
+			return
+		},
+	}
+
+	if flush {
+		fo := NewDefaultFlushOptions()
+		defer fo.Destroy()
+		if err := b.db.Flush(fo); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range b.db.GetLiveFilesMetaData() {
+		t.totalBytes += uint64(file.Size)
+		t.totalFiles++
+	}
+	return t, nil
+}
+
+// dirEntries returns the names of the entries of the directory, none when
+// it can't be read.
+func dirEntries(dir string) (names []string) {
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return
+}
+
+// dirFiles returns the sizes of the files of the tree, by path.
+func dirFiles(root string) map[string]uint64 {
+	files := make(map[string]uint64)
+	_ = filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		// files are renamed while backups are taken
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if fi, err := d.Info(); err == nil {
+			files[name] = uint64(fi.Size())
+		}
+		return nil
+	})
+	return files
+}
+
+// restoreTracker measures the progress of a restore from the files of the
+// backup copied to the restored database.
+func (b *BackupEngine) restoreTracker(dbDir, walDir string, backupID uint32) (*progressTracker, error) {
+	meta, err := b.readBackupMeta(backupID)
+	if err != nil {
+		return nil, err
+	}
+	files, err := b.backupFiles(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &progressTracker{totalFiles: len(files)}
+	restored := make([]string, len(files))
+	sizes := make([]uint64, len(files))
+	for i, file := range files {
+		restored[i] = restoredPath(dbDir, walDir, file.Name)
+		t.totalBytes += file.Size
+		sizes[i] = file.Size
+	}
+
+	t.measure = func() (bytes uint64, n int) {
+		for i, name := range restored {
+			fi, err := os.Stat(name)
+			if err != nil {
+				continue
+			}
+			if size := uint64(fi.Size()); size < sizes[i] {
+				bytes += size
+			} else {
+				bytes += sizes[i]
+				n++
+			}
+		}
+		return
+	}
+	return t, nil
+}
+
+// restoredPath returns where the backup file is restored. Table files shared
+// with checksums are named <number>_<checksum>_<size>.sst or
+// <number>_s<session>.sst in backups, and <number>.sst in databases.
+func restoredPath(dbDir, walDir, name string) string {
+	base := path.Base(name)
+	if strings.HasPrefix(name, "shared_checksum/") {
+		if i, j := strings.Index(base, "_"), strings.LastIndex(base, "."); i >= 0 && j > i {
+			base = base[:i] + base[j:]
+		}
+	}
+
+	if strings.HasSuffix(base, ".log") {
+		return filepath.Join(walDir, base)
+	}
+	return filepath.Join(dbDir, base)
+}
@@ -0,0 +1,119 @@
+package grocksdb
+
+import (
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// OpenAsReadOnlyDB opens the backup (identified by its id) as a read-only
+// database, without restoring it. See OpenAsReadOnlyDBColumnFamilies.
+func (b *BackupEngine) OpenAsReadOnlyDB(backupID uint32, opts *Options) (db *DB, err error) {
+	dir, err := b.linkBackup(backupID)
+	if err != nil {
+		return
+	}
+
+	if db, err = OpenDbForReadOnly(opts, dir, false); err != nil {
+		_ = os.RemoveAll(dir)
+		return
+	}
+	db.cleanup = func() { _ = os.RemoveAll(dir) }
+	return
+}
+
+// OpenAsReadOnlyDBColumnFamilies opens the column families of the backup
+// (identified by its id) as a read-only database, without restoring it.
+//
+// The files of the backup are not copied: they are linked to a temporary
+// directory, removed when the database is closed. The backup must not be
+// deleted while the database is open.
+func (b *BackupEngine) OpenAsReadOnlyDBColumnFamilies(
+	backupID uint32,
+	opts *Options,
+	cfNames []string,
+	cfOpts []*Options,
+) (db *DB, cfHandles []*ColumnFamilyHandle, err error) {
+	dir, err := b.linkBackup(backupID)
+	if err != nil {
+		return
+	}
+
+	if db, cfHandles, err = OpenDbForReadOnlyColumnFamilies(opts, dir, cfNames, cfOpts, false); err != nil {
+		_ = os.RemoveAll(dir)
+		return
+	}
+	db.cleanup = func() { _ = os.RemoveAll(dir) }
+	return
+}
+
+// linkBackup links the files of the backup to a temporary directory, named
+// as they are restored.
+func (b *BackupEngine) linkBackup(backupID uint32) (dir string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	meta, err := b.readBackupMeta(backupID)
+	if err != nil {
+		return
+	}
+
+	if dir, err = os.MkdirTemp("", "grocksdb-backup-"); err != nil {
+		return
+	}
+	for _, file := range meta.files {
+		target, err := filepath.Abs(filepath.Join(b.dir, file.name))
+		if err == nil {
+			err = os.Symlink(target, restoredPath(dir, dir, file.name))
+		}
+		if err != nil {
+			_ = os.RemoveAll(dir)
+			return "", err
+		}
+	}
+	return
+}
+
+// VerifyBackupWithChecksum verifies a backup by its id, as VerifyBackup does,
+// then reads its files to verify their checksums.
+func (b *BackupEngine) VerifyBackupWithChecksum(backupID uint32) (err error) {
+	if err = b.VerifyBackup(backupID); err != nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	meta, err := b.readBackupMeta(backupID)
+	if err != nil {
+		return
+	}
+	for _, file := range meta.files {
+		var checksum uint32
+		if checksum, err = fileCrc32c(filepath.Join(b.dir, file.name)); err != nil {
+			return
+		}
+		if checksum != file.checksum {
+			return fmt.Errorf("Corruption: File checksum mismatch for %s: %d, expected %d", file.name, checksum, file.checksum)
+		}
+	}
+	return
+}
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+func fileCrc32c(name string) (uint32, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	h := crc32.New(crc32cTable)
+	if _, err = io.Copy(h, f); err != nil {
+		return 0, err
+	}
+	return h.Sum32(), nil
+}
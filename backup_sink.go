@@ -0,0 +1,377 @@
+package grocksdb
+
+import (
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BackupSink is where CreateBackupToSink writes backups, such as an object
+// store. Names are slash-separated paths relative to the sink.
+type BackupSink interface {
+	// Create creates the file, which is complete once closed without error.
+	Create(name string) (io.WriteCloser, error)
+	// Exists returns whether the file exists.
+	Exists(name string) (bool, error)
+	// List returns the names of the files.
+	List() ([]string, error)
+}
+
+// BackupSource is where RestoreDBFromSource reads backups, as written by
+// CreateBackupToSink. Names are slash-separated paths relative to the source.
+type BackupSource interface {
+	// Open opens the file for reading.
+	Open(name string) (io.ReadCloser, error)
+	// List returns the names of the files.
+	List() ([]string, error)
+}
+
+// DirBackupStore is a BackupSink and a BackupSource storing backups in a
+// local directory.
+type DirBackupStore struct {
+	dir string
+}
+
+var (
+	_ BackupSink   = (*DirBackupStore)(nil)
+	_ BackupSource = (*DirBackupStore)(nil)
+)
+
+// NewDirBackupStore returns a store of backups in the directory.
+func NewDirBackupStore(dir string) *DirBackupStore {
+	return &DirBackupStore{dir: dir}
+}
+
+// dirBackupFile is a file of a DirBackupStore being created, renamed once closed.
+type dirBackupFile struct {
+	*os.File
+	name string
+}
+
+func (f *dirBackupFile) Close() (err error) {
+	if err = f.File.Sync(); err == nil {
+		err = f.File.Close()
+	} else {
+		_ = f.File.Close()
+	}
+	if err == nil {
+		err = os.Rename(f.File.Name(), f.name)
+	}
+	if err != nil {
+		_ = os.Remove(f.File.Name())
+	}
+	return
+}
+
+// Create creates the file, which is complete once closed without error.
+func (s *DirBackupStore) Create(name string) (io.WriteCloser, error) {
+	p := filepath.Join(s.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Create(p + ".tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &dirBackupFile{File: f, name: p}, nil
+}
+
+// Exists returns whether the file exists.
+func (s *DirBackupStore) Exists(name string) (bool, error) {
+	_, err := os.Stat(filepath.Join(s.dir, filepath.FromSlash(name)))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// List returns the names of the files, except the files being created.
+func (s *DirBackupStore) List() (names []string, err error) {
+	err = filepath.WalkDir(s.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == s.dir {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(p, ".tmp") {
+			return nil
+		}
+
+		name, err := filepath.Rel(s.dir, p)
+		if err == nil {
+			names = append(names, filepath.ToSlash(name))
+		}
+		return err
+	})
+	return
+}
+
+// Open opens the file for reading.
+func (s *DirBackupStore) Open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(s.dir, filepath.FromSlash(name)))
+}
+
+// CreateBackupToSink takes a new backup of db to the sink, from a checkpoint
+// of db. The backups are laid out as the backups of BackupEngine: table and
+// blob files are shared by the backups, named after their number, checksum
+// and size as with LegacyCrc32cAndFileSize naming, so that only the files
+// which are not in the sink yet are written. The metadata of the backup is
+// written last, the backup being complete once written.
+//
+// The ID of the backup follows the IDs of the backups listed in the sink,
+// which is not atomic: a sink must have a single writer, backups taken
+// concurrently to the same sink overwriting each other.
+//
+// The checkpoint is staged in a temporary directory next to the directory of
+// db, so that its files are hard links of the files of db rather than copies.
+func CreateBackupToSink(db *DB, sink BackupSink) (info BackupInfo, err error) {
+	return CreateBackupToSinkWithStagingDir(db, sink, "")
+}
+
+// CreateBackupToSinkWithStagingDir is like CreateBackupToSink, staging the
+// checkpoint in a temporary directory of stagingDir, which should be on the
+// file system of db. The directory next to the directory of db is used when
+// stagingDir is empty.
+func CreateBackupToSinkWithStagingDir(db *DB, sink BackupSink, stagingDir string) (info BackupInfo, err error) {
+	names, err := sink.List()
+	if err != nil {
+		return
+	}
+	info.ID = 1
+	for _, id := range sinkBackupIDs(names) {
+		if id >= info.ID {
+			info.ID = id + 1
+		}
+	}
+
+	tmp, err := checkpointStagingDir(db.Name(), stagingDir)
+	if err != nil {
+		return
+	}
+	defer os.RemoveAll(tmp)
+
+	checkpoint, err := db.NewCheckpoint()
+	if err != nil {
+		return
+	}
+	defer checkpoint.Destroy()
+
+	dir := filepath.Join(tmp, "checkpoint")
+	cpInfo, err := checkpoint.CreateCheckpointWithSequence(dir, 0)
+	if err != nil {
+		return
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	files := make([]backupMetaFile, 0, len(entries))
+	for _, entry := range entries {
+		p := filepath.Join(dir, entry.Name())
+
+		file := backupMetaFile{hasSize: true}
+		var fi os.FileInfo
+		if fi, err = os.Stat(p); err != nil {
+			return
+		}
+		file.size = uint64(fi.Size())
+		if file.checksum, err = fileCrc32c(p); err != nil {
+			return
+		}
+
+		exists := false
+		switch ext := path.Ext(entry.Name()); ext {
+		case ".sst", ".blob":
+			file.name = fmt.Sprintf("shared_checksum/%s_%d_%d%s", strings.TrimSuffix(entry.Name(), ext), file.checksum, file.size, ext)
+			if exists, err = sink.Exists(file.name); err != nil {
+				return
+			}
+		default:
+			file.name = fmt.Sprintf("private/%d/%s", info.ID, entry.Name())
+		}
+
+		if !exists {
+			if err = copyToSink(sink, file.name, p); err != nil {
+				return
+			}
+		}
+		files = append(files, file)
+
+		info.Size += file.size
+		info.Files = append(info.Files, BackupFileInfo{Name: file.name, Size: file.size, Checksum: file.checksum})
+	}
+
+	info.Timestamp = time.Now().Unix()
+	info.NumFiles = uint32(len(files))
+
+	w, err := sink.Create(sinkMetaName(info.ID))
+	if err != nil {
+		return
+	}
+	if _, err = w.Write(formatBackupMeta(info.Timestamp, cpInfo.Sequence, files)); err != nil {
+		_ = w.Close()
+		return
+	}
+	err = w.Close()
+	return
+}
+
+// checkpointStagingDir creates a temporary directory to stage checkpoints of
+// the database in, in dir, or next to the directory of the database when dir
+// is empty. Checkpoints link the files of the database when on its file
+// system, and copy them otherwise.
+func checkpointStagingDir(dbName, dir string) (string, error) {
+	if dir == "" {
+		dbName = filepath.Clean(dbName)
+		return os.MkdirTemp(filepath.Dir(dbName), "."+filepath.Base(dbName)+".checkpoint-")
+	}
+	return os.MkdirTemp(dir, "grocksdb-checkpoint-")
+}
+
+func copyToSink(sink BackupSink, name, p string) (err error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	w, err := sink.Create(name)
+	if err != nil {
+		return
+	}
+	if _, err = io.Copy(w, f); err != nil {
+		_ = w.Close()
+		return
+	}
+	return w.Close()
+}
+
+func sinkMetaName(backupID uint32) string {
+	return "meta/" + strconv.FormatUint(uint64(backupID), 10)
+}
+
+// sinkBackupIDs returns the IDs of the backups of the file names, in order.
+func sinkBackupIDs(names []string) (ids []uint32) {
+	for _, name := range names {
+		if !strings.HasPrefix(name, "meta/") {
+			continue
+		}
+		if id, err := strconv.ParseUint(strings.TrimPrefix(name, "meta/"), 10, 32); err == nil {
+			ids = append(ids, uint32(id))
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return
+}
+
+func readSourceBackupMeta(source BackupSource, backupID uint32) (*backupMeta, error) {
+	r, err := source.Open(sinkMetaName(backupID))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseBackupMeta(data)
+}
+
+// GetBackupInfoFromSource returns the information about the backups of the
+// source, with their files.
+func GetBackupInfoFromSource(source BackupSource) (infos []BackupInfo, err error) {
+	names, err := source.List()
+	if err != nil {
+		return
+	}
+
+	for _, id := range sinkBackupIDs(names) {
+		var meta *backupMeta
+		if meta, err = readSourceBackupMeta(source, id); err != nil {
+			return nil, err
+		}
+
+		info := BackupInfo{
+			ID:          id,
+			Timestamp:   meta.timestamp,
+			NumFiles:    uint32(len(meta.files)),
+			AppMetadata: meta.appMetadata,
+		}
+		for _, file := range meta.files {
+			info.Size += file.size
+			info.Files = append(info.Files, BackupFileInfo{Name: file.name, Size: file.size, Checksum: file.checksum})
+		}
+		infos = append(infos, info)
+	}
+	return
+}
+
+// RestoreDBFromSource restores the backup (identified by its id) of the
+// source to dbDir, which should not exist or be empty. walDir is where the
+// write ahead logs are restored to and usually the same as dbDir. The
+// checksums of the files are verified while they are restored.
+func RestoreDBFromSource(source BackupSource, backupID uint32, dbDir, walDir string) (err error) {
+	meta, err := readSourceBackupMeta(source, backupID)
+	if err != nil {
+		return
+	}
+
+	for _, dir := range []string{dbDir, walDir} {
+		if err = os.MkdirAll(dir, 0o755); err != nil {
+			return
+		}
+	}
+
+	for _, file := range meta.files {
+		if err = restoreFromSource(source, file, restoredPath(dbDir, walDir, file.name)); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func restoreFromSource(source BackupSource, file backupMetaFile, dst string) (err error) {
+	r, err := source.Open(file.name)
+	if err != nil {
+		return
+	}
+	defer r.Close()
+
+	f, err := os.Create(dst)
+	if err != nil {
+		return
+	}
+
+	h := crc32.New(crc32cTable)
+	size, err := io.Copy(io.MultiWriter(f, h), r)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return
+	}
+
+	if file.hasSize && uint64(size) != file.size {
+		return fmt.Errorf("Corruption: File size mismatch for %s: %d, expected %d", file.name, size, file.size)
+	}
+	if checksum := h.Sum32(); checksum != file.checksum {
+		return fmt.Errorf("Corruption: File checksum mismatch for %s: %d, expected %d", file.name, checksum, file.checksum)
+	}
+	return
+}
@@ -0,0 +1,170 @@
+package grocksdb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// memBackupStore is an in-memory BackupSink and BackupSource.
+type memBackupStore struct {
+	files   map[string][]byte
+	created []string
+}
+
+type memBackupFile struct {
+	bytes.Buffer
+	store *memBackupStore
+	name  string
+}
+
+func (f *memBackupFile) Close() error {
+	f.store.files[f.name] = f.Bytes()
+	return nil
+}
+
+func (s *memBackupStore) Create(name string) (io.WriteCloser, error) {
+	s.created = append(s.created, name)
+	return &memBackupFile{store: s, name: name}, nil
+}
+
+func (s *memBackupStore) Exists(name string) (bool, error) {
+	_, ok := s.files[name]
+	return ok, nil
+}
+
+func (s *memBackupStore) List() (names []string, err error) {
+	for name := range s.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+func (s *memBackupStore) Open(name string) (io.ReadCloser, error) {
+	data, ok := s.files[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func TestBackupToSink(t *testing.T) {
+	t.Parallel()
+
+	db := newTestDB(t, nil)
+	defer db.Close()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+
+	store := &memBackupStore{files: make(map[string][]byte)}
+
+	require.Nil(t, db.Put(wo, []byte("a"), []byte("1")))
+	first, err := CreateBackupToSink(db, store)
+	require.Nil(t, err)
+	require.EqualValues(t, 1, first.ID)
+
+	// only the new table files are written
+	store.created = nil
+	require.Nil(t, db.Put(wo, []byte("b"), []byte("2")))
+	second, err := CreateBackupToSink(db, store)
+	require.Nil(t, err)
+	require.EqualValues(t, 2, second.ID)
+	var shared int
+	for _, name := range store.created {
+		if strings.HasPrefix(name, "shared_checksum/") {
+			shared++
+		}
+	}
+	require.Equal(t, 1, shared)
+
+	// checkpoints are staged next to the database, and removed
+	staged, err := filepath.Glob(filepath.Join(filepath.Dir(db.Name()), "."+filepath.Base(db.Name())+".checkpoint-*"))
+	require.Nil(t, err)
+	require.Empty(t, staged)
+	stagingDir := t.TempDir()
+	third, err := CreateBackupToSinkWithStagingDir(db, store, stagingDir)
+	require.Nil(t, err)
+	require.EqualValues(t, 3, third.ID)
+	entries, err := os.ReadDir(stagingDir)
+	require.Nil(t, err)
+	require.Empty(t, entries)
+
+	// the sequence number is the one of the checkpoint
+	meta, err := parseBackupMeta(store.files[sinkMetaName(second.ID)])
+	require.Nil(t, err)
+	require.EqualValues(t, 2, meta.sequence)
+
+	infos, err := GetBackupInfoFromSource(store)
+	require.Nil(t, err)
+	require.Len(t, infos, 3)
+	require.Equal(t, first.Files, infos[0].Files)
+	require.Equal(t, second.Size, infos[1].Size)
+
+	dir := t.TempDir()
+	require.Nil(t, RestoreDBFromSource(store, first.ID, dir, dir))
+	restored, err := OpenDbForReadOnly(db.opts, dir, false)
+	require.Nil(t, err)
+	v, err := restored.GetBytes(ro, []byte("a"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("1"), v)
+	v, err = restored.GetBytes(ro, []byte("b"))
+	require.Nil(t, err)
+	require.Nil(t, v)
+	restored.Close()
+
+	// corrupted files are not restored
+	for _, file := range second.Files {
+		if strings.HasPrefix(file.Name, "shared_checksum/") {
+			store.files[file.Name][0] ^= 0xff
+		}
+	}
+	require.Error(t, RestoreDBFromSource(store, second.ID, t.TempDir(), t.TempDir()))
+}
+
+func TestDirBackupStore(t *testing.T) {
+	t.Parallel()
+
+	db := newTestDB(t, nil)
+	defer db.Close()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+
+	for i := 0; i < 100; i++ {
+		require.Nil(t, db.Put(wo, []byte(fmt.Sprint(i)), []byte("value")))
+	}
+
+	store := NewDirBackupStore(t.TempDir())
+	names, err := store.List()
+	require.Nil(t, err)
+	require.Empty(t, names)
+
+	info, err := CreateBackupToSink(db, store)
+	require.Nil(t, err)
+	names, err = store.List()
+	require.Nil(t, err)
+	require.Len(t, names, len(info.Files)+1)
+	require.Contains(t, names, "meta/1")
+
+	dir := t.TempDir()
+	require.Nil(t, RestoreDBFromSource(store, info.ID, dir, dir))
+	restored, err := OpenDbForReadOnly(db.opts, dir, false)
+	require.Nil(t, err)
+	defer restored.Close()
+	v, err := restored.GetBytes(ro, []byte("42"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("value"), v)
+}
@@ -1,7 +1,11 @@
 package grocksdb
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -131,3 +135,168 @@ func TestBackupEngine(t *testing.T) {
 		}
 	}
 }
+
+func TestBackupEngineGetInfoWithFiles(t *testing.T) {
+	t.Parallel()
+
+	db := newTestDB(t, nil)
+	defer db.Close()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+
+	dir := t.TempDir()
+	engine, err := CreateBackupEngineWithPath(db, dir)
+	require.Nil(t, err)
+	defer engine.Close()
+
+	for i := 0; i < 2; i++ {
+		require.Nil(t, db.Put(wo, []byte(fmt.Sprint(i)), []byte("value")))
+		require.Nil(t, engine.CreateNewBackup())
+	}
+
+	infos, err := engine.GetInfoWithFiles()
+	require.Nil(t, err)
+	require.Len(t, infos, 2)
+	for _, info := range infos {
+		require.Nil(t, engine.VerifyBackup(info.ID))
+		require.Empty(t, info.AppMetadata)
+		require.Len(t, info.Files, int(info.NumFiles))
+		for _, file := range info.Files {
+			fi, err := os.Stat(filepath.Join(dir, file.Name))
+			require.Nil(t, err)
+			require.EqualValues(t, fi.Size(), file.Size)
+		}
+	}
+	require.Len(t, engine.GetInfo(), 2)
+}
+
+func TestParseBackupMeta(t *testing.T) {
+	t.Parallel()
+
+	meta, err := parseBackupMeta([]byte("1700000000\n42\n2\nprivate/1/MANIFEST-000005 crc32 123\nshared_checksum/000007_1_2.sst crc32 456\n"))
+	require.Nil(t, err)
+	require.Empty(t, meta.appMetadata)
+	require.Len(t, meta.files, 2)
+	require.Equal(t, "shared_checksum/000007_1_2.sst", meta.files[1].name)
+	require.EqualValues(t, 456, meta.files[1].checksum)
+	require.False(t, meta.files[1].hasSize)
+
+	meta, err = parseBackupMeta([]byte("schema_version 2\n1700000000\n42\nmetadata 746167\n1\nshared/000007.sst crc32 789 size 1024\n"))
+	require.Nil(t, err)
+	require.Equal(t, "tag", meta.appMetadata)
+	require.EqualValues(t, 1024, meta.files[0].size)
+
+	_, err = parseBackupMeta([]byte("garbage"))
+	require.Error(t, err)
+}
+
+func TestBackupEngineProgress(t *testing.T) {
+	t.Parallel()
+
+	db := newTestDB(t, nil)
+	defer db.Close()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	for i := 0; i < 1000; i++ {
+		require.Nil(t, db.Put(wo, []byte(fmt.Sprint(i)), []byte("value")))
+	}
+
+	engine, err := CreateBackupEngineWithPath(db, t.TempDir())
+	require.Nil(t, err)
+	defer engine.Close()
+	engine.SetProgressInterval(time.Millisecond)
+
+	var last BackupProgress
+	require.Nil(t, engine.CreateNewBackupWithProgress(true, func(progress BackupProgress) {
+		last = progress
+	}))
+	require.True(t, last.BytesCopied > 0)
+	require.NotZero(t, last.FilesCopied)
+	// the totals are the estimate of the live table files, kept once completed
+	require.True(t, last.TotalBytes > 0)
+	require.Equal(t, 1, last.TotalFiles)
+	require.Zero(t, last.ETA)
+
+	infos := engine.GetInfo()
+	require.Len(t, infos, 1)
+
+	ro := NewRestoreOptions()
+	defer ro.Destroy()
+
+	dir := t.TempDir()
+	require.Nil(t, engine.RestoreDBFromBackupWithProgress(dir, dir, ro, infos[0].ID, func(progress BackupProgress) {
+		last = progress
+	}))
+	require.EqualValues(t, infos[0].NumFiles, last.FilesCopied)
+	require.Equal(t, infos[0].Size, last.BytesCopied)
+
+	restored, err := OpenDb(db.opts, dir)
+	require.Nil(t, err)
+	restored.Close()
+}
+
+func TestRestoredPath(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, filepath.Join("db", "000007.sst"), restoredPath("db", "wal", "shared_checksum/000007_1234_5678.sst"))
+	require.Equal(t, filepath.Join("db", "000008.sst"), restoredPath("db", "wal", "shared_checksum/000008_sABCDEF.sst"))
+	require.Equal(t, filepath.Join("db", "000009.sst"), restoredPath("db", "wal", "shared/000009.sst"))
+	require.Equal(t, filepath.Join("wal", "000010.log"), restoredPath("db", "wal", "private/1/000010.log"))
+	require.Equal(t, filepath.Join("db", "MANIFEST-000005"), restoredPath("db", "wal", "private/1/MANIFEST-000005"))
+}
+
+func TestBackupEngineOpenAsReadOnlyDB(t *testing.T) {
+	t.Parallel()
+
+	db := newTestDB(t, nil)
+	defer db.Close()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	require.Nil(t, db.Put(wo, []byte("flushed"), []byte("1")))
+	fo := NewDefaultFlushOptions()
+	defer fo.Destroy()
+	require.Nil(t, db.Flush(fo))
+	require.Nil(t, db.Put(wo, []byte("logged"), []byte("2")))
+
+	dir := t.TempDir()
+	engine, err := CreateBackupEngineWithPath(db, dir)
+	require.Nil(t, err)
+	defer engine.Close()
+	require.Nil(t, engine.CreateNewBackup())
+	backupID := engine.GetInfo()[0].ID
+
+	backupDB, err := engine.OpenAsReadOnlyDB(backupID, db.opts)
+	require.Nil(t, err)
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+	for k, v := range map[string]string{"flushed": "1", "logged": "2"} {
+		value, err := backupDB.GetBytes(ro, []byte(k))
+		require.Nil(t, err)
+		require.Equal(t, v, string(value))
+	}
+	require.Error(t, backupDB.Put(wo, []byte("a"), []byte("b")))
+	linked := backupDB.Name()
+	backupDB.Close()
+	_, err = os.Stat(linked)
+	require.True(t, os.IsNotExist(err))
+
+	// corrupt a table file of the backup, keeping its size
+	require.Nil(t, engine.VerifyBackupWithChecksum(backupID))
+	infos, err := engine.GetInfoWithFiles()
+	require.Nil(t, err)
+	for _, file := range infos[0].Files {
+		if filepath.Ext(file.Name) == ".sst" {
+			name := filepath.Join(dir, file.Name)
+			data, err := os.ReadFile(name)
+			require.Nil(t, err)
+			data[0] ^= 0xff
+			require.Nil(t, os.Chmod(name, 0o600))
+			require.Nil(t, os.WriteFile(name, data, 0o600))
+		}
+	}
+	require.Nil(t, engine.VerifyBackup(backupID))
+	require.Error(t, engine.VerifyBackupWithChecksum(backupID))
+}
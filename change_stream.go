@@ -0,0 +1,419 @@
+package grocksdb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrWALGap is returned by change streams when the changes to resume from
+// are no longer in the WAL, or were written without WAL.
+var ErrWALGap = fmt.Errorf("NotFound: Changes missing from the WAL")
+
+// ErrTwoPhaseCommit is returned by change streams reading the commit of a
+// transaction prepared before the changes they stream, whose changes can't be
+// streamed. Polling the stream again skips the transaction.
+var ErrTwoPhaseCommit = fmt.Errorf("Incomplete: Commit of a transaction prepared before the stream")
+
+const defaultChangeStreamPollInterval = 100 * time.Millisecond
+
+// ChangeType is the type of a change of a ChangeStream.
+type ChangeType uint8
+
+const (
+	// ChangePut is a key written with Put.
+	ChangePut ChangeType = iota
+	// ChangeMerge is an operand merged with Merge.
+	ChangeMerge
+	// ChangeDelete is a key deleted with Delete.
+	ChangeDelete
+	// ChangeSingleDelete is a key deleted with SingleDelete.
+	ChangeSingleDelete
+	// ChangeDeleteRange is a range of keys deleted with DeleteRange.
+	ChangeDeleteRange
+	// ChangeLogData is a blob written with PutLogData.
+	ChangeLogData
+)
+
+// String returns the name of the change type.
+func (t ChangeType) String() string {
+	switch t {
+	case ChangePut:
+		return "Put"
+	case ChangeMerge:
+		return "Merge"
+	case ChangeDelete:
+		return "Delete"
+	case ChangeSingleDelete:
+		return "SingleDelete"
+	case ChangeDeleteRange:
+		return "DeleteRange"
+	case ChangeLogData:
+		return "LogData"
+	}
+	return fmt.Sprintf("ChangeType(%d)", uint8(t))
+}
+
+// ChangeEvent is a change decoded from the WAL.
+type ChangeEvent struct {
+	Type ChangeType
+	// Sequence is the sequence number of the change. Log data has no
+	// sequence number and has the one of the next change.
+	Sequence         uint64
+	ColumnFamilyID   uint32
+	ColumnFamilyName string
+	// Key is the key of the change, or the start key of range deletions.
+	Key []byte
+	// Value is the value of puts, the operand of merges, the end key of range
+	// deletions or the blob of log data.
+	Value []byte
+	// Resume is the sequence number to resume the stream from once the change
+	// is processed.
+	Resume uint64
+}
+
+// ChangeStream tails the WAL of a database from a sequence number, decoding
+// the written batches into changes. Changes are delivered at least once: a
+// stream resumed from the Resume of the last change processed may deliver
+// the log data before the next change again.
+//
+// The WAL must be kept long enough for the stream, see GetUpdatesSince.
+// Changes written without WAL are not streamed and make the stream fail
+// with ErrWALGap.
+//
+// Transactions prepared with Transaction.Prepare are written to the WAL when
+// prepared, and committed or rolled back later. Their changes are streamed
+// when committed, with the sequence numbers of the commit, and the resume
+// points stay before the prepared transactions until then, so that a stream
+// resumed from them reads the prepared changes again. A stream reading the
+// commit of a transaction prepared before the changes it streams fails with
+// ErrTwoPhaseCommit. As the batches of prepared transactions are written to
+// the WAL before their sequence numbers are allocated, the WAL iterator of
+// RocksDB may skip the batches following them, the stream then failing with
+// ErrWALGap or with the error of the iterator.
+type ChangeStream struct {
+	names map[uint32]string
+
+	mu           sync.Mutex
+	next         uint64
+	tail         walTail
+	pollInterval time.Duration
+
+	// prepared are the changes of the prepared transactions, by name.
+	prepared map[string]preparedChanges
+	// skipCommit is the name of the transaction whose unknown commit failed
+	// the stream, skipped when polled again. Its changes are of unknown count,
+	// so that the stream resumes from the next batch once it is skipped.
+	skipCommit string
+	skipped    bool
+}
+
+// preparedChanges are the changes of a prepared transaction, prepared at the
+// sequence number seq.
+type preparedChanges struct {
+	seq     uint64
+	records []WriteBatchRecord
+}
+
+// NewChangeStream returns a stream of the changes of the database from the
+// sequence number resume, 0 streaming the changes from the oldest in the
+// WAL. Changes are named after the given column families, and after the
+// default column family.
+func (db *DB) NewChangeStream(resume uint64, cfs ...*ColumnFamilyHandle) *ChangeStream {
+	names := map[uint32]string{0: "default"}
+	for _, cf := range cfs {
+		names[cf.ID()] = cf.Name()
+	}
+
+	return &ChangeStream{
+		names:        names,
+		next:         resume,
+		tail:         walTail{db: db},
+		pollInterval: defaultChangeStreamPollInterval,
+		prepared:     make(map[string]preparedChanges),
+	}
+}
+
+// SetPollInterval sets how long Run waits for new changes once it has
+// streamed all the changes.
+//
+// Default: 100ms
+func (s *ChangeStream) SetPollInterval(interval time.Duration) {
+	s.mu.Lock()
+	s.pollInterval = interval
+	s.mu.Unlock()
+}
+
+// Resume returns the sequence number to resume the stream from.
+func (s *ChangeStream) Resume() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.resume(s.next)
+}
+
+// resume returns the sequence number to resume from, next or the sequence
+// number of the oldest prepared transaction.
+func (s *ChangeStream) resume(next uint64) uint64 {
+	for _, changes := range s.prepared {
+		if changes.seq < next {
+			next = changes.seq
+		}
+	}
+	return next
+}
+
+// Poll calls fn with the changes written since the last changes streamed,
+// in order, and returns once it has streamed all of them. It stops at the
+// first error returned by fn, the change failing being streamed again.
+func (s *ChangeStream) Poll(fn func(event *ChangeEvent) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.tail.poll(func() uint64 { return s.next }, func(batch *WriteBatch, seq uint64) error {
+		return s.stream(batch, seq, fn)
+	})
+}
+
+// stream calls fn with the changes of the batch, whose first change has the
+// sequence number seq, which were not streamed yet. The changes of prepared
+// transactions are kept until their commit, whose batch has the changes of
+// the transaction after its commit marker, in the memtable only.
+func (s *ChangeStream) stream(batch *WriteBatch, seq uint64, fn func(event *ChangeEvent) error) error {
+	if s.skipped && seq > s.next {
+		s.next = seq
+		s.skipCommit, s.skipped = "", false
+	}
+	if s.next > 0 && seq > s.next {
+		return ErrWALGap
+	}
+
+	var (
+		preparing bool
+		prepared  []WriteBatchRecord
+	)
+
+	iter := batch.NewIterator()
+	for iter.Next() {
+		record := iter.Record()
+
+		switch record.Type {
+		case WriteBatchBeginPrepareXIDRecord, WriteBatchBeginPersistedPrepareXIDRecord:
+			preparing = true
+			continue
+		case WriteBatchEndPrepareXIDRecord:
+			s.prepared[string(record.Value)] = preparedChanges{seq: seq, records: prepared}
+			preparing, prepared = false, nil
+			continue
+		case WriteBatchRollbackXIDRecord:
+			delete(s.prepared, string(record.Value))
+			continue
+		case WriteBatchCommitXIDRecord:
+			changes, ok := s.prepared[string(record.Value)]
+			if !ok {
+				if seq < s.next {
+					// committed changes already streamed
+					continue
+				}
+				if s.skipCommit == string(record.Value) {
+					s.skipped = true
+					continue
+				}
+				s.next, s.skipCommit = seq, string(record.Value)
+				return fmt.Errorf("%w: %q", ErrTwoPhaseCommit, record.Value)
+			}
+			delete(s.prepared, string(record.Value))
+			for i := range changes.records {
+				if err := s.emit(&changes.records[i], &seq, fn); err != nil {
+					// streamed again from the prepared changes
+					s.prepared[string(record.Value)] = changes
+					return err
+				}
+			}
+			continue
+		case WriteBatchNoopRecord:
+			continue
+		}
+
+		if preparing {
+			prepared = append(prepared, WriteBatchRecord{
+				CF:    record.CF,
+				Key:   append([]byte(nil), record.Key...),
+				Value: append([]byte(nil), record.Value...),
+				Type:  record.Type,
+			})
+			continue
+		}
+		if err := s.emit(record, &seq, fn); err != nil {
+			return err
+		}
+	}
+	return iter.Error()
+}
+
+// emit calls fn with the change of the record, of sequence number seq, unless
+// already streamed, and advances seq past the change.
+func (s *ChangeStream) emit(record *WriteBatchRecord, seq *uint64, fn func(event *ChangeEvent) error) error {
+	event := ChangeEvent{
+		Sequence:       *seq,
+		ColumnFamilyID: uint32(record.CF),
+		Resume:         *seq + 1,
+	}
+	event.ColumnFamilyName = s.names[event.ColumnFamilyID]
+
+	switch record.Type {
+	case WriteBatchValueRecord, WriteBatchCFValueRecord:
+		event.Type = ChangePut
+	case WriteBatchMergeRecord, WriteBatchCFMergeRecord:
+		event.Type = ChangeMerge
+	case WriteBatchDeletionRecord, WriteBatchCFDeletionRecord:
+		event.Type = ChangeDelete
+	case WriteBatchSingleDeletionRecord, WriteBatchCFSingleDeletionRecord:
+		event.Type = ChangeSingleDelete
+	case WriteBatchRangeDeletion, WriteBatchCFRangeDeletion:
+		event.Type = ChangeDeleteRange
+	case WriteBatchLogDataRecord:
+		event.Type = ChangeLogData
+		event.Resume = *seq
+	case WriteBatchBlobIndex, WriteBatchCFBlobIndex:
+		// values written to blob files are not streamed
+		if *seq >= s.next {
+			s.next = *seq + 1
+		}
+		*seq++
+		return nil
+	default:
+		return fmt.Errorf("Not implemented: Write batch record of type %d", record.Type)
+	}
+
+	if event.Type != ChangeLogData {
+		*seq++
+	}
+	if event.Sequence < s.next {
+		return nil
+	}
+
+	event.Key = append([]byte(nil), record.Key...)
+	event.Value = append([]byte(nil), record.Value...)
+	next := event.Resume
+	event.Resume = s.resume(next)
+	if err := fn(&event); err != nil {
+		return err
+	}
+	if next > s.next {
+		s.next = next
+	}
+	return nil
+}
+
+// Run streams the changes to fn, waiting for new changes once it has
+// streamed all of them, until ctx is done or fn returns an error.
+func (s *ChangeStream) Run(ctx context.Context, fn func(event *ChangeEvent) error) error {
+	for {
+		if err := s.Poll(fn); err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		interval := s.pollInterval
+		s.mu.Unlock()
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Events streams the changes to the returned channel of the given buffer
+// size, until ctx is done or the stream fails. The channel of changes is
+// then closed, and the error of the stream is sent to the channel of
+// errors.
+func (s *ChangeStream) Events(ctx context.Context, buffer int) (<-chan ChangeEvent, <-chan error) {
+	events := make(chan ChangeEvent, buffer)
+	errs := make(chan error, 1)
+
+	go func() {
+		errs <- s.Run(ctx, func(event *ChangeEvent) error {
+			select {
+			case events <- *event:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		close(events)
+		close(errs)
+	}()
+	return events, errs
+}
+
+// Close releases the WAL iterator of the stream.
+func (s *ChangeStream) Close() {
+	s.mu.Lock()
+	s.tail.close()
+	s.mu.Unlock()
+}
+
+// walTail tails the WAL of a database, reopening the WAL iterator as it
+// ends.
+type walTail struct {
+	db   *DB
+	iter *WalIterator
+}
+
+// poll calls fn with the batches written to the WAL from the sequence number
+// returned by next, and returns once no batch makes next progress.
+func (w *walTail) poll(next func() uint64, fn func(batch *WriteBatch, seq uint64) error) error {
+	var progress bool
+	for {
+		if w.iter == nil {
+			if next() > w.db.GetLatestSequenceNumber() {
+				return nil
+			}
+
+			iter, err := w.db.GetUpdatesSince(next())
+			if err != nil {
+				return err
+			}
+			w.iter, progress = iter, false
+		}
+
+		// the iterator ends at the end of the WAL written so far, or of a WAL
+		// file once it is rolled over, then is reopened
+		if !w.iter.Valid() {
+			err := w.iter.Err()
+			w.close()
+
+			if !progress {
+				if err != nil && !strings.HasPrefix(err.Error(), tryAgainStatusPrefix) {
+					return err
+				}
+				return nil
+			}
+			continue
+		}
+
+		batch, seq := w.iter.GetBatch()
+		prev := next()
+		err := fn(batch, seq)
+		batch.Destroy()
+		if err != nil {
+			return err
+		}
+
+		progress = progress || next() > prev
+		w.iter.Next()
+	}
+}
+
+func (w *walTail) close() {
+	if w.iter != nil {
+		w.iter.Destroy()
+		w.iter = nil
+	}
+}
@@ -0,0 +1,196 @@
+package grocksdb
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeStream(t *testing.T) {
+	t.Parallel()
+
+	db := newTestDB(t, func(opts *Options) {
+		opts.SetWALTtlSeconds(3600)
+		opts.SetMergeOperator(&mockMergeOperator{
+			fullMerge: func(_, existingValue []byte, operands [][]byte) ([]byte, bool) {
+				return existingValue, true
+			},
+		})
+	})
+	defer db.Close()
+
+	opts := NewDefaultOptions()
+	defer opts.Destroy()
+	cf, err := db.CreateColumnFamily(opts, "other")
+	require.Nil(t, err)
+	defer cf.Destroy()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+
+	start := db.GetLatestSequenceNumber() + 1
+	require.Nil(t, db.Put(wo, []byte("a"), []byte("1")))
+	batch := NewWriteBatch()
+	batch.PutCF(cf, []byte("b"), []byte("2"))
+	batch.Merge([]byte("a"), []byte("3"))
+	batch.PutLogData([]byte("position"))
+	batch.Delete([]byte("c"))
+	batch.SingleDelete([]byte("d"))
+	batch.DeleteRange([]byte("e"), []byte("f"))
+	require.Nil(t, db.Write(wo, batch))
+	batch.Destroy()
+
+	stream := db.NewChangeStream(start, cf)
+	defer stream.Close()
+
+	var events []ChangeEvent
+	collect := func(event *ChangeEvent) error {
+		events = append(events, *event)
+		return nil
+	}
+	require.Nil(t, stream.Poll(collect))
+
+	type change struct {
+		Type       ChangeType
+		Sequence   uint64
+		CF         string
+		Key, Value string
+	}
+	var changes []change
+	for _, event := range events {
+		changes = append(changes, change{event.Type, event.Sequence - start, event.ColumnFamilyName, string(event.Key), string(event.Value)})
+	}
+	require.Equal(t, []change{
+		{ChangePut, 0, "default", "a", "1"},
+		{ChangePut, 1, "other", "b", "2"},
+		{ChangeMerge, 2, "default", "a", "3"},
+		{ChangeLogData, 3, "default", "", "position"},
+		{ChangeDelete, 3, "default", "c", ""},
+		{ChangeSingleDelete, 4, "default", "d", ""},
+		{ChangeDeleteRange, 5, "default", "e", "f"},
+	}, changes)
+	require.Equal(t, events[3].Sequence, events[3].Resume)
+	require.Equal(t, events[6].Sequence+1, stream.Resume())
+
+	// resuming in the middle of a batch
+	resumed := db.NewChangeStream(events[2].Resume)
+	defer resumed.Close()
+	var first *ChangeEvent
+	require.Nil(t, resumed.Poll(func(event *ChangeEvent) error {
+		if first == nil {
+			first = event
+		}
+		return nil
+	}))
+	require.Equal(t, ChangeLogData, first.Type)
+
+	// failed changes are streamed again
+	require.Nil(t, db.Put(wo, []byte("g"), []byte("4")))
+	failure := fmt.Errorf("failure")
+	require.Equal(t, failure, stream.Poll(func(*ChangeEvent) error { return failure }))
+	events = events[:0]
+	require.Nil(t, stream.Poll(collect))
+	require.Len(t, events, 1)
+	require.Equal(t, []byte("g"), events[0].Key)
+
+	// the stream follows the rolled over WAL
+	fo := NewDefaultFlushOptions()
+	defer fo.Destroy()
+	require.Nil(t, db.Flush(fo))
+	require.Nil(t, db.Put(wo, []byte("h"), []byte("5")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream.SetPollInterval(time.Millisecond)
+	changesCh, errs := stream.Events(ctx, 0)
+	event := <-changesCh
+	require.Equal(t, []byte("h"), event.Key)
+
+	require.Nil(t, db.Put(wo, []byte("i"), []byte("6")))
+	event = <-changesCh
+	require.Equal(t, []byte("i"), event.Key)
+
+	cancel()
+	for range changesCh {
+	}
+	require.Equal(t, context.Canceled, <-errs)
+}
+
+func TestChangeStreamTwoPhaseCommit(t *testing.T) {
+	t.Parallel()
+
+	// batches as written to the WAL by transactions, whose changes are in the
+	// prepared batch, and in the memtable only once committed
+	batch := func(seq uint64, count uint32, records ...[]byte) *WriteBatch {
+		data := make([]byte, 12)
+		binary.LittleEndian.PutUint64(data, seq)
+		binary.LittleEndian.PutUint32(data[8:], count)
+		for _, record := range records {
+			data = append(data, record...)
+		}
+		return WriteBatchFrom(data)
+	}
+	put := func(key, value string) []byte {
+		record := append([]byte{byte(WriteBatchValueRecord), byte(len(key))}, key...)
+		return append(append(record, byte(len(value))), value...)
+	}
+	marker := func(typ WriteBatchRecordType, name string) []byte {
+		record := []byte{byte(typ)}
+		if name != "" {
+			record = append(append(record, byte(len(name))), name...)
+		}
+		return record
+	}
+	prepare := func(seq uint64, name string, records ...[]byte) *WriteBatch {
+		records = append([][]byte{marker(WriteBatchBeginPrepareXIDRecord, "")}, records...)
+		records = append(records, marker(WriteBatchEndPrepareXIDRecord, name))
+		return batch(seq, uint32(len(records)-2), records...)
+	}
+
+	stream := &ChangeStream{
+		names:    map[uint32]string{0: "default"},
+		next:     10,
+		prepared: make(map[string]preparedChanges),
+	}
+	var events []ChangeEvent
+	collect := func(event *ChangeEvent) error {
+		events = append(events, *event)
+		return nil
+	}
+
+	// committed changes are streamed with the sequence numbers of the commit,
+	// and resume points stay before the prepared transactions until then
+	require.Nil(t, stream.stream(prepare(10, "t1", put("a", "1")), 10, collect))
+	require.Empty(t, events)
+	require.EqualValues(t, 10, stream.Resume())
+	require.Nil(t, stream.stream(batch(10, 1, put("b", "2")), 10, collect))
+	require.Nil(t, stream.stream(batch(11, 0, marker(WriteBatchCommitXIDRecord, "t1")), 11, collect))
+	require.Len(t, events, 2)
+	require.Equal(t, []byte("b"), events[0].Key)
+	require.EqualValues(t, 10, events[0].Sequence)
+	require.EqualValues(t, 10, events[0].Resume)
+	require.Equal(t, []byte("a"), events[1].Key)
+	require.Equal(t, []byte("1"), events[1].Value)
+	require.EqualValues(t, 11, events[1].Sequence)
+	require.EqualValues(t, 12, events[1].Resume)
+	require.EqualValues(t, 12, stream.Resume())
+
+	// rolled back changes are not streamed
+	require.Nil(t, stream.stream(prepare(12, "t2", put("c", "3")), 12, collect))
+	require.Nil(t, stream.stream(batch(12, 0, marker(WriteBatchRollbackXIDRecord, "t2")), 12, collect))
+	require.Len(t, events, 2)
+	require.EqualValues(t, 12, stream.Resume())
+
+	// the commit of a transaction prepared before the stream fails it, and is
+	// skipped when polled again
+	commit := batch(12, 0, marker(WriteBatchCommitXIDRecord, "t0"))
+	require.ErrorIs(t, stream.stream(commit, 12, collect), ErrTwoPhaseCommit)
+	require.Nil(t, stream.stream(commit, 12, collect))
+	require.Nil(t, stream.stream(batch(14, 1, put("d", "4")), 14, collect))
+	require.Len(t, events, 3)
+	require.Equal(t, []byte("d"), events[2].Key)
+	require.EqualValues(t, 15, stream.Resume())
+}
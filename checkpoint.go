@@ -2,20 +2,41 @@ package grocksdb
 
 // #include <stdlib.h>
 // #include "rocksdb/c.h"
+// #include "grocksdb.h"
 import "C"
 
 import (
+	"os"
+	"path/filepath"
 	"unsafe"
 )
 
 // Checkpoint provides persistent snapshots of RocksDB databases.
 type Checkpoint struct {
 	c *C.rocksdb_checkpoint_t
+	// name and base database of the database
+	dbName string
+	db     *DB
 }
 
 // NewNativeCheckpoint creates a new checkpoint.
-func newNativeCheckpoint(c *C.rocksdb_checkpoint_t) *Checkpoint {
-	return &Checkpoint{c: c}
+func newNativeCheckpoint(c *C.rocksdb_checkpoint_t, dbName string, db *DB) *Checkpoint {
+	return &Checkpoint{c: c, dbName: dbName, db: db}
+}
+
+// CheckpointInfo is the information about a checkpoint created by
+// CreateCheckpointWithSequence.
+type CheckpointInfo struct {
+	// Sequence is the latest sequence number of the database as the
+	// checkpoint is created: the checkpoint has the changes up to it, and
+	// the changes written concurrently with its creation, if any.
+	Sequence uint64
+	// LinkedFiles are the files of the checkpoint hard-linked to the files of
+	// the database.
+	LinkedFiles []string
+	// CopiedFiles are the other files of the checkpoint, copied or written,
+	// such as the MANIFEST, OPTIONS and WAL files.
+	CopiedFiles []string
 }
 
 // CreateCheckpoint builds an openable snapshot of RocksDB on the same disk, which
@@ -42,6 +63,94 @@ func (checkpoint *Checkpoint) CreateCheckpoint(checkpointDir string, logSizeForF
 	return err
 }
 
+// CreateCheckpointWithSequence creates a checkpoint as CreateCheckpoint,
+// returning its sequence number, from which replicas bootstrapped from the
+// checkpoint resume tailing the WAL of the database, and its files. The
+// sequence number is read with file deletions disabled, right before the
+// checkpoint is created.
+//
+// When column families are given, the table files of the other column
+// families, except the default column family, are left out of the files
+// returned. The checkpoint itself has all the column families.
+func (checkpoint *Checkpoint) CreateCheckpointWithSequence(
+	checkpointDir string,
+	logSizeForFlush uint64,
+	cfs ...*ColumnFamilyHandle,
+) (info CheckpointInfo, err error) {
+	if err = checkpoint.db.DisableFileDeletions(); err != nil {
+		return
+	}
+	defer func() {
+		if eerr := checkpoint.db.EnableFileDeletions(); err == nil {
+			err = eerr
+		}
+	}()
+
+	info.Sequence = checkpoint.db.GetLatestSequenceNumber()
+	if err = checkpoint.CreateCheckpoint(checkpointDir, logSizeForFlush); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = os.RemoveAll(checkpointDir)
+		}
+	}()
+
+	// column families of the table files, listed while they can't be deleted
+	var skipped map[string]struct{}
+	if len(cfs) > 0 {
+		keep := map[string]struct{}{"default": {}}
+		for _, cf := range cfs {
+			keep[cf.Name()] = struct{}{}
+		}
+		skipped = make(map[string]struct{})
+		for _, file := range checkpoint.db.GetLiveFilesMetaData() {
+			if _, ok := keep[file.ColumnFamilyName]; !ok {
+				skipped[filepath.Base(file.Name)] = struct{}{}
+			}
+		}
+	}
+
+	entries, err := os.ReadDir(checkpointDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if _, ok := skipped[entry.Name()]; ok {
+			continue
+		}
+
+		linked, err := sameFile(filepath.Join(checkpointDir, entry.Name()), filepath.Join(checkpoint.dbName, entry.Name()))
+		if err != nil {
+			return info, err
+		}
+
+		if linked {
+			info.LinkedFiles = append(info.LinkedFiles, entry.Name())
+		} else {
+			info.CopiedFiles = append(info.CopiedFiles, entry.Name())
+		}
+	}
+	return
+}
+
+// sameFile returns whether the files are the same file, false if the second
+// file does not exist.
+func sameFile(name1, name2 string) (bool, error) {
+	fi1, err := os.Stat(name1)
+	if err != nil {
+		return false, err
+	}
+	fi2, err := os.Stat(name2)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return os.SameFile(fi1, fi2), nil
+}
+
 // ExportColumnFamily exports all live SST files of a specified Column Family onto export_dir,
 // returning SST files information in metadata.
 //   - SST files will be created as hard links when the directory specified
@@ -63,6 +172,24 @@ func (checkpoint *Checkpoint) ExportColumnFamily(cf *ColumnFamilyHandle, exportD
 	return metadata, err
 }
 
+// ExportColumnFamilyTo exports all live SST files of a specified Column Family
+// onto exportDir, as ExportColumnFamily does, and writes their metadata to
+// exportDir too, so that exportDir can be moved to another host and imported
+// with ImportColumnFamilyFrom.
+func (checkpoint *Checkpoint) ExportColumnFamilyTo(cf *ColumnFamilyHandle, exportDir string) (err error) {
+	metadata, err := checkpoint.ExportColumnFamily(cf, exportDir)
+	if err != nil {
+		return
+	}
+	defer metadata.Destroy()
+
+	data, err := metadata.MarshalJSON()
+	if err == nil {
+		err = os.WriteFile(filepath.Join(exportDir, ExportMetadataFileName), data, 0o644)
+	}
+	return
+}
+
 // Destroy deallocates the Checkpoint object.
 func (checkpoint *Checkpoint) Destroy() {
 	C.rocksdb_checkpoint_object_destroy(checkpoint.c)
@@ -2,6 +2,7 @@ package grocksdb
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -52,3 +53,78 @@ func TestCheckpoint(t *testing.T) {
 		value.Free()
 	}
 }
+
+func TestCheckpointWithSequence(t *testing.T) {
+	t.Parallel()
+
+	db, cfh, cleanup := newTestDBMultiCF(t, []string{"default", "a", "b"}, nil)
+	defer cleanup()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	for _, cf := range cfh {
+		require.Nil(t, db.PutCF(wo, cf, []byte("key"), []byte("val")))
+	}
+
+	checkpoint, err := db.NewCheckpoint()
+	require.Nil(t, err)
+	defer checkpoint.Destroy()
+
+	dir := filepath.Join(t.TempDir(), "checkpoint")
+	info, err := checkpoint.CreateCheckpointWithSequence(dir, 0)
+	require.Nil(t, err)
+	require.Equal(t, db.GetLatestSequenceNumber(), info.Sequence)
+	require.Len(t, info.LinkedFiles, 3)
+	for _, name := range info.LinkedFiles {
+		require.Equal(t, ".sst", filepath.Ext(name))
+	}
+	require.Contains(t, info.CopiedFiles, "CURRENT")
+
+	// checkpoint of a subset of the column families
+	require.Nil(t, db.PutCF(wo, cfh[1], []byte("key2"), []byte("val")))
+	dir = filepath.Join(t.TempDir(), "checkpoint")
+	info, err = checkpoint.CreateCheckpointWithSequence(dir, 0, cfh[1])
+	require.Nil(t, err)
+	require.Equal(t, db.GetLatestSequenceNumber(), info.Sequence)
+
+	// the table files of the other column families are left out
+	cfNames := make(map[string]string)
+	for _, file := range db.GetLiveFilesMetaData() {
+		cfNames[filepath.Base(file.Name)] = file.ColumnFamilyName
+	}
+	require.Len(t, info.LinkedFiles, 3)
+	for _, name := range info.LinkedFiles {
+		require.Contains(t, []string{"default", "a"}, cfNames[name])
+	}
+
+	// the checkpoint has all the column families
+	opts := NewDefaultOptions()
+	defer opts.Destroy()
+	names, err := ListColumnFamilies(opts, dir)
+	require.Nil(t, err)
+	require.ElementsMatch(t, []string{"default", "a", "b"}, names)
+}
+
+func TestCheckpointWithSequenceTransactionDB(t *testing.T) {
+	t.Parallel()
+
+	db := newTestTransactionDB(t, nil)
+	defer db.Close()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	require.Nil(t, db.Put(wo, []byte("key"), []byte("val")))
+
+	checkpoint, err := db.NewCheckpoint()
+	require.Nil(t, err)
+	defer checkpoint.Destroy()
+
+	// the sequence number is that of the base database
+	info, err := checkpoint.CreateCheckpointWithSequence(filepath.Join(t.TempDir(), "checkpoint"), 1<<30)
+	require.Nil(t, err)
+	base := db.GetBaseDB()
+	defer CloseBaseDBOfTransactionDB(base)
+	require.Equal(t, base.GetLatestSequenceNumber(), info.Sequence)
+	require.NotZero(t, info.Sequence)
+	require.Contains(t, info.CopiedFiles, "CURRENT")
+}
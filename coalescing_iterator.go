@@ -0,0 +1,314 @@
+package grocksdb
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ErrNoColumnFamilies indicates that a multi column family iterator was requested
+// without any column family.
+var ErrNoColumnFamilies = fmt.Errorf("at least one column family must be provided")
+
+// multiCFIterator merges iterators of multiple column families, which are
+// created from a consistent database state, into a single key ordered stream.
+//
+// All column families must use the default bytewise comparator.
+type multiCFIterator struct {
+	cfs   []*ColumnFamilyHandle
+	iters []*Iterator
+
+	// key at which the iterator is positioned, copied into Go memory.
+	key []byte
+	// indexes of child iterators positioned at key, in column family order.
+	current []int
+	forward bool
+}
+
+func newMultiCFIterator(db *DB, opts *ReadOptions, cfs []*ColumnFamilyHandle) (*multiCFIterator, error) {
+	if len(cfs) == 0 {
+		return nil, ErrNoColumnFamilies
+	}
+
+	iters, err := db.NewIterators(opts, cfs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &multiCFIterator{
+		cfs:     cfs,
+		iters:   iters,
+		current: make([]int, 0, len(iters)),
+		forward: true,
+	}, nil
+}
+
+// Valid returns false only when the iterator has iterated past either the
+// first or the last key of all column families.
+func (iter *multiCFIterator) Valid() bool {
+	return len(iter.current) > 0
+}
+
+// ValidForPrefix returns false only when the iterator has iterated past the
+// first or the last key of all column families or the specified prefix.
+func (iter *multiCFIterator) ValidForPrefix(prefix []byte) bool {
+	return iter.Valid() && bytes.HasPrefix(iter.key, prefix)
+}
+
+// Key returns the key the iterator currently holds.
+//
+// The returned slice is only valid until the next move of the iterator.
+func (iter *multiCFIterator) Key() []byte {
+	if !iter.Valid() {
+		return nil
+	}
+	return iter.key
+}
+
+// SeekToFirst moves the iterator to the first key of all column families.
+func (iter *multiCFIterator) SeekToFirst() {
+	for _, it := range iter.iters {
+		it.SeekToFirst()
+	}
+	iter.forward = true
+	iter.findSmallest()
+}
+
+// SeekToLast moves the iterator to the last key of all column families.
+func (iter *multiCFIterator) SeekToLast() {
+	for _, it := range iter.iters {
+		it.SeekToLast()
+	}
+	iter.forward = false
+	iter.findLargest()
+}
+
+// Seek moves the iterator to the position greater than or equal to the key.
+func (iter *multiCFIterator) Seek(key []byte) {
+	for _, it := range iter.iters {
+		it.Seek(key)
+	}
+	iter.forward = true
+	iter.findSmallest()
+}
+
+// SeekForPrev moves the iterator to the last key that less than or equal
+// to the target key, in contrast with Seek.
+func (iter *multiCFIterator) SeekForPrev(key []byte) {
+	for _, it := range iter.iters {
+		it.SeekForPrev(key)
+	}
+	iter.forward = false
+	iter.findLargest()
+}
+
+// Next moves the iterator to the next key.
+func (iter *multiCFIterator) Next() {
+	if !iter.Valid() {
+		return
+	}
+
+	if !iter.forward {
+		// children which are not at the current key are behind it,
+		// reposition them to the first key after it.
+		for i, it := range iter.iters {
+			if !iter.isCurrent(i) {
+				it.Seek(iter.key)
+				if it.Valid() && bytes.Equal(it.Key().Data(), iter.key) {
+					it.Next()
+				}
+			}
+		}
+		iter.forward = true
+	}
+
+	for _, i := range iter.current {
+		iter.iters[i].Next()
+	}
+	iter.findSmallest()
+}
+
+// Prev moves the iterator to the previous key.
+func (iter *multiCFIterator) Prev() {
+	if !iter.Valid() {
+		return
+	}
+
+	if iter.forward {
+		// children which are not at the current key are ahead of it,
+		// reposition them to the last key before it.
+		for i, it := range iter.iters {
+			if !iter.isCurrent(i) {
+				it.SeekForPrev(iter.key)
+				if it.Valid() && bytes.Equal(it.Key().Data(), iter.key) {
+					it.Prev()
+				}
+			}
+		}
+		iter.forward = false
+	}
+
+	for _, i := range iter.current {
+		iter.iters[i].Prev()
+	}
+	iter.findLargest()
+}
+
+// Err returns nil if no errors happened during iteration, or the first
+// error of underlying column family iterators otherwise.
+func (iter *multiCFIterator) Err() error {
+	for _, it := range iter.iters {
+		if err := it.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Refresh updates underlying iterators to the latest DB state.
+// The iterator will be invalidated after the call, a Seek*() function
+// must be called to get it back into a valid state.
+func (iter *multiCFIterator) Refresh() error {
+	iter.current = iter.current[:0]
+	for _, it := range iter.iters {
+		if err := it.Refresh(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the iterator and all underlying column family iterators.
+func (iter *multiCFIterator) Close() {
+	for _, it := range iter.iters {
+		it.Close()
+	}
+	iter.iters = nil
+	iter.current = nil
+}
+
+func (iter *multiCFIterator) isCurrent(i int) bool {
+	for _, j := range iter.current {
+		if i == j {
+			return true
+		}
+	}
+	return false
+}
+
+func (iter *multiCFIterator) findSmallest() {
+	iter.find(func(c int) bool { return c < 0 })
+}
+
+func (iter *multiCFIterator) findLargest() {
+	iter.find(func(c int) bool { return c > 0 })
+}
+
+// find collects children positioned at the best key, according to better.
+func (iter *multiCFIterator) find(better func(c int) bool) {
+	iter.current = iter.current[:0]
+
+	var best []byte
+	for i, it := range iter.iters {
+		if !it.Valid() {
+			continue
+		}
+
+		key := it.Key().Data()
+		if len(iter.current) == 0 {
+			best = key
+			iter.current = append(iter.current, i)
+			continue
+		}
+
+		c := bytes.Compare(key, best)
+		switch {
+		case c == 0:
+			iter.current = append(iter.current, i)
+		case better(c):
+			best = key
+			iter.current = append(iter.current[:0], i)
+		}
+	}
+
+	iter.key = append(iter.key[:0], best...)
+}
+
+// CoalescingIterator iterates over multiple column families as if they were
+// a single one. Each key is returned once; when it exists in several column
+// families, the value of the column family coming last in the list given at
+// creation wins, as RocksDB does for its own coalescing iterator.
+//
+// All column families must use the default bytewise comparator.
+type CoalescingIterator struct {
+	*multiCFIterator
+}
+
+// NewCoalescingIterator returns a CoalescingIterator over the given column
+// families, created from a consistent database state. Column families are
+// listed in increasing priority order.
+func (db *DB) NewCoalescingIterator(opts *ReadOptions, cfs []*ColumnFamilyHandle) (*CoalescingIterator, error) {
+	iter, err := newMultiCFIterator(db, opts, cfs)
+	if err != nil {
+		return nil, err
+	}
+	return &CoalescingIterator{multiCFIterator: iter}, nil
+}
+
+// Value returns the value of the highest priority column family containing
+// the current key.
+func (iter *CoalescingIterator) Value() *Slice {
+	if !iter.Valid() {
+		return nil
+	}
+	return iter.iters[iter.current[len(iter.current)-1]].Value()
+}
+
+// ColumnFamily returns the column family which the current value comes from.
+func (iter *CoalescingIterator) ColumnFamily() *ColumnFamilyHandle {
+	if !iter.Valid() {
+		return nil
+	}
+	return iter.cfs[iter.current[len(iter.current)-1]]
+}
+
+// AttributeGroup is the value of a key within a column family.
+type AttributeGroup struct {
+	ColumnFamily *ColumnFamilyHandle
+	Value        *Slice
+}
+
+// AttributeGroupIterator iterates over multiple column families as if they
+// were a single one. Each key is returned once together with its values
+// from all column families containing it.
+//
+// All column families must use the default bytewise comparator.
+type AttributeGroupIterator struct {
+	*multiCFIterator
+}
+
+// NewAttributeGroupIterator returns an AttributeGroupIterator over the given
+// column families, created from a consistent database state.
+func (db *DB) NewAttributeGroupIterator(opts *ReadOptions, cfs []*ColumnFamilyHandle) (*AttributeGroupIterator, error) {
+	iter, err := newMultiCFIterator(db, opts, cfs)
+	if err != nil {
+		return nil, err
+	}
+	return &AttributeGroupIterator{multiCFIterator: iter}, nil
+}
+
+// AttributeGroups returns the values of the current key, one for each column
+// family containing it, in the column family order given at creation.
+func (iter *AttributeGroupIterator) AttributeGroups() []AttributeGroup {
+	if !iter.Valid() {
+		return nil
+	}
+
+	groups := make([]AttributeGroup, len(iter.current))
+	for i, j := range iter.current {
+		groups[i] = AttributeGroup{
+			ColumnFamily: iter.cfs[j],
+			Value:        iter.iters[j].Value(),
+		}
+	}
+	return groups
+}
@@ -0,0 +1,113 @@
+package grocksdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoalescingIterator(t *testing.T) {
+	t.Parallel()
+
+	db, cfs, cleanup := newTestDBMultiCF(t, []string{"default", "hot", "cold"}, nil)
+	defer cleanup()
+
+	wo := NewDefaultWriteOptions()
+	require.Nil(t, db.PutCF(wo, cfs[1], []byte("key1"), []byte("hot1")))
+	require.Nil(t, db.PutCF(wo, cfs[2], []byte("key1"), []byte("cold1")))
+	require.Nil(t, db.PutCF(wo, cfs[1], []byte("key2"), []byte("hot2")))
+	require.Nil(t, db.PutCF(wo, cfs[2], []byte("key3"), []byte("cold3")))
+	require.Nil(t, db.PutCF(wo, cfs[2], []byte("key4"), []byte("cold4")))
+	require.Nil(t, db.PutCF(wo, cfs[1], []byte("key4"), []byte("hot4")))
+
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+
+	_, err := db.NewCoalescingIterator(ro, nil)
+	require.ErrorIs(t, err, ErrNoColumnFamilies)
+
+	iter, err := db.NewCoalescingIterator(ro, []*ColumnFamilyHandle{cfs[1], cfs[2]})
+	require.Nil(t, err)
+	defer iter.Close()
+
+	var keys, values []string
+	for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+		keys = append(keys, string(iter.Key()))
+		values = append(values, string(iter.Value().Data()))
+	}
+	require.Nil(t, iter.Err())
+	require.EqualValues(t, []string{"key1", "key2", "key3", "key4"}, keys)
+	require.EqualValues(t, []string{"cold1", "hot2", "cold3", "cold4"}, values)
+
+	keys, values = keys[:0], values[:0]
+	for iter.SeekToLast(); iter.Valid(); iter.Prev() {
+		keys = append(keys, string(iter.Key()))
+		values = append(values, string(iter.Value().Data()))
+	}
+	require.Nil(t, iter.Err())
+	require.EqualValues(t, []string{"key4", "key3", "key2", "key1"}, keys)
+	require.EqualValues(t, []string{"cold4", "cold3", "hot2", "cold1"}, values)
+
+	// change direction in the middle of the key space
+	iter.Seek([]byte("key2"))
+	require.True(t, iter.Valid())
+	require.EqualValues(t, "key2", iter.Key())
+	require.Equal(t, cfs[1].ID(), iter.ColumnFamily().ID())
+	iter.Next()
+	require.EqualValues(t, "key3", iter.Key())
+	iter.Prev()
+	require.EqualValues(t, "key2", iter.Key())
+	iter.Prev()
+	require.EqualValues(t, "key1", iter.Key())
+	iter.Next()
+	require.EqualValues(t, "key2", iter.Key())
+
+	iter.SeekForPrev([]byte("key35"))
+	require.True(t, iter.ValidForPrefix([]byte("key3")))
+	iter.Prev()
+	iter.Prev()
+	iter.Prev()
+	require.False(t, iter.Valid())
+}
+
+func TestAttributeGroupIterator(t *testing.T) {
+	t.Parallel()
+
+	db, cfs, cleanup := newTestDBMultiCF(t, []string{"default", "hot", "cold"}, nil)
+	defer cleanup()
+
+	wo := NewDefaultWriteOptions()
+	require.Nil(t, db.PutCF(wo, cfs[1], []byte("key1"), []byte("hot1")))
+	require.Nil(t, db.PutCF(wo, cfs[2], []byte("key1"), []byte("cold1")))
+	require.Nil(t, db.PutCF(wo, cfs[2], []byte("key2"), []byte("cold2")))
+
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+
+	iter, err := db.NewAttributeGroupIterator(ro, []*ColumnFamilyHandle{cfs[1], cfs[2]})
+	require.Nil(t, err)
+	defer iter.Close()
+
+	iter.SeekToFirst()
+	require.True(t, iter.Valid())
+	require.EqualValues(t, "key1", iter.Key())
+	groups := iter.AttributeGroups()
+	require.Len(t, groups, 2)
+	require.Equal(t, cfs[1].ID(), groups[0].ColumnFamily.ID())
+	require.EqualValues(t, "hot1", groups[0].Value.Data())
+	require.Equal(t, cfs[2].ID(), groups[1].ColumnFamily.ID())
+	require.EqualValues(t, "cold1", groups[1].Value.Data())
+
+	iter.Next()
+	require.True(t, iter.Valid())
+	require.EqualValues(t, "key2", iter.Key())
+	groups = iter.AttributeGroups()
+	require.Len(t, groups, 1)
+	require.Equal(t, cfs[2].ID(), groups[0].ColumnFamily.ID())
+	require.EqualValues(t, "cold2", groups[0].Value.Data())
+
+	iter.Next()
+	require.False(t, iter.Valid())
+	require.Nil(t, iter.AttributeGroups())
+	require.Nil(t, iter.Err())
+}
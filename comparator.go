@@ -4,6 +4,19 @@ package grocksdb
 // #include "grocksdb.h"
 import "C"
 
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// U64TimestampSize is the size of timestamps used by built-in comparators
+// with uint64 timestamp.
+const U64TimestampSize = 8
+
+// ErrInvalidU64Timestamp indicates a timestamp which is not of size U64TimestampSize.
+var ErrInvalidU64Timestamp = fmt.Errorf("uint64 timestamp must be %d bytes long", U64TimestampSize)
+
 // Comparing functor.
 //
 // Three-way comparison. Returns value:
@@ -51,6 +64,104 @@ func NewComparatorWithTimestamp(name string, tsSize uint64, compare, compareTs C
 	return cmp
 }
 
+// NewReverseBytewiseComparator creates the RocksDB built-in comparator
+// ordering keys in reverse lexicographic byte-wise order.
+//
+// Comparisons are done in native code without calling back into Go.
+func NewReverseBytewiseComparator() *Comparator {
+	return &Comparator{
+		c:       C.gorocksdb_comparator_reverse_bytewise_create(),
+		name:    "rocksdb.ReverseBytewiseComparator",
+		compare: reverseBytewiseCompare,
+	}
+}
+
+// NewBytewiseComparatorWithU64Timestamp creates the RocksDB built-in comparator
+// ordering keys in lexicographic byte-wise order, with user-defined timestamps
+// being uint64 encoded by EncodeU64Timestamp. For the same key, newer timestamp comes first.
+//
+// Comparisons are done in native code without calling back into Go.
+func NewBytewiseComparatorWithU64Timestamp() *Comparator {
+	return &Comparator{
+		c:                C.gorocksdb_comparator_bytewise_u64ts_create(),
+		name:             "leveldb.BytewiseComparator.u64ts",
+		tsSize:           U64TimestampSize,
+		compare:          u64TimestampCompare(bytes.Compare),
+		compareTs:        compareU64Timestamp,
+		compareWithoutTs: u64TimestampCompareWithoutTs(bytes.Compare),
+	}
+}
+
+// NewReverseBytewiseComparatorWithU64Timestamp creates the RocksDB built-in comparator
+// ordering keys in reverse lexicographic byte-wise order, with user-defined timestamps
+// being uint64 encoded by EncodeU64Timestamp. For the same key, newer timestamp comes first.
+//
+// Comparisons are done in native code without calling back into Go.
+func NewReverseBytewiseComparatorWithU64Timestamp() *Comparator {
+	return &Comparator{
+		c:                C.gorocksdb_comparator_reverse_bytewise_u64ts_create(),
+		name:             "rocksdb.ReverseBytewiseComparator.u64ts",
+		tsSize:           U64TimestampSize,
+		compare:          u64TimestampCompare(reverseBytewiseCompare),
+		compareTs:        compareU64Timestamp,
+		compareWithoutTs: u64TimestampCompareWithoutTs(reverseBytewiseCompare),
+	}
+}
+
+// EncodeU64Timestamp encodes timestamp for comparators with uint64 timestamp.
+func EncodeU64Timestamp(ts uint64) []byte {
+	b := make([]byte, U64TimestampSize)
+	binary.LittleEndian.PutUint64(b, ts)
+	return b
+}
+
+// DecodeU64Timestamp decodes timestamp encoded by EncodeU64Timestamp.
+func DecodeU64Timestamp(ts []byte) (uint64, error) {
+	if len(ts) != U64TimestampSize {
+		return 0, ErrInvalidU64Timestamp
+	}
+	return binary.LittleEndian.Uint64(ts), nil
+}
+
+func reverseBytewiseCompare(a, b []byte) int {
+	return bytes.Compare(b, a)
+}
+
+func compareU64Timestamp(a, b []byte) int {
+	tsA, tsB := binary.LittleEndian.Uint64(a), binary.LittleEndian.Uint64(b)
+	switch {
+	case tsA < tsB:
+		return -1
+	case tsA > tsB:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func u64TimestampCompareWithoutTs(compare Comparing) ComparingWithoutTimestamp {
+	return func(a []byte, aHasTs bool, b []byte, bHasTs bool) int {
+		if aHasTs {
+			a = a[:len(a)-U64TimestampSize]
+		}
+		if bHasTs {
+			b = b[:len(b)-U64TimestampSize]
+		}
+		return compare(a, b)
+	}
+}
+
+func u64TimestampCompare(compare Comparing) Comparing {
+	compareWithoutTs := u64TimestampCompareWithoutTs(compare)
+	return func(a, b []byte) int {
+		if r := compareWithoutTs(a, true, b, true); r != 0 {
+			return r
+		}
+		// newer timestamp comes first
+		return -compareU64Timestamp(a[len(a)-U64TimestampSize:], b[len(b)-U64TimestampSize:])
+	}
+}
+
 // NativeComparator wraps c-comparator pointer.
 type Comparator struct {
 	c *C.rocksdb_comparator_t
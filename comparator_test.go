@@ -50,3 +50,39 @@ func TestComparator(t *testing.T) {
 	// ensure that the order is correct
 	require.EqualValues(t, actualKeys, givenKeys)
 }
+
+func TestReverseBytewiseComparator(t *testing.T) {
+	t.Parallel()
+
+	cmp := NewReverseBytewiseComparator()
+	require.Equal(t, "rocksdb.ReverseBytewiseComparator", cmp.Name())
+	require.Equal(t, 1, cmp.Compare([]byte("a"), []byte("b")))
+	require.Equal(t, -1, cmp.Compare([]byte("ab"), []byte("a")))
+
+	db, opts := newTestDBAndOpts(t, func(opts *Options) {
+		opts.SetComparator(cmp)
+	})
+	defer func() {
+		db.Close()
+		opts.Destroy()
+	}()
+
+	givenKeys := [][]byte{[]byte("key1"), []byte("key2"), []byte("key3")}
+	wo := NewDefaultWriteOptions()
+	for _, k := range givenKeys {
+		require.Nil(t, db.Put(wo, k, []byte("val")))
+	}
+
+	ro := NewDefaultReadOptions()
+	iter := db.NewIterator(ro)
+	defer iter.Close()
+
+	var actualKeys [][]byte
+	for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+		key := make([]byte, 4)
+		copy(key, iter.Key().Data())
+		actualKeys = append(actualKeys, key)
+	}
+	require.Nil(t, iter.Err())
+	require.EqualValues(t, [][]byte{[]byte("key3"), []byte("key2"), []byte("key1")}, actualKeys)
+}
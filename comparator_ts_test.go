@@ -63,3 +63,84 @@ func TestComparatorWithTS(t *testing.T) {
 	require.EqualValues(t, actualKeys, givenKeys)
 	require.EqualValues(t, actualTimes, givenTimes)
 }
+
+func TestU64TimestampEncoding(t *testing.T) {
+	t.Parallel()
+
+	ts := EncodeU64Timestamp(42)
+	require.EqualValues(t, marshalTimestampLittleEndian(42), ts)
+
+	v, err := DecodeU64Timestamp(ts)
+	require.Nil(t, err)
+	require.EqualValues(t, 42, v)
+
+	_, err = DecodeU64Timestamp([]byte{1})
+	require.ErrorIs(t, err, ErrInvalidU64Timestamp)
+}
+
+func TestBuiltinComparatorsWithU64Timestamp(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		cmp      *Comparator
+		name     string
+		expected [][]byte
+	}{
+		{
+			cmp:      NewBytewiseComparatorWithU64Timestamp(),
+			name:     "leveldb.BytewiseComparator.u64ts",
+			expected: [][]byte{[]byte("key1"), []byte("key2"), []byte("key3")},
+		},
+		{
+			cmp:      NewReverseBytewiseComparatorWithU64Timestamp(),
+			name:     "rocksdb.ReverseBytewiseComparator.u64ts",
+			expected: [][]byte{[]byte("key3"), []byte("key2"), []byte("key1")},
+		},
+	} {
+		cmp := tc.cmp
+		require.Equal(t, tc.name, cmp.Name())
+		require.EqualValues(t, U64TimestampSize, cmp.TimestampSize())
+
+		// same key: newer timestamp comes first
+		a := append([]byte("key"), EncodeU64Timestamp(2)...)
+		b := append([]byte("key"), EncodeU64Timestamp(256)...)
+		require.Equal(t, 1, cmp.Compare(a, b))
+		require.Equal(t, -1, cmp.CompareTimestamp(EncodeU64Timestamp(2), EncodeU64Timestamp(256)))
+		require.Equal(t, 0, cmp.CompareWithoutTimestamp(a, true, []byte("key"), false))
+
+		db, opts := newTestDBAndOpts(t, func(opts *Options) {
+			opts.SetComparator(cmp)
+		})
+
+		wo := NewDefaultWriteOptions()
+		for i, k := range [][]byte{[]byte("key1"), []byte("key2"), []byte("key3")} {
+			require.Nil(t, db.PutWithTS(wo, k, EncodeU64Timestamp(uint64(i+1)), []byte("old")))
+			require.Nil(t, db.PutWithTS(wo, k, EncodeU64Timestamp(uint64(i+256)), []byte("new")))
+		}
+
+		// read as of a timestamp between both versions of key1
+		ro := NewDefaultReadOptions()
+		ro.SetTimestamp(EncodeU64Timestamp(100))
+		v, ts, err := db.GetBytesWithTS(ro, []byte("key1"))
+		require.Nil(t, err)
+		require.EqualValues(t, "old", v)
+		require.EqualValues(t, EncodeU64Timestamp(1), ts)
+
+		ro.SetTimestamp(EncodeU64Timestamp(1000))
+		iter := db.NewIterator(ro)
+
+		var actualKeys [][]byte
+		for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+			key := make([]byte, 4)
+			copy(key, iter.Key().Data())
+			actualKeys = append(actualKeys, key)
+			require.EqualValues(t, "new", iter.Value().Data())
+		}
+		require.Nil(t, iter.Err())
+		require.EqualValues(t, tc.expected, actualKeys)
+
+		iter.Close()
+		db.Close()
+		opts.Destroy()
+	}
+}
@@ -24,6 +24,10 @@ type DB struct {
 	c    *C.rocksdb_t
 	name string
 	opts *Options
+
+	snapshots snapshotRegistry
+	// cleanup is called once the database is closed.
+	cleanup func()
 }
 
 // OpenDb opens a database with the specified options.
@@ -1263,6 +1267,40 @@ func (db *DB) MergeCF(opts *WriteOptions, cf *ColumnFamilyHandle, key, value []b
 	return err
 }
 
+// AddUint64 adds delta to the uint64 value associated with the key.
+// The uint64 add merge operator must be set, see NewUint64AddMergeOperator.
+func (db *DB) AddUint64(opts *WriteOptions, key []byte, delta uint64) (err error) {
+	return db.Merge(opts, key, EncodeUint64(delta))
+}
+
+// AddUint64CF adds delta to the uint64 value associated with the key in
+// the column family. The uint64 add merge operator must be set,
+// see NewUint64AddMergeOperator.
+func (db *DB) AddUint64CF(opts *WriteOptions, cf *ColumnFamilyHandle, key []byte, delta uint64) (err error) {
+	return db.MergeCF(opts, cf, key, EncodeUint64(delta))
+}
+
+// GetUint64 returns the uint64 value associated with the key, as maintained
+// by AddUint64. Missing keys have a value of 0.
+func (db *DB) GetUint64(opts *ReadOptions, key []byte) (value uint64, err error) {
+	data, err := db.GetBytes(opts, key)
+	if err == nil {
+		value = DecodeUint64(data)
+	}
+	return value, err
+}
+
+// GetUint64CF returns the uint64 value associated with the key in the
+// column family, as maintained by AddUint64CF. Missing keys have a value of 0.
+func (db *DB) GetUint64CF(opts *ReadOptions, cf *ColumnFamilyHandle, key []byte) (value uint64, err error) {
+	slice, err := db.GetCF(opts, cf, key)
+	if err == nil {
+		value = DecodeUint64(slice.Data())
+		slice.Free()
+	}
+	return value, err
+}
+
 // Write a batch to the database.
 func (db *DB) Write(opts *WriteOptions, batch *WriteBatch) (err error) {
 	var cErr *C.char
@@ -1350,15 +1388,24 @@ func (db *DB) GetLatestSequenceNumber() uint64 {
 // NewSnapshot creates a new snapshot of the database.
 func (db *DB) NewSnapshot() *Snapshot {
 	cSnap := C.rocksdb_create_snapshot(db.c)
-	return newNativeSnapshot(cSnap)
+	snapshot := newNativeSnapshot(cSnap)
+	db.snapshots.add(snapshot)
+	return snapshot
 }
 
 // ReleaseSnapshot releases the snapshot and its resources.
 func (db *DB) ReleaseSnapshot(snapshot *Snapshot) {
+	db.snapshots.remove(snapshot)
 	C.rocksdb_release_snapshot(db.c, snapshot.c)
 	snapshot.c = nil
 }
 
+// GetSnapshots returns the live snapshots created by NewSnapshot, oldest first.
+// Snapshots taken by transactions are not listed.
+func (db *DB) GetSnapshots() []SnapshotInfo {
+	return db.snapshots.list()
+}
+
 // GetProperty returns the value of a database property.
 func (db *DB) GetProperty(propName string) (value string) {
 	cprop := C.CString(propName)
@@ -1437,6 +1484,38 @@ func (db *DB) CreateColumnFamilyWithImport(
 	return handle, err
 }
 
+// ImportColumnFamilyFrom creates a new column family with the SST files
+// exported by ExportColumnFamilyTo to exportDirs. Several exported column
+// families can be imported into one column family if their key ranges do not
+// overlap, otherwise ErrImportRangesOverlap is returned.
+func (db *DB) ImportColumnFamilyFrom(
+	opts *Options,
+	name string,
+	importOpts *ImportColumnFamilyOption,
+	exportDirs ...string,
+) (handle *ColumnFamilyHandle, err error) {
+	exports := make([]*exportedMetadata, len(exportDirs))
+	for i, dir := range exportDirs {
+		if exports[i], err = readExportedMetadata(dir); err != nil {
+			return
+		}
+	}
+	if len(exports) == 0 {
+		return nil, fmt.Errorf("Invalid argument: No exported column family to import")
+	}
+
+	merged, err := mergeExportedMetadata(exports)
+	if err != nil {
+		return
+	}
+
+	metadata := NewExportImportFileMetadata()
+	defer metadata.Destroy()
+	metadata.fromExported(merged)
+
+	return db.CreateColumnFamilyWithImport(opts, name, importOpts, metadata)
+}
+
 // GetDefaultColumnFamily gets default column family handle.
 func (db *DB) GetDefaultColumnFamily() *ColumnFamilyHandle {
 	return newNativeColumnFamilyHandle(C.rocksdb_get_default_column_family_handle(db.c))
@@ -1681,6 +1760,7 @@ func (db *DB) SetOptionsCF(cf *ColumnFamilyHandle, keys, values []string) (err e
 // LiveFileMetadata is a metadata which is associated with each SST file.
 type LiveFileMetadata struct {
 	Name             string
+	Directory        string
 	ColumnFamilyName string
 	Level            int
 	Size             int64
@@ -1700,6 +1780,7 @@ func (db *DB) GetLiveFilesMetaData() []LiveFileMetadata {
 	for i := C.int(0); i < count; i++ {
 		var liveFile LiveFileMetadata
 		liveFile.Name = C.GoString(C.rocksdb_livefiles_name(lf, i))
+		liveFile.Directory = C.GoString(C.rocksdb_livefiles_directory(lf, i))
 		liveFile.ColumnFamilyName = C.GoString(C.rocksdb_livefiles_column_family_name(lf, i))
 		liveFile.Level = int(C.rocksdb_livefiles_level(lf, i))
 		liveFile.Size = int64(C.rocksdb_livefiles_size(lf, i))
@@ -1983,7 +2064,7 @@ func (db *DB) NewCheckpoint() (cp *Checkpoint, err error) {
 		db.c, &cErr,
 	)
 	if err = fromCError(cErr); err == nil {
-		cp = newNativeCheckpoint(cCheckpoint)
+		cp = newNativeCheckpoint(cCheckpoint, db.name, db)
 	}
 	return cp, err
 }
@@ -2063,6 +2144,11 @@ func (db *DB) LiveFiles() *LiveFiles {
 func (db *DB) Close() {
 	C.rocksdb_close(db.c)
 	db.c = nil
+
+	if db.cleanup != nil {
+		db.cleanup()
+		db.cleanup = nil
+	}
 }
 
 // DestroyDb removes a database entirely, removing everything from the
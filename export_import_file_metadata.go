@@ -5,9 +5,22 @@ package grocksdb
 import "C"
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
 	"unsafe"
 )
 
+// ExportMetadataFileName is the name of the file ExportColumnFamilyTo writes
+// the metadata of the exported column family to, next to its table files.
+const ExportMetadataFileName = "EXPORT_METADATA.json"
+
+// ErrImportRangesOverlap is returned when importing exported column families
+// whose key ranges overlap into one column family.
+var ErrImportRangesOverlap = fmt.Errorf("Invalid argument: Exported column families have overlapping key ranges")
+
 // ExportImportFileMetadata is the metadata returned as output from ExportColumnFamily()
 // and used as input to CreateColumnFamiliesWithImport().
 type ExportImportFileMetadata struct {
@@ -47,6 +60,171 @@ func (e *ExportImportFileMetadata) SetFiles(files *LiveFiles) {
 	C.rocksdb_export_import_files_metadata_set_files(e.c, files.c)
 }
 
+// exportedFile is the JSON encoding of a table file of ExportImportFileMetadata.
+type exportedFile struct {
+	ColumnFamilyName string `json:"column_family_name"`
+	Name             string `json:"name"`
+	Directory        string `json:"directory"`
+	Level            int    `json:"level"`
+	Size             int    `json:"size"`
+	SmallestKey      []byte `json:"smallest_key"`
+	LargestKey       []byte `json:"largest_key"`
+	SmallestSeqNo    uint64 `json:"smallest_seqno"`
+	LargestSeqNo     uint64 `json:"largest_seqno"`
+	NumEntries       uint64 `json:"num_entries"`
+	NumDeletions     uint64 `json:"num_deletions"`
+}
+
+// exportedMetadata is the JSON encoding of ExportImportFileMetadata.
+type exportedMetadata struct {
+	ComparatorName string         `json:"comparator_name"`
+	Files          []exportedFile `json:"files"`
+}
+
+func (e *ExportImportFileMetadata) toExported() *exportedMetadata {
+	files := e.GetFiles()
+	defer files.Destroy()
+
+	exported := &exportedMetadata{
+		ComparatorName: e.GetComparatorName(),
+		Files:          make([]exportedFile, files.Count()),
+	}
+	for i := range exported.Files {
+		exported.Files[i] = exportedFile{
+			ColumnFamilyName: files.ColumnFamilyName(i),
+			Name:             files.Name(i),
+			Directory:        files.Directory(i),
+			Level:            files.Level(i),
+			Size:             files.Size(i),
+			SmallestKey:      files.SmallestKey(i),
+			LargestKey:       files.LargestKey(i),
+			SmallestSeqNo:    files.SmallestSeqNo(i),
+			LargestSeqNo:     files.LargestSeqNo(i),
+			NumEntries:       files.NumEntries(i),
+			NumDeletions:     files.NumDeletions(i),
+		}
+	}
+	return exported
+}
+
+func (e *ExportImportFileMetadata) fromExported(exported *exportedMetadata) {
+	if e.c == nil {
+		e.c = C.rocksdb_export_import_files_metadata_create()
+	}
+	e.SetComparatorName(exported.ComparatorName)
+
+	files := NewLiveFiles()
+	defer files.Destroy()
+	for _, f := range exported.Files {
+		file := NewLiveFile()
+		file.SetColumnFamilyName(f.ColumnFamilyName)
+		file.SetName(f.Name)
+		file.SetDirectory(f.Directory)
+		file.SetLevel(f.Level)
+		file.SetSize(f.Size)
+		file.SetSmallestKey(f.SmallestKey)
+		file.SetLargestKey(f.LargestKey)
+		file.SetSmallestSeqNo(f.SmallestSeqNo)
+		file.SetLargestSeqNo(f.LargestSeqNo)
+		file.SetNumEntries(f.NumEntries)
+		file.SetNumDeletions(f.NumDeletions)
+		files.AddLiveFile(file)
+		file.Destroy()
+	}
+	e.SetFiles(files)
+}
+
+// MarshalJSON encodes the metadata to JSON, so that it can be imported by
+// another database.
+func (e *ExportImportFileMetadata) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.toExported())
+}
+
+// UnmarshalJSON decodes the metadata from JSON.
+func (e *ExportImportFileMetadata) UnmarshalJSON(data []byte) error {
+	var exported exportedMetadata
+	if err := json.Unmarshal(data, &exported); err != nil {
+		return err
+	}
+	e.fromExported(&exported)
+	return nil
+}
+
+// readExportedMetadata reads the metadata written by ExportColumnFamilyTo to
+// exportDir, with the files in exportDir.
+func readExportedMetadata(exportDir string) (*exportedMetadata, error) {
+	data, err := os.ReadFile(filepath.Join(exportDir, ExportMetadataFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	var exported exportedMetadata
+	if err = json.Unmarshal(data, &exported); err != nil {
+		return nil, err
+	}
+
+	dir, err := filepath.Abs(exportDir)
+	if err != nil {
+		return nil, err
+	}
+	for i := range exported.Files {
+		exported.Files[i].Directory = dir
+	}
+	return &exported, nil
+}
+
+// LoadExportImportFileMetadata loads the metadata written by
+// ExportColumnFamilyTo to exportDir, which may have been moved since.
+func LoadExportImportFileMetadata(exportDir string) (*ExportImportFileMetadata, error) {
+	exported, err := readExportedMetadata(exportDir)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := NewExportImportFileMetadata()
+	metadata.fromExported(exported)
+	return metadata, nil
+}
+
+// mergeExportedMetadata merges the metadata of exported column families to
+// import them into one column family. Their key ranges must not overlap,
+// which is checked for the bytewise comparator.
+func mergeExportedMetadata(exports []*exportedMetadata) (*exportedMetadata, error) {
+	merged := &exportedMetadata{ComparatorName: exports[0].ComparatorName}
+
+	type keyRange struct{ smallest, largest []byte }
+	ranges := make([]keyRange, len(exports))
+	for i, exported := range exports {
+		if exported.ComparatorName != merged.ComparatorName {
+			return nil, fmt.Errorf("Invalid argument: Exported column families have different comparators: %s and %s",
+				merged.ComparatorName, exported.ComparatorName)
+		}
+
+		for j, f := range exported.Files {
+			if j == 0 || bytes.Compare(f.SmallestKey, ranges[i].smallest) < 0 {
+				ranges[i].smallest = f.SmallestKey
+			}
+			if j == 0 || bytes.Compare(f.LargestKey, ranges[i].largest) > 0 {
+				ranges[i].largest = f.LargestKey
+			}
+		}
+		merged.Files = append(merged.Files, exported.Files...)
+	}
+
+	if merged.ComparatorName == "leveldb.BytewiseComparator" {
+		for i := range ranges {
+			for j := i + 1; j < len(ranges); j++ {
+				if len(exports[i].Files) > 0 && len(exports[j].Files) > 0 &&
+					bytes.Compare(ranges[i].smallest, ranges[j].largest) <= 0 &&
+					bytes.Compare(ranges[j].smallest, ranges[i].largest) <= 0 {
+					return nil, ErrImportRangesOverlap
+				}
+			}
+		}
+	}
+	return merged, nil
+}
+
 // Destroy ExportImportFileMetadata.
 func (e *ExportImportFileMetadata) Destroy() {
 	if e.c != nil {
@@ -0,0 +1,88 @@
+package grocksdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImportColumnFamilyFrom(t *testing.T) {
+	t.Parallel()
+
+	db := newTestDB(t, nil)
+	defer db.Close()
+
+	opts := NewDefaultOptions()
+	defer opts.Destroy()
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+
+	// tenants in column families with disjoint key ranges
+	cfs, err := db.CreateColumnFamilies(opts, []string{"tenant1", "tenant2"})
+	require.Nil(t, err)
+	for i, cf := range cfs {
+		for j := 0; j < 10; j++ {
+			require.Nil(t, db.PutCF(wo, cf, []byte(fmt.Sprintf("tenant%d/%d", i+1, j)), []byte("value")))
+		}
+	}
+
+	checkpoint, err := db.NewCheckpoint()
+	require.Nil(t, err)
+	defer checkpoint.Destroy()
+
+	tmp := t.TempDir()
+	var dirs []string
+	for i, cf := range cfs {
+		dir := filepath.Join(tmp, fmt.Sprint("export", i))
+		require.Nil(t, checkpoint.ExportColumnFamilyTo(cf, dir))
+
+		// exports are moved to other hosts
+		moved := filepath.Join(tmp, fmt.Sprint("moved", i))
+		require.Nil(t, os.Rename(dir, moved))
+		dirs = append(dirs, moved)
+	}
+
+	metadata, err := LoadExportImportFileMetadata(dirs[0])
+	require.Nil(t, err)
+	defer metadata.Destroy()
+	data, err := json.Marshal(metadata)
+	require.Nil(t, err)
+	decoded := NewExportImportFileMetadata()
+	defer decoded.Destroy()
+	require.Nil(t, json.Unmarshal(data, decoded))
+	require.Equal(t, metadata.toExported(), decoded.toExported())
+	require.Equal(t, "leveldb.BytewiseComparator", decoded.GetComparatorName())
+	files := decoded.GetFiles()
+	require.True(t, files.Count() > 0)
+	require.Equal(t, dirs[0], files.Directory(0))
+	require.Equal(t, []byte("tenant1/0"), files.SmallestKey(0))
+	files.Destroy()
+
+	other := newTestDB(t, nil)
+	defer other.Close()
+
+	importOpts := NewImportColumnFamilyOption()
+	defer importOpts.Destroy()
+
+	cf, err := other.ImportColumnFamilyFrom(opts, "tenants", importOpts, dirs...)
+	require.Nil(t, err)
+	defer cf.Destroy()
+	for i := range cfs {
+		for j := 0; j < 10; j++ {
+			v, err := other.GetCF(ro, cf, []byte(fmt.Sprintf("tenant%d/%d", i+1, j)))
+			require.Nil(t, err)
+			require.Equal(t, []byte("value"), v.Data())
+			v.Free()
+		}
+	}
+
+	// the same tenant twice
+	_, err = other.ImportColumnFamilyFrom(opts, "overlapping", importOpts, dirs[0], dirs[0])
+	require.Equal(t, ErrImportRangesOverlap, err)
+}
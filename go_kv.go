@@ -0,0 +1,444 @@
+package grocksdb
+
+import "github.com/linxGnu/grocksdb/kv"
+
+// GoKV adapts a KV to kv.KV, whose interfaces have no cgo types, so that code
+// written against kv.KV runs against RocksDB as well as against memdb.
+//
+// Its column families are the ColumnFamilyHandle of the database, and its
+// snapshots those of NewSnapshot. Reads copy the data to Go memory.
+type GoKV struct {
+	db KV
+	wo *WriteOptions
+}
+
+var _ kv.KV = (*GoKV)(nil)
+
+// NewGoKV returns the kv.KV of db, writing with opts. Default write options
+// are used when opts is nil. opts must be valid as long as the GoKV is used.
+func NewGoKV(db KV, opts *WriteOptions) *GoKV {
+	return &GoKV{db: db, wo: opts}
+}
+
+// Get returns the data associated with the key from the database, or nil when
+// the key does not exist.
+func (g *GoKV) Get(opts *kv.ReadOptions, key []byte) ([]byte, error) {
+	ro, err := g.readOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer ro.Destroy()
+
+	value, err := g.db.Get(ro, key)
+	if err != nil {
+		return nil, err
+	}
+	defer value.Free()
+	return goBytes(value), nil
+}
+
+// GetCF returns the data associated with the key from the column family.
+func (g *GoKV) GetCF(opts *kv.ReadOptions, cf kv.ColumnFamily, key []byte) ([]byte, error) {
+	h, err := columnFamilyHandle(cf)
+	if err != nil {
+		return nil, err
+	}
+	ro, err := g.readOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer ro.Destroy()
+
+	value, err := g.db.GetCF(ro, h, key)
+	if err != nil {
+		return nil, err
+	}
+	defer value.Free()
+	return goBytes(value), nil
+}
+
+// MultiGet returns the data associated with the keys from the database, nil
+// for the keys which do not exist.
+func (g *GoKV) MultiGet(opts *kv.ReadOptions, keys ...[]byte) ([][]byte, error) {
+	ro, err := g.readOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer ro.Destroy()
+
+	values, err := g.db.MultiGet(ro, keys...)
+	if err != nil {
+		return nil, err
+	}
+	defer values.Destroy()
+	return goBytesOfSlices(values), nil
+}
+
+// MultiGetCF returns the data associated with the keys from the column family.
+func (g *GoKV) MultiGetCF(opts *kv.ReadOptions, cf kv.ColumnFamily, keys ...[]byte) ([][]byte, error) {
+	h, err := columnFamilyHandle(cf)
+	if err != nil {
+		return nil, err
+	}
+	ro, err := g.readOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer ro.Destroy()
+
+	values, err := g.db.MultiGetCF(ro, h, keys...)
+	if err != nil {
+		return nil, err
+	}
+	defer values.Destroy()
+	return goBytesOfSlices(values), nil
+}
+
+// Put writes data associated with a key to the database.
+func (g *GoKV) Put(key, value []byte) error {
+	return g.write(func(wo *WriteOptions) error {
+		return g.db.Put(wo, key, value)
+	})
+}
+
+// PutCF writes data associated with a key to the column family.
+func (g *GoKV) PutCF(cf kv.ColumnFamily, key, value []byte) error {
+	h, err := columnFamilyHandle(cf)
+	if err != nil {
+		return err
+	}
+	return g.write(func(wo *WriteOptions) error {
+		return g.db.PutCF(wo, h, key, value)
+	})
+}
+
+// Delete removes the data associated with the key from the database.
+func (g *GoKV) Delete(key []byte) error {
+	return g.write(func(wo *WriteOptions) error {
+		return g.db.Delete(wo, key)
+	})
+}
+
+// DeleteCF removes the data associated with the key from the column family.
+func (g *GoKV) DeleteCF(cf kv.ColumnFamily, key []byte) error {
+	h, err := columnFamilyHandle(cf)
+	if err != nil {
+		return err
+	}
+	return g.write(func(wo *WriteOptions) error {
+		return g.db.DeleteCF(wo, h, key)
+	})
+}
+
+// SingleDelete removes the database entry for "key", as DB.SingleDelete does.
+func (g *GoKV) SingleDelete(key []byte) error {
+	return g.write(func(wo *WriteOptions) error {
+		return g.db.SingleDelete(wo, key)
+	})
+}
+
+// SingleDeleteCF removes the entry for "key" of the column family, as
+// DB.SingleDeleteCF does.
+func (g *GoKV) SingleDeleteCF(cf kv.ColumnFamily, key []byte) error {
+	h, err := columnFamilyHandle(cf)
+	if err != nil {
+		return err
+	}
+	return g.write(func(wo *WriteOptions) error {
+		return g.db.SingleDeleteCF(wo, h, key)
+	})
+}
+
+// DeleteRangeCF deletes keys that are between [startKey, endKey) of the
+// column family. DeleteRangeCF of TransactionDB bypasses the locks of
+// transactions, see TransactionDB.DeleteRangeCF.
+func (g *GoKV) DeleteRangeCF(cf kv.ColumnFamily, startKey, endKey []byte) error {
+	h, err := columnFamilyHandle(cf)
+	if err != nil {
+		return err
+	}
+	return g.write(func(wo *WriteOptions) error {
+		return g.db.DeleteRangeCF(wo, h, startKey, endKey)
+	})
+}
+
+// Merge merges the data associated with the key with the actual data in the
+// database.
+func (g *GoKV) Merge(key, operand []byte) error {
+	return g.write(func(wo *WriteOptions) error {
+		return g.db.Merge(wo, key, operand)
+	})
+}
+
+// MergeCF merges the data associated with the key with the actual data in the
+// column family.
+func (g *GoKV) MergeCF(cf kv.ColumnFamily, key, operand []byte) error {
+	h, err := columnFamilyHandle(cf)
+	if err != nil {
+		return err
+	}
+	return g.write(func(wo *WriteOptions) error {
+		return g.db.MergeCF(wo, h, key, operand)
+	})
+}
+
+// NewWriteBatch returns an empty write batch, to be destroyed with its
+// Destroy method.
+func (g *GoKV) NewWriteBatch() kv.WriteBatch {
+	return &goWriteBatch{wb: NewWriteBatch()}
+}
+
+// Write writes the records of the batch atomically.
+func (g *GoKV) Write(batch kv.WriteBatch) error {
+	b, ok := batch.(*goWriteBatch)
+	if !ok {
+		return kv.ErrForeign
+	}
+	if b.err != nil {
+		return b.err
+	}
+	return g.write(func(wo *WriteOptions) error {
+		return g.db.Write(wo, b.wb)
+	})
+}
+
+// NewIterator returns an iterator over the default column family.
+func (g *GoKV) NewIterator(opts *kv.ReadOptions) kv.Iterator {
+	ro, err := g.readOptions(opts)
+	if err != nil {
+		return kv.NewErrorIterator(err)
+	}
+	return &goIterator{it: g.db.NewIterator(ro), ro: ro}
+}
+
+// NewIteratorCF returns an iterator over the column family.
+func (g *GoKV) NewIteratorCF(opts *kv.ReadOptions, cf kv.ColumnFamily) kv.Iterator {
+	h, err := columnFamilyHandle(cf)
+	if err != nil {
+		return kv.NewErrorIterator(err)
+	}
+	ro, err := g.readOptions(opts)
+	if err != nil {
+		return kv.NewErrorIterator(err)
+	}
+	return &goIterator{it: g.db.NewIteratorCF(ro, h), ro: ro}
+}
+
+// NewSnapshot creates a new snapshot of the database, to be released with
+// ReleaseSnapshot.
+func (g *GoKV) NewSnapshot() kv.Snapshot {
+	return goSnapshot{s: g.db.NewSnapshot()}
+}
+
+// ReleaseSnapshot releases the snapshot.
+func (g *GoKV) ReleaseSnapshot(snapshot kv.Snapshot) {
+	if s, ok := snapshot.(goSnapshot); ok {
+		g.db.ReleaseSnapshot(s.s)
+	}
+}
+
+// write calls fn with the write options of g.
+func (g *GoKV) write(fn func(wo *WriteOptions) error) error {
+	if g.wo != nil {
+		return fn(g.wo)
+	}
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	return fn(wo)
+}
+
+// readOptions returns new read options with the settings of opts, to be
+// destroyed by the caller.
+func (g *GoKV) readOptions(opts *kv.ReadOptions) (*ReadOptions, error) {
+	ro := NewDefaultReadOptions()
+	if opts == nil {
+		return ro, nil
+	}
+
+	if opts.Snapshot != nil {
+		s, ok := opts.Snapshot.(goSnapshot)
+		if !ok {
+			ro.Destroy()
+			return nil, kv.ErrForeign
+		}
+		ro.SetSnapshot(s.s)
+	}
+	if opts.IterateLowerBound != nil {
+		ro.SetIterateLowerBound(opts.IterateLowerBound)
+	}
+	if opts.IterateUpperBound != nil {
+		ro.SetIterateUpperBound(opts.IterateUpperBound)
+	}
+	return ro, nil
+}
+
+// columnFamilyHandle returns the handle of the kv column family.
+func columnFamilyHandle(cf kv.ColumnFamily) (*ColumnFamilyHandle, error) {
+	h, ok := cf.(*ColumnFamilyHandle)
+	if !ok {
+		return nil, kv.ErrForeign
+	}
+	return h, nil
+}
+
+// goBytes returns a copy of the data of the slice, nil when it doesn't exist.
+func goBytes(s *Slice) []byte {
+	if !s.Exists() {
+		return nil
+	}
+	return append(make([]byte, 0, s.Size()), s.Data()...)
+}
+
+// goBytesOfSlices returns copies of the data of the slices.
+func goBytesOfSlices(slices Slices) [][]byte {
+	values := make([][]byte, len(slices))
+	for i, s := range slices {
+		values[i] = goBytes(s)
+	}
+	return values
+}
+
+// goSnapshot is a snapshot of GoKV.
+type goSnapshot struct {
+	s *Snapshot
+}
+
+func (s goSnapshot) SequenceNumber() uint64 {
+	return s.s.GetSequenceNumber()
+}
+
+// goIterator is an iterator of GoKV, with the read options it destroys once
+// closed.
+type goIterator struct {
+	it *Iterator
+	ro *ReadOptions
+}
+
+func (iter *goIterator) Valid() bool {
+	return iter.it.Valid()
+}
+
+func (iter *goIterator) ValidForPrefix(prefix []byte) bool {
+	return iter.it.ValidForPrefix(prefix)
+}
+
+func (iter *goIterator) Key() []byte {
+	if !iter.it.Valid() {
+		return nil
+	}
+	return iter.it.Key().Data()
+}
+
+func (iter *goIterator) Value() []byte {
+	if !iter.it.Valid() {
+		return nil
+	}
+	return iter.it.Value().Data()
+}
+
+func (iter *goIterator) Next() {
+	iter.it.Next()
+}
+
+func (iter *goIterator) Prev() {
+	iter.it.Prev()
+}
+
+func (iter *goIterator) SeekToFirst() {
+	iter.it.SeekToFirst()
+}
+
+func (iter *goIterator) SeekToLast() {
+	iter.it.SeekToLast()
+}
+
+func (iter *goIterator) Seek(key []byte) {
+	iter.it.Seek(key)
+}
+
+func (iter *goIterator) SeekForPrev(key []byte) {
+	iter.it.SeekForPrev(key)
+}
+
+func (iter *goIterator) Err() error {
+	return iter.it.Err()
+}
+
+func (iter *goIterator) Close() {
+	iter.it.Close()
+	iter.ro.Destroy()
+}
+
+// goWriteBatch is a write batch of GoKV, with the first error of its writes.
+type goWriteBatch struct {
+	wb  *WriteBatch
+	err error
+}
+
+func (b *goWriteBatch) handle(cf kv.ColumnFamily) *ColumnFamilyHandle {
+	h, err := columnFamilyHandle(cf)
+	if err != nil && b.err == nil {
+		b.err = err
+	}
+	return h
+}
+
+func (b *goWriteBatch) Put(key, value []byte) {
+	b.wb.Put(key, value)
+}
+
+func (b *goWriteBatch) PutCF(cf kv.ColumnFamily, key, value []byte) {
+	if h := b.handle(cf); h != nil {
+		b.wb.PutCF(h, key, value)
+	}
+}
+
+func (b *goWriteBatch) Delete(key []byte) {
+	b.wb.Delete(key)
+}
+
+func (b *goWriteBatch) DeleteCF(cf kv.ColumnFamily, key []byte) {
+	if h := b.handle(cf); h != nil {
+		b.wb.DeleteCF(h, key)
+	}
+}
+
+func (b *goWriteBatch) SingleDelete(key []byte) {
+	b.wb.SingleDelete(key)
+}
+
+func (b *goWriteBatch) SingleDeleteCF(cf kv.ColumnFamily, key []byte) {
+	if h := b.handle(cf); h != nil {
+		b.wb.SingleDeleteCF(h, key)
+	}
+}
+
+func (b *goWriteBatch) DeleteRangeCF(cf kv.ColumnFamily, startKey, endKey []byte) {
+	if h := b.handle(cf); h != nil {
+		b.wb.DeleteRangeCF(h, startKey, endKey)
+	}
+}
+
+func (b *goWriteBatch) Merge(key, operand []byte) {
+	b.wb.Merge(key, operand)
+}
+
+func (b *goWriteBatch) MergeCF(cf kv.ColumnFamily, key, operand []byte) {
+	if h := b.handle(cf); h != nil {
+		b.wb.MergeCF(h, key, operand)
+	}
+}
+
+func (b *goWriteBatch) Count() int {
+	return b.wb.Count()
+}
+
+func (b *goWriteBatch) Clear() {
+	b.wb.Clear()
+	b.err = nil
+}
+
+func (b *goWriteBatch) Destroy() {
+	b.wb.Destroy()
+}
@@ -1,3 +1,6 @@
+#include <errno.h>
+#include <stdio.h>
+#include <string.h>
 #include "grocksdb.h"
 #include "_cgo_export.h"
 
@@ -26,6 +29,119 @@ rocksdb_comparator_t* gorocksdb_comparator_with_ts_create(uintptr_t idx, size_t
         ts_size);
 }
 
+/* Built-in Comparators
+ *
+ * Implemented in C so that comparing keys does not call back into Go.
+ * Names match the RocksDB built-in comparators, thus databases created
+ * with them can be opened with these ones and vice versa.
+ */
+
+#define GOROCKSDB_U64TS_SIZE 8
+
+static int gorocksdb_bytewise_compare(const char* a, size_t alen, const char* b, size_t blen) {
+    int r = memcmp(a, b, alen < blen ? alen : blen);
+    if (r == 0) {
+        if (alen < blen) {
+            r = -1;
+        } else if (alen > blen) {
+            r = 1;
+        }
+    }
+    return r;
+}
+
+static int gorocksdb_u64ts_compare_ts(void* state, const char* a_ts, size_t a_tslen, const char* b_ts, size_t b_tslen) {
+    uint64_t a = 0, b = 0;
+    int i;
+    // timestamps are encoded as fixed 64-bit little-endian integers
+    for (i = GOROCKSDB_U64TS_SIZE - 1; i >= 0; i--) {
+        a = (a << 8) | (unsigned char)a_ts[i];
+        b = (b << 8) | (unsigned char)b_ts[i];
+    }
+    if (a < b) {
+        return -1;
+    }
+    return a > b ? 1 : 0;
+}
+
+static int gorocksdb_bytewise_u64ts_compare_without_ts(void* state, const char* a, size_t alen, unsigned char a_has_ts, const char* b, size_t blen, unsigned char b_has_ts) {
+    if (a_has_ts) {
+        alen -= GOROCKSDB_U64TS_SIZE;
+    }
+    if (b_has_ts) {
+        blen -= GOROCKSDB_U64TS_SIZE;
+    }
+    return gorocksdb_bytewise_compare(a, alen, b, blen);
+}
+
+static int gorocksdb_bytewise_u64ts_compare(void* state, const char* a, size_t alen, const char* b, size_t blen) {
+    int r = gorocksdb_bytewise_u64ts_compare_without_ts(state, a, alen, 1, b, blen, 1);
+    if (r == 0) {
+        // newer timestamp comes first
+        r = -gorocksdb_u64ts_compare_ts(state, a + alen - GOROCKSDB_U64TS_SIZE, GOROCKSDB_U64TS_SIZE, b + blen - GOROCKSDB_U64TS_SIZE, GOROCKSDB_U64TS_SIZE);
+    }
+    return r;
+}
+
+static const char* gorocksdb_bytewise_u64ts_name(void* state) {
+    return "leveldb.BytewiseComparator.u64ts";
+}
+
+static int gorocksdb_reverse_bytewise_compare(void* state, const char* a, size_t alen, const char* b, size_t blen) {
+    return -gorocksdb_bytewise_compare(a, alen, b, blen);
+}
+
+static const char* gorocksdb_reverse_bytewise_name(void* state) {
+    return "rocksdb.ReverseBytewiseComparator";
+}
+
+static int gorocksdb_reverse_bytewise_u64ts_compare_without_ts(void* state, const char* a, size_t alen, unsigned char a_has_ts, const char* b, size_t blen, unsigned char b_has_ts) {
+    return -gorocksdb_bytewise_u64ts_compare_without_ts(state, a, alen, a_has_ts, b, blen, b_has_ts);
+}
+
+static int gorocksdb_reverse_bytewise_u64ts_compare(void* state, const char* a, size_t alen, const char* b, size_t blen) {
+    int r = gorocksdb_reverse_bytewise_u64ts_compare_without_ts(state, a, alen, 1, b, blen, 1);
+    if (r == 0) {
+        // newer timestamp comes first
+        r = -gorocksdb_u64ts_compare_ts(state, a + alen - GOROCKSDB_U64TS_SIZE, GOROCKSDB_U64TS_SIZE, b + blen - GOROCKSDB_U64TS_SIZE, GOROCKSDB_U64TS_SIZE);
+    }
+    return r;
+}
+
+static const char* gorocksdb_reverse_bytewise_u64ts_name(void* state) {
+    return "rocksdb.ReverseBytewiseComparator.u64ts";
+}
+
+rocksdb_comparator_t* gorocksdb_comparator_bytewise_u64ts_create() {
+    return rocksdb_comparator_with_ts_create(
+        NULL,
+        gorocksdb_destruct_handler,
+        gorocksdb_bytewise_u64ts_compare,
+        gorocksdb_u64ts_compare_ts,
+        gorocksdb_bytewise_u64ts_compare_without_ts,
+        gorocksdb_bytewise_u64ts_name,
+        GOROCKSDB_U64TS_SIZE);
+}
+
+rocksdb_comparator_t* gorocksdb_comparator_reverse_bytewise_create() {
+    return rocksdb_comparator_create(
+        NULL,
+        gorocksdb_destruct_handler,
+        gorocksdb_reverse_bytewise_compare,
+        gorocksdb_reverse_bytewise_name);
+}
+
+rocksdb_comparator_t* gorocksdb_comparator_reverse_bytewise_u64ts_create() {
+    return rocksdb_comparator_with_ts_create(
+        NULL,
+        gorocksdb_destruct_handler,
+        gorocksdb_reverse_bytewise_u64ts_compare,
+        gorocksdb_u64ts_compare_ts,
+        gorocksdb_reverse_bytewise_u64ts_compare_without_ts,
+        gorocksdb_reverse_bytewise_u64ts_name,
+        GOROCKSDB_U64TS_SIZE);
+}
+
 /* CompactionFilter */
 
 rocksdb_compactionfilter_t* gorocksdb_compactionfilter_create(uintptr_t idx) {
@@ -52,6 +168,284 @@ void gorocksdb_mergeoperator_delete_value(void* id, const char* v, size_t s) {
     free((char*)v);
 }
 
+/* Built-in Merge Operators
+ *
+ * Implemented in C so that merging does not call back into Go. All of them
+ * are associative: operands are folded one by one into an accumulated value,
+ * starting from the existing value if any.
+ */
+
+typedef struct {
+    char* data;
+    size_t len;
+    size_t cap;
+    unsigned char has_value;
+} gorocksdb_merge_acc_t;
+
+typedef unsigned char (*gorocksdb_merge_fold_fn)(void* state, gorocksdb_merge_acc_t* acc, const char* v, size_t vlen);
+
+typedef struct {
+    gorocksdb_merge_fold_fn fold;
+    const char* name;
+    char* delim;
+    size_t delim_len;
+} gorocksdb_merge_state_t;
+
+static void gorocksdb_merge_acc_reserve(gorocksdb_merge_acc_t* acc, size_t n) {
+    if (n > acc->cap) {
+        acc->cap = n * 2;
+        acc->data = (char*)realloc(acc->data, acc->cap);
+    }
+}
+
+static void gorocksdb_merge_acc_append(gorocksdb_merge_acc_t* acc, const char* v, size_t vlen) {
+    gorocksdb_merge_acc_reserve(acc, acc->len + vlen + 1);
+    if (vlen > 0) {
+        memcpy(acc->data + acc->len, v, vlen);
+    }
+    acc->len += vlen;
+    acc->has_value = 1;
+}
+
+static void gorocksdb_merge_acc_assign(gorocksdb_merge_acc_t* acc, const char* v, size_t vlen) {
+    acc->len = 0;
+    gorocksdb_merge_acc_append(acc, v, vlen);
+}
+
+static char* gorocksdb_merge_fold(void* state, const char* existing, size_t existing_len, unsigned char has_existing,
+                                  const char* const* operands, const size_t* operands_len, int num_operands,
+                                  unsigned char* success, size_t* new_value_len) {
+    gorocksdb_merge_state_t* s = (gorocksdb_merge_state_t*)state;
+    gorocksdb_merge_acc_t acc = {NULL, 0, 0, 0};
+    int i;
+
+    *success = 1;
+    if (has_existing) {
+        *success = s->fold(state, &acc, existing, existing_len);
+    }
+    for (i = 0; i < num_operands && *success; i++) {
+        *success = s->fold(state, &acc, operands[i], operands_len[i]);
+    }
+
+    if (!*success) {
+        free(acc.data);
+        *new_value_len = 0;
+        return NULL;
+    }
+
+    // never return NULL on success, even for an empty value
+    gorocksdb_merge_acc_reserve(&acc, 1);
+    *new_value_len = acc.len;
+    return acc.data;
+}
+
+static char* gorocksdb_merge_full_merge(void* state, const char* key, size_t key_len,
+                                        const char* existing, size_t existing_len,
+                                        const char* const* operands, const size_t* operands_len, int num_operands,
+                                        unsigned char* success, size_t* new_value_len) {
+    return gorocksdb_merge_fold(state, existing, existing_len, existing != NULL,
+                                operands, operands_len, num_operands, success, new_value_len);
+}
+
+static char* gorocksdb_merge_partial_merge(void* state, const char* key, size_t key_len,
+                                           const char* const* operands, const size_t* operands_len, int num_operands,
+                                           unsigned char* success, size_t* new_value_len) {
+    return gorocksdb_merge_fold(state, NULL, 0, 0, operands, operands_len, num_operands, success, new_value_len);
+}
+
+static void gorocksdb_merge_destroy(void* state) {
+    gorocksdb_merge_state_t* s = (gorocksdb_merge_state_t*)state;
+    free(s->delim);
+    free(s);
+}
+
+static const char* gorocksdb_merge_name(void* state) {
+    return ((gorocksdb_merge_state_t*)state)->name;
+}
+
+static rocksdb_mergeoperator_t* gorocksdb_merge_create(gorocksdb_merge_fold_fn fold, const char* name, const char* delim, size_t delim_len) {
+    gorocksdb_merge_state_t* s = (gorocksdb_merge_state_t*)calloc(1, sizeof(gorocksdb_merge_state_t));
+    s->fold = fold;
+    s->name = name;
+    if (delim_len > 0) {
+        s->delim = (char*)malloc(delim_len);
+        memcpy(s->delim, delim, delim_len);
+        s->delim_len = delim_len;
+    }
+    return rocksdb_mergeoperator_create(
+        s,
+        gorocksdb_merge_destroy,
+        gorocksdb_merge_full_merge,
+        gorocksdb_merge_partial_merge,
+        gorocksdb_mergeoperator_delete_value,
+        gorocksdb_merge_name);
+}
+
+static uint64_t gorocksdb_decode_fixed64(const char* v, size_t vlen) {
+    uint64_t r = 0;
+    int i;
+    // values of a wrong size are treated as 0, as RocksDB does
+    if (vlen != 8) {
+        return 0;
+    }
+    for (i = 7; i >= 0; i--) {
+        r = (r << 8) | (unsigned char)v[i];
+    }
+    return r;
+}
+
+static unsigned char gorocksdb_merge_uint64add_fold(void* state, gorocksdb_merge_acc_t* acc, const char* v, size_t vlen) {
+    uint64_t sum = gorocksdb_decode_fixed64(v, vlen);
+    char buf[8];
+    int i;
+
+    if (acc->has_value) {
+        sum += gorocksdb_decode_fixed64(acc->data, acc->len);
+    }
+    for (i = 0; i < 8; i++) {
+        buf[i] = (char)(sum >> (8 * i));
+    }
+    gorocksdb_merge_acc_assign(acc, buf, 8);
+    return 1;
+}
+
+static unsigned char gorocksdb_merge_stringappend_fold(void* state, gorocksdb_merge_acc_t* acc, const char* v, size_t vlen) {
+    gorocksdb_merge_state_t* s = (gorocksdb_merge_state_t*)state;
+    if (acc->has_value) {
+        gorocksdb_merge_acc_append(acc, s->delim, s->delim_len);
+    }
+    gorocksdb_merge_acc_append(acc, v, vlen);
+    return 1;
+}
+
+static unsigned char gorocksdb_merge_max_fold(void* state, gorocksdb_merge_acc_t* acc, const char* v, size_t vlen) {
+    size_t n = acc->len < vlen ? acc->len : vlen;
+    int r;
+
+    if (acc->has_value) {
+        r = n > 0 ? memcmp(acc->data, v, n) : 0;
+        if (r > 0 || (r == 0 && acc->len >= vlen)) {
+            return 1;
+        }
+    }
+    gorocksdb_merge_acc_assign(acc, v, vlen);
+    return 1;
+}
+
+static unsigned char gorocksdb_merge_bytesxor_fold(void* state, gorocksdb_merge_acc_t* acc, const char* v, size_t vlen) {
+    size_t i;
+
+    if (!acc->has_value) {
+        gorocksdb_merge_acc_assign(acc, v, vlen);
+        return 1;
+    }
+    if (vlen > acc->len) {
+        gorocksdb_merge_acc_reserve(acc, vlen);
+        memset(acc->data + acc->len, 0, vlen - acc->len);
+        acc->len = vlen;
+    }
+    for (i = 0; i < vlen; i++) {
+        acc->data[i] ^= v[i];
+    }
+    return 1;
+}
+
+static int gorocksdb_compare_int64(const void* a, const void* b) {
+    int64_t x = *(const int64_t*)a, y = *(const int64_t*)b;
+    return (x > y) - (x < y);
+}
+
+// gorocksdb_parse_int_list appends comma separated integers of v to list,
+// failing as strconv.ParseInt on integers out of the int64 range.
+static unsigned char gorocksdb_parse_int_list(const char* v, size_t vlen, int64_t** list, size_t* n, size_t* cap) {
+    size_t i = 0;
+    while (i < vlen) {
+        char buf[24];
+        char* end;
+        long long x;
+        size_t len = 0, start;
+
+        if (v[i] == '-') {
+            buf[len++] = '-';
+            i++;
+        }
+        // leading zeros are skipped, keeping the last digit, so that the
+        // digits left fit the buffer unless out of range
+        while (i + 1 < vlen && v[i] == '0' && v[i + 1] >= '0' && v[i + 1] <= '9') {
+            i++;
+        }
+        for (start = i; i < vlen && v[i] >= '0' && v[i] <= '9'; i++) {
+        }
+        if (i == start || (i < vlen && v[i] != ',')) {
+            return 0;
+        }
+        if (i - start > 19) {
+            return 0;
+        }
+        memcpy(buf + len, v + start, i - start);
+        buf[len + i - start] = '\0';
+        i++;
+
+        errno = 0;
+        x = strtoll(buf, &end, 10);
+        if (errno == ERANGE || *end != '\0') {
+            return 0;
+        }
+
+        if (*n == *cap) {
+            *cap = *cap ? *cap * 2 : 16;
+            *list = (int64_t*)realloc(*list, *cap * sizeof(int64_t));
+        }
+        (*list)[(*n)++] = (int64_t)x;
+    }
+    return 1;
+}
+
+static unsigned char gorocksdb_merge_sortlist_fold(void* state, gorocksdb_merge_acc_t* acc, const char* v, size_t vlen) {
+    int64_t* list = NULL;
+    size_t n = 0, cap = 0, i;
+    char buf[24];
+    unsigned char ok = 1;
+
+    if (acc->has_value) {
+        ok = gorocksdb_parse_int_list(acc->data, acc->len, &list, &n, &cap);
+    }
+    if (ok) {
+        ok = gorocksdb_parse_int_list(v, vlen, &list, &n, &cap);
+    }
+    if (ok) {
+        qsort(list, n, sizeof(int64_t), gorocksdb_compare_int64);
+        acc->len = 0;
+        acc->has_value = 1;
+        for (i = 0; i < n; i++) {
+            int len = snprintf(buf, sizeof(buf), i == 0 ? "%lld" : ",%lld", (long long)list[i]);
+            gorocksdb_merge_acc_append(acc, buf, (size_t)len);
+        }
+    }
+    free(list);
+    return ok;
+}
+
+rocksdb_mergeoperator_t* gorocksdb_mergeoperator_uint64add_create() {
+    return gorocksdb_merge_create(gorocksdb_merge_uint64add_fold, "UInt64AddOperator", NULL, 0);
+}
+
+rocksdb_mergeoperator_t* gorocksdb_mergeoperator_stringappend_create(const char* delim, size_t delim_len) {
+    return gorocksdb_merge_create(gorocksdb_merge_stringappend_fold, "StringAppendOperator", delim, delim_len);
+}
+
+rocksdb_mergeoperator_t* gorocksdb_mergeoperator_max_create() {
+    return gorocksdb_merge_create(gorocksdb_merge_max_fold, "MaxOperator", NULL, 0);
+}
+
+rocksdb_mergeoperator_t* gorocksdb_mergeoperator_sortlist_create() {
+    return gorocksdb_merge_create(gorocksdb_merge_sortlist_fold, "MergeSortOperator", NULL, 0);
+}
+
+rocksdb_mergeoperator_t* gorocksdb_mergeoperator_bytesxor_create() {
+    return gorocksdb_merge_create(gorocksdb_merge_bytesxor_fold, "BytesXOR", NULL, 0);
+}
+
 /* Slice Transform */
 
 rocksdb_slicetransform_t* gorocksdb_slicetransform_create(uintptr_t idx) {
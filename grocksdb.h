@@ -17,11 +17,27 @@ extern rocksdb_comparator_t* gorocksdb_comparator_create(uintptr_t idx);
 
 extern rocksdb_comparator_t* gorocksdb_comparator_with_ts_create(uintptr_t idx, size_t ts_size);
 
+extern rocksdb_comparator_t* gorocksdb_comparator_bytewise_u64ts_create();
+
+extern rocksdb_comparator_t* gorocksdb_comparator_reverse_bytewise_create();
+
+extern rocksdb_comparator_t* gorocksdb_comparator_reverse_bytewise_u64ts_create();
+
 /* Merge Operator */
 
 extern rocksdb_mergeoperator_t* gorocksdb_mergeoperator_create(uintptr_t idx);
 extern void gorocksdb_mergeoperator_delete_value(void* state, const char* v, size_t s);
 
+extern rocksdb_mergeoperator_t* gorocksdb_mergeoperator_uint64add_create();
+
+extern rocksdb_mergeoperator_t* gorocksdb_mergeoperator_stringappend_create(const char* delim, size_t delim_len);
+
+extern rocksdb_mergeoperator_t* gorocksdb_mergeoperator_max_create();
+
+extern rocksdb_mergeoperator_t* gorocksdb_mergeoperator_sortlist_create();
+
+extern rocksdb_mergeoperator_t* gorocksdb_mergeoperator_bytesxor_create();
+
 /* Slice Transform */
 
 extern rocksdb_slicetransform_t* gorocksdb_slicetransform_create(uintptr_t idx);
@@ -0,0 +1,296 @@
+package keys
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrCorrupted indicates a key which is not a valid tuple encoding.
+var ErrCorrupted = fmt.Errorf("keys: corrupted key")
+
+// ErrTypeMismatch indicates that the next component of a key is not of the requested type.
+var ErrTypeMismatch = fmt.Errorf("keys: component type mismatch")
+
+// Unpack decodes all components of a tuple key.
+//
+// Signed integers are returned as int64, unsigned integers as uint64,
+// floats as float64, timestamps as time.Time in UTC and nulls as Null.
+// Descending components are returned wrapped in Descending.
+func Unpack(key []byte) ([]interface{}, error) {
+	var values []interface{}
+	d := NewDecoder(key)
+	for d.More() {
+		v, err := d.Next()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// Decoder reads the components of a tuple key one by one.
+//
+// The direction of a component is detected from its encoding, thus the
+// typed methods decode ascending and descending components alike.
+type Decoder struct {
+	buf []byte
+	off int
+}
+
+// NewDecoder returns a Decoder reading the components of key.
+func NewDecoder(key []byte) *Decoder {
+	return &Decoder{buf: key}
+}
+
+// More reports whether there are components left to decode.
+func (d *Decoder) More() bool {
+	return d.off < len(d.buf)
+}
+
+// Remaining returns the undecoded part of the key.
+func (d *Decoder) Remaining() []byte {
+	return d.buf[d.off:]
+}
+
+// Next decodes the next component whatever its type is.
+// See Unpack for the types of returned values.
+func (d *Decoder) Next() (interface{}, error) {
+	tag, desc, err := d.peek()
+	if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	switch tag {
+	case tagNull:
+		d.off++
+		v = Null
+	case tagBytes:
+		v, err = d.Bytes()
+	case tagString:
+		v, err = d.String()
+	case tagFalse, tagTrue:
+		v, err = d.Bool()
+	case tagInt:
+		v, err = d.Int()
+	case tagUint:
+		v, err = d.Uint()
+	case tagFloat:
+		v, err = d.Float()
+	case tagTime:
+		v, err = d.Time()
+	default:
+		return nil, fmt.Errorf("%w: unknown tag %#x at offset %d", ErrCorrupted, d.buf[d.off], d.off)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	if desc {
+		v = Descending{Value: v}
+	}
+	return v, nil
+}
+
+// Null decodes the next component, which must be a null.
+func (d *Decoder) Null() error {
+	_, err := d.expect(tagNull)
+	return err
+}
+
+// Bytes decodes the next component, which must be a byte string.
+func (d *Decoder) Bytes() ([]byte, error) {
+	desc, err := d.expect(tagBytes)
+	if err != nil {
+		return nil, err
+	}
+	return d.readEscaped(desc)
+}
+
+// String decodes the next component, which must be a string.
+func (d *Decoder) String() (string, error) {
+	desc, err := d.expect(tagString)
+	if err != nil {
+		return "", err
+	}
+	v, err := d.readEscaped(desc)
+	return string(v), err
+}
+
+// Bool decodes the next component, which must be a boolean.
+func (d *Decoder) Bool() (bool, error) {
+	tag, _, err := d.peek()
+	if err != nil {
+		return false, err
+	}
+	if tag != tagFalse && tag != tagTrue {
+		return false, d.mismatch("bool")
+	}
+	d.off++
+	return tag == tagTrue, nil
+}
+
+// Int decodes the next component, which must be a signed integer.
+func (d *Decoder) Int() (int64, error) {
+	desc, err := d.expect(tagInt)
+	if err != nil {
+		return 0, err
+	}
+	v, err := d.readUint64(desc)
+	return int64(v ^ (1 << 63)), err
+}
+
+// Uint decodes the next component, which must be an unsigned integer.
+func (d *Decoder) Uint() (uint64, error) {
+	desc, err := d.expect(tagUint)
+	if err != nil {
+		return 0, err
+	}
+	return d.readUint64(desc)
+}
+
+// Float decodes the next component, which must be a floating point number.
+func (d *Decoder) Float() (float64, error) {
+	desc, err := d.expect(tagFloat)
+	if err != nil {
+		return 0, err
+	}
+
+	bits, err := d.readUint64(desc)
+	if err != nil {
+		return 0, err
+	}
+	if bits&(1<<63) != 0 {
+		bits &^= 1 << 63
+	} else {
+		bits = ^bits
+	}
+	return math.Float64frombits(bits), nil
+}
+
+// Time decodes the next component, which must be a timestamp.
+// The returned time is in UTC.
+func (d *Decoder) Time() (time.Time, error) {
+	desc, err := d.expect(tagTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	sec, err := d.readUint64(desc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	nsec, err := d.read(4, desc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(sec^(1<<63)), int64(binary.BigEndian.Uint32(nsec))).UTC(), nil
+}
+
+// peek returns the ascending tag of the next component and whether
+// it is descending, without consuming it.
+func (d *Decoder) peek() (tag byte, desc bool, err error) {
+	if !d.More() {
+		return 0, false, fmt.Errorf("%w: no component left", ErrCorrupted)
+	}
+	tag = d.buf[d.off]
+	if tag >= 0x80 {
+		return ^tag, true, nil
+	}
+	return tag, false, nil
+}
+
+// expect consumes the tag of the next component, which must be tag.
+func (d *Decoder) expect(tag byte) (desc bool, err error) {
+	actual, desc, err := d.peek()
+	if err != nil {
+		return false, err
+	}
+	if actual != tag {
+		return false, d.mismatch(tagName(tag))
+	}
+	d.off++
+	return desc, nil
+}
+
+func (d *Decoder) mismatch(expected string) error {
+	return fmt.Errorf("%w: expected %s at offset %d", ErrTypeMismatch, expected, d.off)
+}
+
+func (d *Decoder) read(n int, desc bool) ([]byte, error) {
+	if len(d.buf)-d.off < n {
+		return nil, fmt.Errorf("%w: truncated component", ErrCorrupted)
+	}
+
+	b := d.buf[d.off : d.off+n]
+	d.off += n
+	if desc {
+		b = append([]byte(nil), b...)
+		invert(b)
+	}
+	return b, nil
+}
+
+func (d *Decoder) readUint64(desc bool) (uint64, error) {
+	b, err := d.read(intWidth, desc)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+func (d *Decoder) readEscaped(desc bool) ([]byte, error) {
+	var mask byte
+	if desc {
+		mask = 0xff
+	}
+
+	v := []byte{}
+	for d.off < len(d.buf) {
+		c := d.buf[d.off] ^ mask
+		d.off++
+		if c != escape {
+			v = append(v, c)
+			continue
+		}
+
+		if d.off == len(d.buf) {
+			break
+		}
+		next := d.buf[d.off] ^ mask
+		d.off++
+		switch next {
+		case terminator:
+			return v, nil
+		case escapedEscape:
+			v = append(v, escape)
+		default:
+			return nil, fmt.Errorf("%w: invalid escape sequence at offset %d", ErrCorrupted, d.off-2)
+		}
+	}
+	return nil, fmt.Errorf("%w: unterminated component", ErrCorrupted)
+}
+
+func tagName(tag byte) string {
+	switch tag {
+	case tagNull:
+		return "null"
+	case tagBytes:
+		return "bytes"
+	case tagString:
+		return "string"
+	case tagInt:
+		return "int"
+	case tagUint:
+		return "uint"
+	case tagFloat:
+		return "float"
+	case tagTime:
+		return "time"
+	default:
+		return fmt.Sprintf("tag %#x", tag)
+	}
+}
@@ -0,0 +1,253 @@
+/*
+Package keys provides an order-preserving encoding of tuple keys.
+
+RocksDB orders keys with the default bytewise comparator unless told otherwise.
+Keys built by this package compare bytewise in the same order as the tuples
+they were built from, so there is no need to register a Go comparator with
+grocksdb.NewComparator, which would be called through cgo for every key
+comparison.
+
+	key, err := keys.Pack(tenantID, "orders", int64(42), keys.Desc(createdAt))
+
+A tuple is compared component by component. Components of different types
+are ordered by type: nulls, byte strings, strings, booleans, signed integers,
+unsigned integers, floats and then timestamps. A component wrapped with Desc
+sorts in reverse order.
+
+The encoding of a tuple is a prefix of the encoding of any longer tuple
+starting with the same components, thus a packed prefix can be passed to
+Iterator.ValidForPrefix, and PrefixEnd gives the matching bound for
+ReadOptions.SetIterateUpperBound. Booleans, integers, floats and timestamps are
+encoded with a fixed width, so that FixedPrefixLen can size a
+NewFixedPrefixTransform for tuples starting with them.
+*/
+package keys
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Type tags of the encoded components. All of them are lower than 0x80,
+// their complement marks a descending component.
+const (
+	tagNull   byte = 0x05
+	tagBytes  byte = 0x10
+	tagString byte = 0x11
+	tagFalse  byte = 0x20
+	tagTrue   byte = 0x21
+	tagInt    byte = 0x30
+	tagUint   byte = 0x31
+	tagFloat  byte = 0x40
+	tagTime   byte = 0x50
+)
+
+// Escaping of variable length components: 0x00 is written as 0x00 0xff
+// and the component is terminated with 0x00 0x01.
+const (
+	escape        byte = 0x00
+	escapedEscape byte = 0xff
+	terminator    byte = 0x01
+)
+
+const (
+	intWidth  = 8
+	timeWidth = 12
+)
+
+// ErrUnsupportedType indicates a tuple component of a type which can not be encoded.
+var ErrUnsupportedType = fmt.Errorf("keys: unsupported component type")
+
+// Null is the value of a null tuple component. It sorts before any other value.
+var Null = null{}
+
+type null struct{}
+
+// Descending wraps a tuple component which sorts in reverse order.
+type Descending struct {
+	Value interface{}
+}
+
+// Desc marks the tuple component v as sorting in reverse order.
+func Desc(v interface{}) Descending {
+	return Descending{Value: v}
+}
+
+// Pack encodes values as an order-preserving tuple key.
+//
+// Supported component types are: nil or Null, []byte, string, bool,
+// int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+// float32, float64, time.Time and Descending wrapping any of them.
+func Pack(values ...interface{}) ([]byte, error) {
+	return AppendTuple(nil, values...)
+}
+
+// MustPack is like Pack but panics on unsupported component types.
+func MustPack(values ...interface{}) []byte {
+	key, err := Pack(values...)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// AppendTuple appends the encoding of values to dst and returns the extended buffer.
+func AppendTuple(dst []byte, values ...interface{}) (_ []byte, err error) {
+	for _, v := range values {
+		if dst, err = Append(dst, v); err != nil {
+			return nil, err
+		}
+	}
+	return dst, nil
+}
+
+// Append appends the encoding of the tuple component v to dst and returns
+// the extended buffer.
+func Append(dst []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return AppendNull(dst), nil
+	case null:
+		return AppendNull(dst), nil
+	case []byte:
+		return AppendBytes(dst, v), nil
+	case string:
+		return AppendString(dst, v), nil
+	case bool:
+		return AppendBool(dst, v), nil
+	case int:
+		return AppendInt(dst, int64(v)), nil
+	case int8:
+		return AppendInt(dst, int64(v)), nil
+	case int16:
+		return AppendInt(dst, int64(v)), nil
+	case int32:
+		return AppendInt(dst, int64(v)), nil
+	case int64:
+		return AppendInt(dst, v), nil
+	case uint:
+		return AppendUint(dst, uint64(v)), nil
+	case uint8:
+		return AppendUint(dst, uint64(v)), nil
+	case uint16:
+		return AppendUint(dst, uint64(v)), nil
+	case uint32:
+		return AppendUint(dst, uint64(v)), nil
+	case uint64:
+		return AppendUint(dst, v), nil
+	case float32:
+		return AppendFloat(dst, float64(v)), nil
+	case float64:
+		return AppendFloat(dst, v), nil
+	case time.Time:
+		return AppendTime(dst, v), nil
+	case Descending:
+		if _, nested := v.Value.(Descending); nested {
+			return nil, fmt.Errorf("%w: nested Descending", ErrUnsupportedType)
+		}
+		start := len(dst)
+		dst, err := Append(dst, v.Value)
+		if err != nil {
+			return nil, err
+		}
+		invert(dst[start:])
+		return dst, nil
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+}
+
+// AppendNull appends the encoding of a null component to dst.
+func AppendNull(dst []byte) []byte {
+	return append(dst, tagNull)
+}
+
+// AppendBytes appends the encoding of a byte string component to dst.
+func AppendBytes(dst, v []byte) []byte {
+	dst = append(dst, tagBytes)
+	for _, c := range v {
+		dst = appendEscapedByte(dst, c)
+	}
+	return append(dst, escape, terminator)
+}
+
+// AppendString appends the encoding of a string component to dst.
+func AppendString(dst []byte, v string) []byte {
+	dst = append(dst, tagString)
+	for i := 0; i < len(v); i++ {
+		dst = appendEscapedByte(dst, v[i])
+	}
+	return append(dst, escape, terminator)
+}
+
+// AppendBool appends the encoding of a boolean component to dst.
+// False sorts before true.
+func AppendBool(dst []byte, v bool) []byte {
+	if v {
+		return append(dst, tagTrue)
+	}
+	return append(dst, tagFalse)
+}
+
+// AppendInt appends the encoding of a signed integer component to dst.
+func AppendInt(dst []byte, v int64) []byte {
+	dst = append(dst, tagInt)
+	return appendUint64(dst, uint64(v)^(1<<63))
+}
+
+// AppendUint appends the encoding of an unsigned integer component to dst.
+func AppendUint(dst []byte, v uint64) []byte {
+	dst = append(dst, tagUint)
+	return appendUint64(dst, v)
+}
+
+// AppendFloat appends the encoding of a floating point component to dst.
+//
+// Negative zero sorts right before positive zero and NaNs are normalized
+// so that they sort after positive infinity.
+func AppendFloat(dst []byte, v float64) []byte {
+	dst = append(dst, tagFloat)
+	if math.IsNaN(v) {
+		v = math.NaN()
+	}
+
+	bits := math.Float64bits(v)
+	if bits&(1<<63) != 0 {
+		bits = ^bits
+	} else {
+		bits |= 1 << 63
+	}
+	return appendUint64(dst, bits)
+}
+
+// AppendTime appends the encoding of a timestamp component to dst.
+// Timestamps are compared by instant, their location is not encoded.
+func AppendTime(dst []byte, v time.Time) []byte {
+	dst = append(dst, tagTime)
+	dst = appendUint64(dst, uint64(v.Unix())^(1<<63))
+
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(v.Nanosecond()))
+	return append(dst, b[:]...)
+}
+
+func appendUint64(dst []byte, v uint64) []byte {
+	var b [intWidth]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	return append(dst, b[:]...)
+}
+
+func appendEscapedByte(dst []byte, c byte) []byte {
+	if c == escape {
+		return append(dst, escape, escapedEscape)
+	}
+	return append(dst, c)
+}
+
+func invert(b []byte) {
+	for i := range b {
+		b[i] = ^b[i]
+	}
+}
@@ -0,0 +1,226 @@
+package keys
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackUnpack(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Date(2023, 5, 17, 10, 30, 0, 123, time.UTC)
+	values := []interface{}{
+		Null,
+		[]byte("a\x00b"),
+		"tenant",
+		true,
+		false,
+		int64(-42),
+		uint64(42),
+		-1.5,
+		ts,
+		Desc("name"),
+		Desc(int64(7)),
+		Desc([]byte{0x00, 0xff}),
+	}
+
+	key, err := Pack(values...)
+	require.NoError(t, err)
+
+	decoded, err := Unpack(key)
+	require.NoError(t, err)
+	require.Equal(t, values, decoded)
+
+	// smaller integer types are widened
+	key, err = Pack(int8(-1), uint16(2), float32(0.5), nil)
+	require.NoError(t, err)
+	decoded, err = Unpack(key)
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{int64(-1), uint64(2), 0.5, Null}, decoded)
+
+	_, err = Pack(struct{}{})
+	require.ErrorIs(t, err, ErrUnsupportedType)
+	_, err = Pack(Desc(Desc(1)))
+	require.ErrorIs(t, err, ErrUnsupportedType)
+	require.Panics(t, func() { MustPack(struct{}{}) })
+}
+
+func TestDecoder(t *testing.T) {
+	t.Parallel()
+
+	key := MustPack(int64(1), "orders", Desc(uint64(9)))
+
+	d := NewDecoder(key)
+	id, err := d.Int()
+	require.NoError(t, err)
+	require.EqualValues(t, 1, id)
+
+	_, err = d.Int()
+	require.ErrorIs(t, err, ErrTypeMismatch)
+
+	table, err := d.String()
+	require.NoError(t, err)
+	require.Equal(t, "orders", table)
+
+	seq, err := d.Uint()
+	require.NoError(t, err)
+	require.EqualValues(t, 9, seq)
+	require.False(t, d.More())
+
+	_, err = d.Next()
+	require.ErrorIs(t, err, ErrCorrupted)
+
+	// keys truncated in the middle of a component
+	boundaries := map[int]bool{
+		len(MustPack(int64(1))):           true,
+		len(MustPack(int64(1), "orders")): true,
+	}
+	for i := 1; i < len(key); i++ {
+		if boundaries[i] {
+			continue
+		}
+		_, err := Unpack(key[:i])
+		require.ErrorIs(t, err, ErrCorrupted, "prefix of length %d", i)
+	}
+}
+
+func TestFloatOrder(t *testing.T) {
+	t.Parallel()
+
+	floats := []float64{math.Inf(-1), -math.MaxFloat64, -1, -math.SmallestNonzeroFloat64, math.Copysign(0, -1), 0, math.SmallestNonzeroFloat64, 1, math.MaxFloat64, math.Inf(1), math.NaN()}
+	for i := 1; i < len(floats); i++ {
+		require.Equal(t, -1, bytes.Compare(MustPack(floats[i-1]), MustPack(floats[i])), "%v < %v", floats[i-1], floats[i])
+	}
+
+	v, err := NewDecoder(MustPack(math.NaN())).Float()
+	require.NoError(t, err)
+	require.True(t, math.IsNaN(v))
+}
+
+func TestTypeOrder(t *testing.T) {
+	t.Parallel()
+
+	ordered := []interface{}{Null, []byte("z"), "a", false, true, int64(math.MaxInt64), uint64(0), math.Inf(-1), time.Unix(0, 0)}
+	for i := 1; i < len(ordered); i++ {
+		require.Equal(t, -1, bytes.Compare(MustPack(ordered[i-1]), MustPack(ordered[i])))
+	}
+}
+
+// tuple is the reference model of the keys used by the property test:
+// (tenant int64, table string, id int64 desc, payload []byte, ts time.Time).
+type tuple struct {
+	tenant  int64
+	table   string
+	id      int64
+	payload []byte
+	ts      time.Time
+}
+
+func (tp tuple) pack() []byte {
+	return MustPack(tp.tenant, tp.table, Desc(tp.id), tp.payload, tp.ts)
+}
+
+func (tp tuple) less(o tuple) bool {
+	if tp.tenant != o.tenant {
+		return tp.tenant < o.tenant
+	}
+	if tp.table != o.table {
+		return tp.table < o.table
+	}
+	if tp.id != o.id {
+		return tp.id > o.id
+	}
+	if c := bytes.Compare(tp.payload, o.payload); c != 0 {
+		return c < 0
+	}
+	return tp.ts.Before(o.ts)
+}
+
+func randomTuple(r *rand.Rand) tuple {
+	pick := func(n int) int64 { return r.Int63n(int64(n)) - int64(n/2) }
+	randBytes := func() []byte {
+		b := make([]byte, r.Intn(4))
+		for i := range b {
+			// favor bytes used by the escaping
+			switch r.Intn(4) {
+			case 0:
+				b[i] = 0x00
+			case 1:
+				b[i] = 0xff
+			case 2:
+				b[i] = 0x01
+			default:
+				b[i] = byte(r.Intn(256))
+			}
+		}
+		return b
+	}
+
+	tp := tuple{
+		tenant:  pick(4),
+		table:   string(randBytes()),
+		id:      pick(8),
+		payload: randBytes(),
+		ts:      time.Unix(pick(6), pick(4)+2).UTC(),
+	}
+	if r.Intn(8) == 0 {
+		tp.tenant = math.MinInt64
+	}
+	return tp
+}
+
+func TestOrderPreserving(t *testing.T) {
+	t.Parallel()
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	tuples := make([]tuple, 2000)
+	for i := range tuples {
+		tuples[i] = randomTuple(r)
+	}
+
+	for i := 0; i < 20000; i++ {
+		a, b := tuples[r.Intn(len(tuples))], tuples[r.Intn(len(tuples))]
+		c := bytes.Compare(a.pack(), b.pack())
+		switch {
+		case a.less(b):
+			require.Equal(t, -1, c, "%+v < %+v", a, b)
+		case b.less(a):
+			require.Equal(t, 1, c, "%+v > %+v", a, b)
+		default:
+			require.Equal(t, 0, c, "%+v == %+v", a, b)
+		}
+	}
+
+	// sorting encoded keys gives the logical order
+	encoded := make([][]byte, len(tuples))
+	for i := range tuples {
+		encoded[i] = tuples[i].pack()
+	}
+	sort.Slice(encoded, func(i, j int) bool { return bytes.Compare(encoded[i], encoded[j]) < 0 })
+	sort.SliceStable(tuples, func(i, j int) bool { return tuples[i].less(tuples[j]) })
+	for i := range tuples {
+		values, err := Unpack(encoded[i])
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{tuples[i].tenant, tuples[i].table, Desc(tuples[i].id), tuples[i].payload, tuples[i].ts}, values)
+	}
+}
+
+func TestStringEscaping(t *testing.T) {
+	t.Parallel()
+
+	ordered := []string{"", "\x00", "\x00\x00", "\x00\x01", "\x00\xff", "\x01", "a", "a\x00", "a\x00\x00", "a\x01", "ab", strings.Repeat("\xff", 3)}
+	for i := 1; i < len(ordered); i++ {
+		asc := bytes.Compare(MustPack(ordered[i-1], int64(1)), MustPack(ordered[i], int64(0)))
+		require.Equal(t, -1, asc, "%q < %q", ordered[i-1], ordered[i])
+
+		desc := bytes.Compare(MustPack(Desc(ordered[i-1]), int64(0)), MustPack(Desc(ordered[i]), int64(1)))
+		require.Equal(t, 1, desc, "desc %q > %q", ordered[i-1], ordered[i])
+	}
+}
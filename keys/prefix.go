@@ -0,0 +1,72 @@
+package keys
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrVariableWidth indicates a tuple component without a fixed encoded width.
+var ErrVariableWidth = fmt.Errorf("keys: component has no fixed width")
+
+// PrefixEnd returns the smallest key which is greater than every key starting
+// with prefix, to be used with ReadOptions.SetIterateUpperBound.
+//
+// It returns nil when there is no such key, which is the case when prefix is
+// empty or only made of 0xff bytes.
+func PrefixEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		end[i]++
+		if end[i] != 0 {
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+// PrefixRange returns the bounds of the keys starting with the tuple prefix
+// made of values: lower is inclusive and upper exclusive, matching
+// ReadOptions.SetIterateLowerBound and ReadOptions.SetIterateUpperBound.
+func PrefixRange(values ...interface{}) (lower, upper []byte, err error) {
+	lower, err = Pack(values...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return lower, PrefixEnd(lower), nil
+}
+
+// FixedPrefixLen returns the encoded length of a tuple prefix made of
+// values, to be given to NewFixedPrefixTransform. Only the type of values
+// matters, all of them must be encoded with a fixed width: nulls, booleans,
+// integers, floats and timestamps, ascending or descending.
+func FixedPrefixLen(values ...interface{}) (int, error) {
+	n := 0
+	for _, v := range values {
+		w, err := fixedWidth(v)
+		if err != nil {
+			return 0, err
+		}
+		n += w
+	}
+	return n, nil
+}
+
+func fixedWidth(v interface{}) (int, error) {
+	switch v := v.(type) {
+	case nil, null, bool:
+		return 1, nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return 1 + intWidth, nil
+	case time.Time:
+		return 1 + timeWidth, nil
+	case Descending:
+		if _, nested := v.Value.(Descending); nested {
+			return 0, fmt.Errorf("%w: nested Descending", ErrUnsupportedType)
+		}
+		return fixedWidth(v.Value)
+	case []byte, string:
+		return 0, fmt.Errorf("%w: %T", ErrVariableWidth, v)
+	default:
+		return 0, fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+}
@@ -0,0 +1,70 @@
+package keys
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefixEnd(t *testing.T) {
+	t.Parallel()
+
+	require.Nil(t, PrefixEnd(nil))
+	require.Nil(t, PrefixEnd([]byte{0xff, 0xff}))
+	require.Equal(t, []byte{0x01, 0x03}, PrefixEnd([]byte{0x01, 0x02}))
+	require.Equal(t, []byte{0x02}, PrefixEnd([]byte{0x01, 0xff}))
+
+	prefix := []byte{0x01, 0x02}
+	PrefixEnd(prefix)
+	require.Equal(t, []byte{0x01, 0x02}, prefix)
+}
+
+func TestPrefixRange(t *testing.T) {
+	t.Parallel()
+
+	lower, upper, err := PrefixRange(int64(7), "orders")
+	require.NoError(t, err)
+
+	inside := [][]byte{
+		MustPack(int64(7), "orders"),
+		MustPack(int64(7), "orders", int64(-1)),
+		MustPack(int64(7), "orders", Desc("zzz")),
+		MustPack(int64(7), "orders", 1e300),
+	}
+	for _, key := range inside {
+		require.True(t, bytes.HasPrefix(key, lower))
+		require.True(t, bytes.Compare(lower, key) <= 0)
+		require.Equal(t, -1, bytes.Compare(key, upper))
+	}
+
+	outside := [][]byte{
+		MustPack(int64(7), "order"),
+		MustPack(int64(7), "orders\x00"),
+		MustPack(int64(8)),
+		MustPack(int64(6), "orders"),
+	}
+	for _, key := range outside {
+		require.False(t, bytes.HasPrefix(key, lower))
+		require.True(t, bytes.Compare(key, lower) < 0 || bytes.Compare(key, upper) >= 0)
+	}
+
+	_, _, err = PrefixRange(struct{}{})
+	require.ErrorIs(t, err, ErrUnsupportedType)
+}
+
+func TestFixedPrefixLen(t *testing.T) {
+	t.Parallel()
+
+	values := []interface{}{int64(1), Desc(uint32(2)), true, nil, 2.5, time.Now()}
+	n, err := FixedPrefixLen(values...)
+	require.NoError(t, err)
+	require.Equal(t, len(MustPack(values...)), n)
+
+	_, err = FixedPrefixLen(int64(1), "table")
+	require.ErrorIs(t, err, ErrVariableWidth)
+
+	_, err = FixedPrefixLen(struct{}{})
+	require.ErrorIs(t, err, ErrUnsupportedType)
+}
@@ -0,0 +1,55 @@
+package grocksdb
+
+// Reader reads data from a database, or from a consistent view of it.
+type Reader interface {
+	Get(opts *ReadOptions, key []byte) (*Slice, error)
+	GetCF(opts *ReadOptions, cf *ColumnFamilyHandle, key []byte) (*Slice, error)
+	GetPinned(opts *ReadOptions, key []byte) (*PinnableSlice, error)
+	GetPinnedCF(opts *ReadOptions, cf *ColumnFamilyHandle, key []byte) (*PinnableSlice, error)
+	MultiGet(opts *ReadOptions, keys ...[]byte) (Slices, error)
+	MultiGetCF(opts *ReadOptions, cf *ColumnFamilyHandle, keys ...[]byte) (Slices, error)
+}
+
+// Writer writes data to a database.
+//
+// DeleteRangeCF of TransactionDB bypasses the locks of transactions, see
+// TransactionDB.DeleteRangeCF.
+type Writer interface {
+	Put(opts *WriteOptions, key, value []byte) error
+	PutCF(opts *WriteOptions, cf *ColumnFamilyHandle, key, value []byte) error
+	Delete(opts *WriteOptions, key []byte) error
+	DeleteCF(opts *WriteOptions, cf *ColumnFamilyHandle, key []byte) error
+	SingleDelete(opts *WriteOptions, key []byte) error
+	SingleDeleteCF(opts *WriteOptions, cf *ColumnFamilyHandle, key []byte) error
+	DeleteRangeCF(opts *WriteOptions, cf *ColumnFamilyHandle, startKey, endKey []byte) error
+	Merge(opts *WriteOptions, key, value []byte) error
+	MergeCF(opts *WriteOptions, cf *ColumnFamilyHandle, key, value []byte) error
+	Write(opts *WriteOptions, batch *WriteBatch) error
+}
+
+// Iterable iterates over the data of a database, or of a consistent view of it.
+type Iterable interface {
+	NewIterator(opts *ReadOptions) *Iterator
+	NewIteratorCF(opts *ReadOptions, cf *ColumnFamilyHandle) *Iterator
+}
+
+// KV is the key-value store interface implemented by DB, TransactionDB and
+// OptimisticTransactionDB, so that code can be written once against any of
+// them. NewGoKV adapts it to kv.KV, which has no cgo types.
+type KV interface {
+	Reader
+	Writer
+	Iterable
+	NewSnapshot() *Snapshot
+	ReleaseSnapshot(snapshot *Snapshot)
+}
+
+var (
+	_ KV = (*DB)(nil)
+	_ KV = (*TransactionDB)(nil)
+	_ KV = (*OptimisticTransactionDB)(nil)
+
+	// views bound to a snapshot only read
+	_ Reader   = (*View)(nil)
+	_ Iterable = (*View)(nil)
+)
@@ -0,0 +1,144 @@
+/*
+Package kv defines the key-value store interfaces of grocksdb without its cgo
+types, so that code written against them can be unit tested without linking
+librocksdb.
+
+grocksdb.NewGoKV adapts the databases of grocksdb to KV, and memdb.NewKV adapts
+the in-memory databases of memdb:
+
+	func count(db kv.KV, prefix []byte) (n int, err error) {
+		iter := db.NewIterator(&kv.ReadOptions{IterateLowerBound: prefix})
+		defer iter.Close()
+		for iter.Seek(prefix); iter.ValidForPrefix(prefix); iter.Next() {
+			n++
+		}
+		return n, iter.Err()
+	}
+
+Column families, snapshots and write batches are those of the KV they are
+used with: using them with another KV fails with ErrForeign.
+*/
+package kv
+
+import "fmt"
+
+// ErrForeign is returned when a column family, snapshot or write batch is used
+// with another KV than the one it comes from.
+var ErrForeign = fmt.Errorf("kv: column family, snapshot or write batch of another store")
+
+// ColumnFamily is a column family of a KV.
+type ColumnFamily interface {
+	Name() string
+	ID() uint32
+}
+
+// Snapshot is a consistent view of a KV.
+type Snapshot interface {
+	SequenceNumber() uint64
+}
+
+// ReadOptions configures reads. Default options are used when nil.
+type ReadOptions struct {
+	// Snapshot to read from. The latest data is read when nil.
+	Snapshot Snapshot
+	// IterateLowerBound is the inclusive lower bound of iterators.
+	IterateLowerBound []byte
+	// IterateUpperBound is the exclusive upper bound of iterators.
+	IterateUpperBound []byte
+}
+
+// Iterator iterates over the keys of a column family. The key and value it
+// returns must not be modified, and are only valid until the iterator moves.
+type Iterator interface {
+	Valid() bool
+	ValidForPrefix(prefix []byte) bool
+	Key() []byte
+	Value() []byte
+	Next()
+	Prev()
+	SeekToFirst()
+	SeekToLast()
+	Seek(key []byte)
+	SeekForPrev(key []byte)
+	Err() error
+	Close()
+}
+
+// WriteBatch holds writes to be applied atomically by Writer.Write. The errors
+// of its writes, such as ErrForeign, are returned by Writer.Write.
+type WriteBatch interface {
+	Put(key, value []byte)
+	PutCF(cf ColumnFamily, key, value []byte)
+	Delete(key []byte)
+	DeleteCF(cf ColumnFamily, key []byte)
+	SingleDelete(key []byte)
+	SingleDeleteCF(cf ColumnFamily, key []byte)
+	DeleteRangeCF(cf ColumnFamily, startKey, endKey []byte)
+	Merge(key, operand []byte)
+	MergeCF(cf ColumnFamily, key, operand []byte)
+	Count() int
+	Clear()
+	Destroy()
+}
+
+// Reader reads data from a KV. Reads return nil for the keys which do not
+// exist.
+type Reader interface {
+	Get(opts *ReadOptions, key []byte) ([]byte, error)
+	GetCF(opts *ReadOptions, cf ColumnFamily, key []byte) ([]byte, error)
+	MultiGet(opts *ReadOptions, keys ...[]byte) ([][]byte, error)
+	MultiGetCF(opts *ReadOptions, cf ColumnFamily, keys ...[]byte) ([][]byte, error)
+}
+
+// Writer writes data to a KV.
+type Writer interface {
+	Put(key, value []byte) error
+	PutCF(cf ColumnFamily, key, value []byte) error
+	Delete(key []byte) error
+	DeleteCF(cf ColumnFamily, key []byte) error
+	SingleDelete(key []byte) error
+	SingleDeleteCF(cf ColumnFamily, key []byte) error
+	DeleteRangeCF(cf ColumnFamily, startKey, endKey []byte) error
+	Merge(key, operand []byte) error
+	MergeCF(cf ColumnFamily, key, operand []byte) error
+	NewWriteBatch() WriteBatch
+	Write(batch WriteBatch) error
+}
+
+// Iterable iterates over the data of a KV.
+type Iterable interface {
+	NewIterator(opts *ReadOptions) Iterator
+	NewIteratorCF(opts *ReadOptions, cf ColumnFamily) Iterator
+}
+
+// KV is a key-value store.
+type KV interface {
+	Reader
+	Writer
+	Iterable
+	NewSnapshot() Snapshot
+	ReleaseSnapshot(snapshot Snapshot)
+}
+
+// NewErrorIterator returns an iterator which is not valid, and whose Err
+// returns err. It is returned by the KVs failing to create iterators.
+func NewErrorIterator(err error) Iterator {
+	return errorIterator{err: err}
+}
+
+type errorIterator struct {
+	err error
+}
+
+func (iter errorIterator) Valid() bool                  { return false }
+func (iter errorIterator) ValidForPrefix(_ []byte) bool { return false }
+func (iter errorIterator) Key() []byte                  { return nil }
+func (iter errorIterator) Value() []byte                { return nil }
+func (iter errorIterator) Next()                        {}
+func (iter errorIterator) Prev()                        {}
+func (iter errorIterator) SeekToFirst()                 {}
+func (iter errorIterator) SeekToLast()                  {}
+func (iter errorIterator) Seek(_ []byte)                {}
+func (iter errorIterator) SeekForPrev(_ []byte)         {}
+func (iter errorIterator) Err() error                   { return iter.err }
+func (iter errorIterator) Close()                       {}
@@ -0,0 +1,180 @@
+// Package kvtest checks implementations of kv.KV, so that the same checks run
+// against RocksDB and against the fakes of tests.
+package kvtest
+
+import (
+	"testing"
+
+	"github.com/linxGnu/grocksdb/kv"
+	"github.com/stretchr/testify/require"
+)
+
+// Run checks the operations of db, an empty KV whose default column family is
+// cf, and whose merge operator appends the operands to the value.
+func Run(t *testing.T, db kv.KV, cf kv.ColumnFamily) {
+	t.Run("ReadWrite", func(t *testing.T) {
+		testReadWrite(t, db, cf)
+	})
+	t.Run("DeleteRange", func(t *testing.T) {
+		testDeleteRange(t, db, cf)
+	})
+	t.Run("Iterator", func(t *testing.T) {
+		testIterator(t, db)
+	})
+}
+
+func testReadWrite(t *testing.T, db kv.KV, cf kv.ColumnFamily) {
+	requireValue := func(key, expected string) {
+		v, err := db.Get(nil, []byte(key))
+		require.Nil(t, err)
+		if expected == "" {
+			require.Nil(t, v, key)
+		} else {
+			require.EqualValues(t, expected, v, key)
+		}
+	}
+
+	// writes
+	require.Nil(t, db.Put([]byte("a"), []byte("1")))
+	require.Nil(t, db.PutCF(cf, []byte("b"), []byte("2")))
+	require.Nil(t, db.Merge([]byte("b"), []byte("3")))
+	require.Nil(t, db.MergeCF(cf, []byte("c"), []byte("4")))
+	require.Nil(t, db.Put([]byte("d"), []byte("5")))
+	require.Nil(t, db.SingleDelete([]byte("d")))
+	require.Nil(t, db.Put([]byte("e"), []byte("6")))
+	require.Nil(t, db.SingleDeleteCF(cf, []byte("e")))
+
+	batch := db.NewWriteBatch()
+	batch.Put([]byte("f"), []byte("7"))
+	batch.PutCF(cf, []byte("g"), []byte("8"))
+	batch.Merge([]byte("g"), []byte("9"))
+	require.Equal(t, 3, batch.Count())
+	require.Nil(t, db.Write(batch))
+	batch.Destroy()
+	requireValue("f", "7")
+	requireValue("g", "89")
+
+	require.Nil(t, db.Delete([]byte("f")))
+	require.Nil(t, db.DeleteCF(cf, []byte("g")))
+
+	requireValue("a", "1")
+	requireValue("b", "23")
+	requireValue("c", "4")
+	requireValue("d", "")
+	requireValue("e", "")
+	requireValue("f", "")
+	requireValue("g", "")
+
+	// empty values exist
+	require.Nil(t, db.Put([]byte("empty"), nil))
+	v, err := db.Get(nil, []byte("empty"))
+	require.Nil(t, err)
+	require.NotNil(t, v)
+	require.Empty(t, v)
+	require.Nil(t, db.Delete([]byte("empty")))
+
+	// reads
+	v, err = db.GetCF(nil, cf, []byte("b"))
+	require.Nil(t, err)
+	require.EqualValues(t, "23", v)
+
+	values, err := db.MultiGet(nil, []byte("a"), []byte("d"))
+	require.Nil(t, err)
+	require.EqualValues(t, "1", values[0])
+	require.Nil(t, values[1])
+
+	values, err = db.MultiGetCF(nil, cf, []byte("b"), []byte("c"))
+	require.Nil(t, err)
+	require.EqualValues(t, "23", values[0])
+	require.EqualValues(t, "4", values[1])
+
+	// snapshots
+	snapshot := db.NewSnapshot()
+	require.Nil(t, db.Put([]byte("h"), []byte("10")))
+	require.Nil(t, db.Put([]byte("a"), []byte("11")))
+
+	snapshotRO := &kv.ReadOptions{Snapshot: snapshot}
+	v, err = db.Get(snapshotRO, []byte("a"))
+	require.Nil(t, err)
+	require.EqualValues(t, "1", v)
+
+	var keys []string
+	iter := db.NewIteratorCF(snapshotRO, cf)
+	for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+		keys = append(keys, string(iter.Key()))
+	}
+	require.Nil(t, iter.Err())
+	iter.Close()
+	require.Equal(t, []string{"a", "b", "c"}, keys)
+	db.ReleaseSnapshot(snapshot)
+
+	v, err = db.Get(nil, []byte("a"))
+	require.Nil(t, err)
+	require.EqualValues(t, "11", v)
+
+	// foreign column families
+	require.ErrorIs(t, db.PutCF(foreignColumnFamily{}, []byte("a"), []byte("1")), kv.ErrForeign)
+	batch = db.NewWriteBatch()
+	batch.PutCF(foreignColumnFamily{}, []byte("a"), []byte("1"))
+	require.ErrorIs(t, db.Write(batch), kv.ErrForeign)
+	batch.Destroy()
+	iter = db.NewIteratorCF(nil, foreignColumnFamily{})
+	require.False(t, iter.Valid())
+	require.ErrorIs(t, iter.Err(), kv.ErrForeign)
+	iter.Close()
+}
+
+func testDeleteRange(t *testing.T, db kv.KV, cf kv.ColumnFamily) {
+	require.Nil(t, db.DeleteRangeCF(cf, []byte("a"), []byte("c")))
+
+	var keys []string
+	iter := db.NewIterator(nil)
+	for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+		keys = append(keys, string(iter.Key()))
+	}
+	require.Nil(t, iter.Err())
+	iter.Close()
+	require.Equal(t, []string{"c", "h"}, keys)
+}
+
+func testIterator(t *testing.T, db kv.KV) {
+	for _, key := range []string{"it/1", "it/2", "it/3"} {
+		require.Nil(t, db.Put([]byte(key), []byte("v"+key)))
+	}
+
+	iter := db.NewIterator(&kv.ReadOptions{
+		IterateLowerBound: []byte("it/"),
+		IterateUpperBound: []byte("it0"),
+	})
+	defer iter.Close()
+
+	var keys []string
+	for iter.SeekToFirst(); iter.ValidForPrefix([]byte("it/")); iter.Next() {
+		keys = append(keys, string(iter.Key()))
+		require.Equal(t, "v"+string(iter.Key()), string(iter.Value()))
+	}
+	require.Nil(t, iter.Err())
+	require.Equal(t, []string{"it/1", "it/2", "it/3"}, keys)
+
+	keys = nil
+	for iter.SeekToLast(); iter.Valid(); iter.Prev() {
+		keys = append(keys, string(iter.Key()))
+	}
+	require.Equal(t, []string{"it/3", "it/2", "it/1"}, keys)
+
+	iter.Seek([]byte("it/15"))
+	require.True(t, iter.Valid())
+	require.Equal(t, "it/2", string(iter.Key()))
+	iter.SeekForPrev([]byte("it/15"))
+	require.True(t, iter.Valid())
+	require.Equal(t, "it/1", string(iter.Key()))
+	iter.Seek([]byte("it/4"))
+	require.False(t, iter.Valid())
+	require.Nil(t, iter.Err())
+}
+
+// foreignColumnFamily is a column family of no KV.
+type foreignColumnFamily struct{}
+
+func (foreignColumnFamily) Name() string { return "foreign" }
+func (foreignColumnFamily) ID() uint32   { return 1 << 31 }
@@ -0,0 +1,250 @@
+package grocksdb
+
+import (
+	"testing"
+
+	"github.com/linxGnu/grocksdb/kv/kvtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKV(t *testing.T) {
+	t.Parallel()
+
+	merger := &mockMergeOperator{
+		fullMerge: func(_, existingValue []byte, operands [][]byte) ([]byte, bool) {
+			for _, operand := range operands {
+				existingValue = append(existingValue, operand...)
+			}
+			return existingValue, true
+		},
+	}
+	newOpts := func() *Options {
+		opts := NewDefaultOptions()
+		opts.SetCreateIfMissing(true)
+		opts.SetMergeOperator(merger)
+		return opts
+	}
+
+	t.Run("DB", func(t *testing.T) {
+		t.Parallel()
+
+		opts := newOpts()
+		db, cfh, err := OpenDbColumnFamilies(opts, t.TempDir(), []string{"default"}, []*Options{opts})
+		require.Nil(t, err)
+		defer db.Close()
+		defer cfh[0].Destroy()
+		testKV(t, db, cfh[0])
+		testKVDeleteRange(t, db, cfh[0])
+		testKVIterator(t, db)
+	})
+
+	t.Run("TransactionDB", func(t *testing.T) {
+		t.Parallel()
+
+		opts := newOpts()
+		transactionDBOpts := NewDefaultTransactionDBOptions()
+		defer transactionDBOpts.Destroy()
+		db, cfh, err := OpenTransactionDbColumnFamilies(opts, transactionDBOpts, t.TempDir(), []string{"default"}, []*Options{opts})
+		require.Nil(t, err)
+		defer db.Close()
+		defer cfh[0].Destroy()
+		testKV(t, db, cfh[0])
+		testKVDeleteRange(t, db, cfh[0])
+		testKVIterator(t, db)
+
+		sizes, err := db.GetApproximateSizesCF(cfh[0], []Range{{Start: []byte("a"), Limit: []byte("z")}})
+		require.Nil(t, err)
+		require.Len(t, sizes, 1)
+	})
+
+	t.Run("OptimisticTransactionDB", func(t *testing.T) {
+		t.Parallel()
+
+		opts := newOpts()
+		db, cfh, err := OpenOptimisticTransactionDbColumnFamilies(opts, t.TempDir(), []string{"default"}, []*Options{opts})
+		require.Nil(t, err)
+		defer db.Close()
+		defer cfh[0].Destroy()
+		testKV(t, db, cfh[0])
+		testKVDeleteRange(t, db, cfh[0])
+		testKVIterator(t, db)
+	})
+
+	t.Run("GoKV", func(t *testing.T) {
+		t.Parallel()
+
+		opts := newOpts()
+		db, cfh, err := OpenDbColumnFamilies(opts, t.TempDir(), []string{"default"}, []*Options{opts})
+		require.Nil(t, err)
+		defer db.Close()
+		defer cfh[0].Destroy()
+		kvtest.Run(t, NewGoKV(db, nil), cfh[0])
+
+		transactionDBOpts := NewDefaultTransactionDBOptions()
+		defer transactionDBOpts.Destroy()
+		tdb, tcfh, err := OpenTransactionDbColumnFamilies(opts, transactionDBOpts, t.TempDir(), []string{"default"}, []*Options{opts})
+		require.Nil(t, err)
+		defer tdb.Close()
+		defer tcfh[0].Destroy()
+		wo := NewDefaultWriteOptions()
+		defer wo.Destroy()
+		kvtest.Run(t, NewGoKV(tdb, wo), tcfh[0])
+	})
+}
+
+// testKV checks the operations of kv, whose default column family is cf.
+func testKV(t *testing.T, kv KV, cf *ColumnFamilyHandle) {
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+
+	requireValue := func(key, expected string) {
+		v, err := kv.Get(ro, []byte(key))
+		require.Nil(t, err)
+		require.EqualValues(t, expected, v.Data(), key)
+		v.Free()
+	}
+
+	// writes
+	require.Nil(t, kv.Put(wo, []byte("a"), []byte("1")))
+	require.Nil(t, kv.PutCF(wo, cf, []byte("b"), []byte("2")))
+	require.Nil(t, kv.Merge(wo, []byte("b"), []byte("3")))
+	require.Nil(t, kv.MergeCF(wo, cf, []byte("c"), []byte("4")))
+	require.Nil(t, kv.Put(wo, []byte("d"), []byte("5")))
+	require.Nil(t, kv.SingleDelete(wo, []byte("d")))
+	require.Nil(t, kv.Put(wo, []byte("e"), []byte("6")))
+	require.Nil(t, kv.SingleDeleteCF(wo, cf, []byte("e")))
+
+	batch := NewWriteBatch()
+	batch.Put([]byte("f"), []byte("7"))
+	batch.Put([]byte("g"), []byte("8"))
+	require.Nil(t, kv.Write(wo, batch))
+	batch.Destroy()
+
+	require.Nil(t, kv.Delete(wo, []byte("f")))
+	require.Nil(t, kv.DeleteCF(wo, cf, []byte("g")))
+
+	requireValue("a", "1")
+	requireValue("b", "23")
+	requireValue("c", "4")
+	requireValue("d", "")
+	requireValue("e", "")
+	requireValue("f", "")
+	requireValue("g", "")
+
+	// reads
+	v, err := kv.GetCF(ro, cf, []byte("b"))
+	require.Nil(t, err)
+	require.EqualValues(t, "23", v.Data())
+	v.Free()
+
+	pinned, err := kv.GetPinned(ro, []byte("a"))
+	require.Nil(t, err)
+	require.EqualValues(t, "1", pinned.Data())
+	pinned.Destroy()
+
+	pinned, err = kv.GetPinnedCF(ro, cf, []byte("c"))
+	require.Nil(t, err)
+	require.EqualValues(t, "4", pinned.Data())
+	pinned.Destroy()
+
+	values, err := kv.MultiGet(ro, []byte("a"), []byte("d"))
+	require.Nil(t, err)
+	require.EqualValues(t, "1", values[0].Data())
+	require.False(t, values[1].Exists())
+	values.Destroy()
+
+	values, err = kv.MultiGetCF(ro, cf, []byte("b"), []byte("c"))
+	require.Nil(t, err)
+	require.EqualValues(t, "23", values[0].Data())
+	require.EqualValues(t, "4", values[1].Data())
+	values.Destroy()
+
+	// snapshots and iterators
+	snapshot := kv.NewSnapshot()
+	require.Nil(t, kv.Put(wo, []byte("h"), []byte("9")))
+
+	snapshotRO := NewDefaultReadOptions()
+	defer snapshotRO.Destroy()
+	snapshotRO.SetSnapshot(snapshot)
+
+	var keys []string
+	iter := kv.NewIteratorCF(snapshotRO, cf)
+	for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+		keys = append(keys, string(iter.Key().Data()))
+	}
+	require.Nil(t, iter.Err())
+	iter.Close()
+	require.Equal(t, []string{"a", "b", "c"}, keys)
+	kv.ReleaseSnapshot(snapshot)
+}
+
+// testKVDeleteRange checks the range deletions of kv, written by testKV.
+func testKVDeleteRange(t *testing.T, kv KV, cf *ColumnFamilyHandle) {
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+
+	require.Nil(t, kv.DeleteRangeCF(wo, cf, []byte("a"), []byte("c")))
+
+	var keys []string
+	iter := kv.NewIterator(ro)
+	for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+		keys = append(keys, string(iter.Key().Data()))
+	}
+	require.Nil(t, iter.Err())
+	iter.Close()
+	require.Equal(t, []string{"c", "h"}, keys)
+}
+
+// testKVIterator checks the iterators of kv, with keys of their own prefix.
+func testKVIterator(t *testing.T, kv KV) {
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	for _, key := range []string{"it/1", "it/2", "it/3"} {
+		require.Nil(t, kv.Put(wo, []byte(key), []byte("v"+key)))
+	}
+
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+	ro.SetIterateLowerBound([]byte("it/"))
+	ro.SetIterateUpperBound([]byte("it0"))
+
+	iter := kv.NewIterator(ro)
+	defer iter.Close()
+
+	var keys []string
+	for iter.SeekToFirst(); iter.ValidForPrefix([]byte("it/")); iter.Next() {
+		keys = append(keys, string(iter.Key().Data()))
+		require.Equal(t, "v"+string(iter.Key().Data()), string(iter.Value().Data()))
+		require.Equal(t, iter.Key().Data(), iter.KeySlice().Data())
+		require.Equal(t, iter.Value().Data(), iter.ValueSlice().Data())
+	}
+	require.Nil(t, iter.Err())
+	require.Equal(t, []string{"it/1", "it/2", "it/3"}, keys)
+
+	keys = nil
+	for iter.SeekToLast(); iter.Valid(); iter.Prev() {
+		keys = append(keys, string(iter.Key().Data()))
+	}
+	require.Equal(t, []string{"it/3", "it/2", "it/1"}, keys)
+
+	iter.Seek([]byte("it/15"))
+	require.True(t, iter.Valid())
+	require.Equal(t, "it/2", string(iter.Key().Data()))
+	iter.SeekForPrev([]byte("it/15"))
+	require.True(t, iter.Valid())
+	require.Equal(t, "it/1", string(iter.Key().Data()))
+	iter.Seek([]byte("it/4"))
+	require.False(t, iter.Valid())
+
+	// refreshed iterators read the latest data
+	require.Nil(t, kv.Put(wo, []byte("it/4"), []byte("vit/4")))
+	require.Nil(t, iter.Refresh())
+	iter.SeekToLast()
+	require.True(t, iter.Valid())
+	require.Equal(t, "it/4", string(iter.Key().Data()))
+	require.Nil(t, iter.Err())
+}
@@ -0,0 +1,294 @@
+package grocksdb
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LiveFileType is the type of a file of LiveFileStorageInfo.
+type LiveFileType int
+
+const (
+	// LiveFileWAL is a WAL file.
+	LiveFileWAL LiveFileType = iota
+	// LiveFileTable is a table (SST) file.
+	LiveFileTable
+	// LiveFileBlob is a blob file.
+	LiveFileBlob
+	// LiveFileManifest is the MANIFEST file.
+	LiveFileManifest
+	// LiveFileCurrent is the CURRENT file, naming the MANIFEST file.
+	LiveFileCurrent
+	// LiveFileOptions is the OPTIONS file.
+	LiveFileOptions
+)
+
+// LiveFileStorageInfo is a file to copy for a consistent copy of a
+// database.
+type LiveFileStorageInfo struct {
+	// RelativeFilename is the name of the file in Directory.
+	RelativeFilename string
+	Directory        string
+	FileNumber       uint64
+	FileType         LiveFileType
+	// Size is the size of the file to copy, in bytes.
+	Size uint64
+	// TrimToSize is whether only the first Size bytes of the file are to be
+	// copied, the file being appended to.
+	TrimToSize bool
+	// ReplacementContents, if not nil, is the content to write instead of
+	// copying the file.
+	ReplacementContents []byte
+	// ColumnFamilyName is the column family of live table files.
+	ColumnFamilyName string
+}
+
+// LiveFilesStorageInfoOptions are the options of GetLiveFilesStorageInfo.
+type LiveFilesStorageInfoOptions struct {
+	// WalSizeForFlush is the total size of the live WAL files from which the
+	// memtables are flushed first, 0 always flushing them.
+	WalSizeForFlush uint64
+	// ColumnFamilies are the column families flushed, the default column
+	// family when empty.
+	ColumnFamilies []*ColumnFamilyHandle
+}
+
+// GetLiveFilesStorageInfo returns the files to copy for a consistent copy of
+// the database: table and blob files, the MANIFEST, CURRENT and OPTIONS
+// files and the live WAL files, the MANIFEST and WAL files being trimmed to
+// their size as of the call. nil opts use the default options.
+//
+// The C API of RocksDB lacks GetLiveFilesStorageInfo, which this
+// approximates on a best-effort basis from the live table files of the
+// database and the files of its directories, rather than from the state of
+// the database: the copy may have obsolete files, deleted once it is opened.
+//
+// File deletions must be disabled with DisableFileDeletions until the files
+// are copied. As the MANIFEST is appended to while the files are listed,
+// the table files are the live table files listed before and after the
+// MANIFEST is read, and the blob files all the blob files of the database:
+// files obsolete by then are deleted once the copy is opened.
+func (db *DB) GetLiveFilesStorageInfo(opts *LiveFilesStorageInfoOptions) (files []LiveFileStorageInfo, err error) {
+	if opts == nil {
+		opts = &LiveFilesStorageInfoOptions{}
+	}
+	if err = db.flushForLiveFiles(opts); err != nil {
+		return
+	}
+
+	// the MANIFEST is read between two listings of the live table files, so
+	// that the files it refers to are listed though compacted meanwhile, and
+	// before the blob files, which are written before the MANIFEST refers to
+	// them
+	live := db.GetLiveFilesMetaData()
+	current, err := os.ReadFile(filepath.Join(db.name, "CURRENT"))
+	if err != nil {
+		return
+	}
+	manifest := strings.TrimSuffix(string(current), "\n")
+	fi, err := os.Stat(filepath.Join(db.name, manifest))
+	if err != nil {
+		return
+	}
+	manifestSize := uint64(fi.Size())
+
+	if files, err = db.tableAndBlobFiles(live); err != nil {
+		return
+	}
+
+	options, optionsSize, err := newestOptionsFile(db.name)
+	if err != nil {
+		return
+	}
+	if options != "" {
+		files = append(files, LiveFileStorageInfo{
+			RelativeFilename: options,
+			Directory:        db.name,
+			FileNumber:       fileNumber(strings.TrimPrefix(options, "OPTIONS-")),
+			FileType:         LiveFileOptions,
+			Size:             optionsSize,
+		})
+	}
+
+	files = append(files,
+		LiveFileStorageInfo{
+			RelativeFilename: manifest,
+			Directory:        db.name,
+			FileNumber:       fileNumber(strings.TrimPrefix(manifest, "MANIFEST-")),
+			FileType:         LiveFileManifest,
+			Size:             manifestSize,
+			TrimToSize:       true,
+		},
+		LiveFileStorageInfo{
+			RelativeFilename:    "CURRENT",
+			Directory:           db.name,
+			FileType:            LiveFileCurrent,
+			Size:                uint64(len(current)),
+			ReplacementContents: current,
+		},
+	)
+
+	// the WAL files are listed last, with the writes made so far
+	if err = db.FlushWAL(true); err != nil {
+		return
+	}
+	walDir, err := db.walDir()
+	if err != nil {
+		return
+	}
+	wals, err := listWalFiles(walDir, "", WalFileLive)
+	if err != nil {
+		return
+	}
+	for _, wal := range wals {
+		files = append(files, LiveFileStorageInfo{
+			RelativeFilename: strings.TrimPrefix(wal.PathName, "/"),
+			Directory:        walDir,
+			FileNumber:       wal.LogNumber,
+			FileType:         LiveFileWAL,
+			Size:             wal.Size,
+			TrimToSize:       true,
+		})
+	}
+	return
+}
+
+// flushForLiveFiles flushes the memtables if the live WAL files are large
+// enough.
+func (db *DB) flushForLiveFiles(opts *LiveFilesStorageInfoOptions) error {
+	if opts.WalSizeForFlush > 0 {
+		walDir, err := db.walDir()
+		if err != nil {
+			return err
+		}
+		wals, err := listWalFiles(walDir, "", WalFileLive)
+		if err != nil {
+			return err
+		}
+
+		var size uint64
+		for _, wal := range wals {
+			size += wal.Size
+		}
+		if size < opts.WalSizeForFlush {
+			return nil
+		}
+	}
+
+	fo := NewDefaultFlushOptions()
+	defer fo.Destroy()
+	fo.SetWait(true)
+
+	if len(opts.ColumnFamilies) > 0 {
+		return db.FlushCFs(opts.ColumnFamilies, fo)
+	}
+	return db.Flush(fo)
+}
+
+// tableAndBlobFiles returns the live table files of the database, listed
+// along with the given live table files, and the blob files of the
+// directories of the database. Table files being written are left out, as
+// they aren't live yet; blob files may be, which the MANIFEST doesn't refer
+// to.
+func (db *DB) tableAndBlobFiles(live []LiveFileMetadata) (files []LiveFileStorageInfo, err error) {
+	live = append(live, db.GetLiveFilesMetaData()...)
+
+	dirs := []string{db.name}
+	listed := make(map[string]struct{}, len(live))
+	for _, file := range live {
+		dir := file.Directory
+		if dir == "" {
+			dir = db.name
+		}
+		name := strings.TrimPrefix(file.Name, "/")
+		if _, ok := listed[filepath.Join(dir, name)]; ok {
+			continue
+		}
+		listed[filepath.Join(dir, name)] = struct{}{}
+		dirs = append(dirs, dir)
+
+		files = append(files, LiveFileStorageInfo{
+			RelativeFilename: name,
+			Directory:        dir,
+			FileNumber:       fileNumber(strings.TrimSuffix(name, filepath.Ext(name))),
+			FileType:         LiveFileTable,
+			Size:             uint64(file.Size),
+			ColumnFamilyName: file.ColumnFamilyName,
+		})
+	}
+
+	seen := make(map[string]struct{}, len(dirs))
+	for _, dir := range dirs {
+		abs, aerr := filepath.Abs(dir)
+		if aerr != nil {
+			return nil, aerr
+		}
+		if _, ok := seen[abs]; ok {
+			continue
+		}
+		seen[abs] = struct{}{}
+
+		var entries []os.DirEntry
+		if entries, err = os.ReadDir(dir); err != nil {
+			return
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if filepath.Ext(name) != ".blob" {
+				continue
+			}
+
+			var fi os.FileInfo
+			if fi, err = entry.Info(); err != nil {
+				return
+			}
+			files = append(files, LiveFileStorageInfo{
+				RelativeFilename: name,
+				Directory:        dir,
+				FileNumber:       fileNumber(strings.TrimSuffix(name, ".blob")),
+				FileType:         LiveFileBlob,
+				Size:             uint64(fi.Size()),
+			})
+		}
+	}
+
+	sort.SliceStable(files, func(i, j int) bool { return files[i].FileNumber < files[j].FileNumber })
+	return
+}
+
+// newestOptionsFile returns the name and the size of the newest OPTIONS
+// file of the directory, an empty name if none.
+func newestOptionsFile(dir string) (name string, size uint64, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	var number uint64
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "OPTIONS-") || strings.HasSuffix(entry.Name(), ".dbtmp") {
+			continue
+		}
+		if n := fileNumber(strings.TrimPrefix(entry.Name(), "OPTIONS-")); name == "" || n > number {
+			name, number = entry.Name(), n
+		}
+	}
+	if name == "" {
+		return
+	}
+
+	fi, err := os.Stat(filepath.Join(dir, name))
+	if err != nil {
+		return "", 0, err
+	}
+	return name, uint64(fi.Size()), nil
+}
+
+func fileNumber(s string) uint64 {
+	n, _ := strconv.ParseUint(s, 10, 64)
+	return n
+}
@@ -0,0 +1,166 @@
+package grocksdb
+
+import (
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSortedWalFiles(t *testing.T) {
+	t.Parallel()
+
+	db := newTestDB(t, func(opts *Options) {
+		opts.SetWALTtlSeconds(3600)
+	})
+	defer db.Close()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	require.Nil(t, db.Put(wo, []byte("a"), []byte("1")))
+
+	current, err := db.GetCurrentWalFile()
+	require.Nil(t, err)
+	require.Equal(t, WalFileLive, current.Type)
+	require.NotZero(t, current.Size)
+
+	// the flushed WAL file is archived
+	fo := NewDefaultFlushOptions()
+	defer fo.Destroy()
+	fo.SetWait(true)
+	require.Nil(t, db.Flush(fo))
+	require.Nil(t, db.Put(wo, []byte("b"), []byte("2")))
+
+	files, err := db.GetSortedWalFiles()
+	require.Nil(t, err)
+	require.True(t, len(files) >= 2)
+	for i := 1; i < len(files); i++ {
+		require.Less(t, files[i-1].LogNumber, files[i].LogNumber)
+	}
+
+	archived := files[0]
+	require.Equal(t, WalFileArchived, archived.Type)
+	require.Equal(t, current.LogNumber, archived.LogNumber)
+	require.Equal(t, filepath.Join("/archive", filepath.Base(current.PathName)), archived.PathName)
+
+	current, err = db.GetCurrentWalFile()
+	require.Nil(t, err)
+	require.Equal(t, files[len(files)-1], current)
+}
+
+func TestGetSortedWalFilesLoadedOptions(t *testing.T) {
+	t.Parallel()
+
+	dir, walDir := t.TempDir(), t.TempDir()
+	opts := NewDefaultOptions()
+	defer opts.Destroy()
+	opts.SetCreateIfMissing(true)
+	opts.SetWalDir(walDir)
+	db, err := OpenDb(opts, dir)
+	require.Nil(t, err)
+	db.Close()
+
+	// the WAL directory of options loaded from OPTIONS files is read from the
+	// OPTIONS file of the database
+	env := NewDefaultEnv()
+	defer env.Destroy()
+	cache := NewLRUCache(1 << 20)
+	defer cache.Destroy()
+	lo, err := LoadLatestOptions(dir, env, true, cache)
+	require.Nil(t, err)
+	defer lo.Destroy()
+
+	db, err = OpenDb(lo.Options(), dir)
+	require.Nil(t, err)
+	defer db.Close()
+
+	current, err := db.GetCurrentWalFile()
+	require.Nil(t, err)
+	_, err = os.Stat(filepath.Join(walDir, current.PathName))
+	require.Nil(t, err)
+
+	dir = t.TempDir()
+	options := filepath.Join(dir, "OPTIONS-000005")
+	require.Nil(t, os.WriteFile(options, []byte("[DBOptions]\n  wal_dir=/wal\n"), 0o644))
+	walDir, ok, err := optionsWalDir(options)
+	require.Nil(t, err)
+	require.True(t, ok)
+	require.Equal(t, "/wal", walDir)
+
+	// unknown without OPTIONS file
+	require.Nil(t, os.Remove(options))
+	_, err = (&DB{name: dir}).walDir()
+	require.NotNil(t, err)
+}
+
+func TestGetLiveFilesStorageInfo(t *testing.T) {
+	t.Parallel()
+
+	db := newTestDB(t, nil)
+	defer db.Close()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	require.Nil(t, db.Put(wo, []byte("a"), []byte("1")))
+	fo := NewDefaultFlushOptions()
+	defer fo.Destroy()
+	fo.SetWait(true)
+	require.Nil(t, db.Flush(fo))
+	require.Nil(t, db.Put(wo, []byte("b"), []byte("2")))
+
+	require.Nil(t, db.DisableFileDeletions())
+	files, err := db.GetLiveFilesStorageInfo(&LiveFilesStorageInfoOptions{WalSizeForFlush: math.MaxUint64})
+	require.Nil(t, err)
+
+	types := make(map[LiveFileType]int)
+	for _, file := range files {
+		types[file.FileType]++
+	}
+	// the flushed WAL file may not be deleted yet
+	require.NotZero(t, types[LiveFileWAL])
+	delete(types, LiveFileWAL)
+	require.Equal(t, map[LiveFileType]int{
+		LiveFileTable:    1,
+		LiveFileOptions:  1,
+		LiveFileManifest: 1,
+		LiveFileCurrent:  1,
+	}, types)
+
+	// copy the files
+	dir := t.TempDir()
+	for _, file := range files {
+		dst := filepath.Join(dir, file.RelativeFilename)
+		if file.ReplacementContents != nil {
+			require.Nil(t, os.WriteFile(dst, file.ReplacementContents, 0o644))
+			continue
+		}
+
+		src, err := os.Open(filepath.Join(file.Directory, file.RelativeFilename))
+		require.Nil(t, err)
+		f, err := os.Create(dst)
+		require.Nil(t, err)
+		_, err = io.Copy(f, io.LimitReader(src, int64(file.Size)))
+		require.Nil(t, err)
+		require.Nil(t, f.Close())
+		require.Nil(t, src.Close())
+	}
+	require.Nil(t, db.EnableFileDeletions())
+
+	opts := NewDefaultOptions()
+	defer opts.Destroy()
+	copied, err := OpenDb(opts, dir)
+	require.Nil(t, err)
+	defer copied.Close()
+
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+	for key, value := range map[string]string{"a": "1", "b": "2"} {
+		v, err := copied.Get(ro, []byte(key))
+		require.Nil(t, err)
+		require.Equal(t, value, string(v.Data()))
+		v.Free()
+	}
+}
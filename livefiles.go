@@ -130,7 +130,7 @@ func (l *LiveFiles) SmallestKey(liveFileIndex int) []byte {
 	// returning const char* -> do not C.free
 	cValue := C.rocksdb_livefiles_smallestkey(l.c, C.int(liveFileIndex), &cValLen)
 
-	return C.GoBytes(unsafe.Pointer(&cValue), C.int(cValLen))
+	return C.GoBytes(unsafe.Pointer(cValue), C.int(cValLen))
 }
 
 // LargestKey returns the largest key in the live file.
@@ -140,7 +140,7 @@ func (l *LiveFiles) LargestKey(liveFileIndex int) []byte {
 	// returning const char* -> do not C.free
 	cValue := C.rocksdb_livefiles_largestkey(l.c, C.int(liveFileIndex), &cValLen)
 
-	return C.GoBytes(unsafe.Pointer(&cValue), C.int(cValLen))
+	return C.GoBytes(unsafe.Pointer(cValue), C.int(cValLen))
 }
 
 // SmallestSeqNo returns smallest sequence number in the live file.
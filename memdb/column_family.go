@@ -0,0 +1,155 @@
+package memdb
+
+import "sort"
+
+type entryKind uint8
+
+const (
+	kindPut entryKind = iota
+	kindDelete
+	kindSingleDelete
+	kindMerge
+	kindDeleteRange
+)
+
+// entry is a version of a key.
+type entry struct {
+	seq   uint64
+	kind  entryKind
+	value []byte
+}
+
+// record holds the versions of a key, oldest first.
+type record struct {
+	key     []byte
+	entries []entry
+}
+
+// rangeTombstone deletes the keys in [start, end) written before it.
+type rangeTombstone struct {
+	seq        uint64
+	start, end []byte
+}
+
+// ColumnFamily is a column family of a database.
+type ColumnFamily struct {
+	db      *DB
+	id      uint32
+	name    string
+	dropped bool
+
+	// records sorted by key.
+	records    []*record
+	tombstones []rangeTombstone
+}
+
+// Name returns the name of the column family.
+func (cf *ColumnFamily) Name() string {
+	return cf.name
+}
+
+// ID returns the ID of the column family, 0 for the default column family.
+func (cf *ColumnFamily) ID() uint32 {
+	return cf.id
+}
+
+// search returns the index of the first record whose key is not before key.
+func (cf *ColumnFamily) search(key []byte) int {
+	return sort.Search(len(cf.records), func(i int) bool {
+		return cf.db.compare(cf.records[i].key, key) >= 0
+	})
+}
+
+func (cf *ColumnFamily) apply(seq uint64, op batchOp) {
+	if op.kind == kindDeleteRange {
+		if cf.db.compare(op.key, op.value) < 0 {
+			cf.tombstones = append(cf.tombstones, rangeTombstone{
+				seq:   seq,
+				start: copyBytes(op.key),
+				end:   copyBytes(op.value),
+			})
+		}
+		return
+	}
+
+	i := cf.search(op.key)
+	if i == len(cf.records) || cf.db.compare(cf.records[i].key, op.key) != 0 {
+		cf.records = append(cf.records, nil)
+		copy(cf.records[i+1:], cf.records[i:])
+		cf.records[i] = &record{key: copyBytes(op.key)}
+	}
+
+	e := entry{seq: seq, kind: op.kind}
+	if op.kind == kindPut || op.kind == kindMerge {
+		e.value = copyBytes(op.value)
+	}
+	cf.records[i].entries = append(cf.records[i].entries, e)
+}
+
+// deletedAt returns the sequence number of the most recent range tombstone
+// visible at seq which deletes key, or 0.
+func (cf *ColumnFamily) deletedAt(key []byte, seq uint64) (deleted uint64) {
+	for _, t := range cf.tombstones {
+		if t.seq <= seq && t.seq > deleted &&
+			cf.db.compare(key, t.start) >= 0 && cf.db.compare(key, t.end) < 0 {
+			deleted = t.seq
+		}
+	}
+	return deleted
+}
+
+// get returns the value of key visible at seq.
+func (cf *ColumnFamily) get(key []byte, seq uint64) (value []byte, ok bool, err error) {
+	i := cf.search(key)
+	if i == len(cf.records) || cf.db.compare(cf.records[i].key, key) != 0 {
+		return nil, false, nil
+	}
+	return cf.resolve(cf.records[i], seq)
+}
+
+// resolve returns the value of the record visible at seq, merging its operands.
+func (cf *ColumnFamily) resolve(r *record, seq uint64) (value []byte, ok bool, err error) {
+	deleted := cf.deletedAt(r.key, seq)
+
+	// operands, most recent first
+	var operands [][]byte
+	var base []byte
+	hasBase := false
+
+loop:
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		e := r.entries[i]
+		if e.seq > seq {
+			continue
+		}
+		if e.seq < deleted {
+			break
+		}
+
+		switch e.kind {
+		case kindPut:
+			base, hasBase = e.value, true
+			break loop
+		case kindDelete, kindSingleDelete:
+			break loop
+		case kindMerge:
+			operands = append(operands, e.value)
+		}
+	}
+
+	if len(operands) == 0 {
+		return base, hasBase, nil
+	}
+	if cf.db.merger == nil {
+		return nil, false, ErrNoMergeOperator
+	}
+
+	for i, j := 0, len(operands)-1; i < j; i, j = i+1, j-1 {
+		operands[i], operands[j] = operands[j], operands[i]
+	}
+	merged, success := cf.db.merger.FullMerge(r.key, base, operands)
+	if !success {
+		return nil, false, ErrMergeFailed
+	}
+	return merged, true, nil
+}
@@ -0,0 +1,151 @@
+package memdb
+
+import "bytes"
+
+// Iterator iterates over the keys of a column family, in the order of the
+// comparator of the database. It reads the consistent view of the database
+// taken when it was created.
+type Iterator struct {
+	compare func(a, b []byte) int
+	keys    [][]byte
+	values  [][]byte
+	pos     int
+	err     error
+}
+
+// NewIterator returns an iterator over the default column family. Default
+// read options are used when opts is nil.
+func (db *DB) NewIterator(opts *ReadOptions) *Iterator {
+	return db.NewIteratorCF(opts, db.DefaultColumnFamily())
+}
+
+// NewIteratorCF returns an iterator over the column family.
+func (db *DB) NewIteratorCF(opts *ReadOptions, cf *ColumnFamily) *Iterator {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	iter := &Iterator{compare: db.compare, pos: -1}
+
+	seq, err := db.readSeq(opts, cf)
+	if err != nil {
+		iter.err = err
+		return iter
+	}
+
+	var lower, upper []byte
+	if opts != nil {
+		lower, upper = opts.IterateLowerBound, opts.IterateUpperBound
+	}
+
+	for _, r := range cf.records {
+		if lower != nil && db.compare(r.key, lower) < 0 {
+			continue
+		}
+		if upper != nil && db.compare(r.key, upper) >= 0 {
+			break
+		}
+
+		value, ok, err := cf.resolve(r, seq)
+		if err != nil {
+			iter.err = err
+			iter.keys, iter.values = nil, nil
+			return iter
+		}
+		if ok {
+			iter.keys = append(iter.keys, r.key)
+			iter.values = append(iter.values, value)
+		}
+	}
+	return iter
+}
+
+// Valid returns false once the iterator is positioned before the first key
+// or after the last key, or when it failed.
+func (iter *Iterator) Valid() bool {
+	return iter.err == nil && iter.pos >= 0 && iter.pos < len(iter.keys)
+}
+
+// ValidForPrefix returns false once the iterator is positioned out of the
+// keys starting with prefix.
+func (iter *Iterator) ValidForPrefix(prefix []byte) bool {
+	return iter.Valid() && bytes.HasPrefix(iter.keys[iter.pos], prefix)
+}
+
+// Key returns the key at the position of the iterator. It must not be modified.
+func (iter *Iterator) Key() []byte {
+	if !iter.Valid() {
+		return nil
+	}
+	return iter.keys[iter.pos]
+}
+
+// Value returns the value at the position of the iterator. It must not be
+// modified.
+func (iter *Iterator) Value() []byte {
+	if !iter.Valid() {
+		return nil
+	}
+	return iter.values[iter.pos]
+}
+
+// Next moves the iterator to the next key.
+func (iter *Iterator) Next() {
+	if iter.Valid() {
+		iter.pos++
+	}
+}
+
+// Prev moves the iterator to the previous key.
+func (iter *Iterator) Prev() {
+	if iter.Valid() {
+		iter.pos--
+	}
+}
+
+// SeekToFirst moves the iterator to the first key.
+func (iter *Iterator) SeekToFirst() {
+	iter.pos = 0
+}
+
+// SeekToLast moves the iterator to the last key.
+func (iter *Iterator) SeekToLast() {
+	iter.pos = len(iter.keys) - 1
+}
+
+// Seek moves the iterator to the first key which is not before key.
+func (iter *Iterator) Seek(key []byte) {
+	iter.pos = iter.search(key)
+}
+
+// SeekForPrev moves the iterator to the last key which is not after key.
+func (iter *Iterator) SeekForPrev(key []byte) {
+	iter.pos = iter.search(key)
+	if iter.pos == len(iter.keys) || iter.compare(iter.keys[iter.pos], key) > 0 {
+		iter.pos--
+	}
+}
+
+// search returns the index of the first key which is not before key.
+func (iter *Iterator) search(key []byte) int {
+	lo, hi := 0, len(iter.keys)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if iter.compare(iter.keys[mid], key) < 0 {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// Err returns the error of the iterator, such as a failed merge.
+func (iter *Iterator) Err() error {
+	return iter.err
+}
+
+// Close releases the iterator.
+func (iter *Iterator) Close() {
+	iter.keys, iter.values = nil, nil
+	iter.pos = -1
+}
@@ -0,0 +1,272 @@
+package memdb
+
+import "github.com/linxGnu/grocksdb/kv"
+
+// KV adapts a database to kv.KV, so that code written against kv.KV can be
+// unit tested in memory. Its column families are those of the database, and
+// its snapshots those of NewSnapshot.
+type KV struct {
+	db *DB
+}
+
+var _ kv.KV = (*KV)(nil)
+
+// NewKV returns the kv.KV of the database.
+func NewKV(db *DB) *KV {
+	return &KV{db: db}
+}
+
+// Get returns the data associated with the key, or nil when the key does not
+// exist.
+func (a *KV) Get(opts *kv.ReadOptions, key []byte) ([]byte, error) {
+	return a.GetCF(opts, a.db.DefaultColumnFamily(), key)
+}
+
+// GetCF returns the data associated with the key of the column family.
+func (a *KV) GetCF(opts *kv.ReadOptions, cf kv.ColumnFamily, key []byte) ([]byte, error) {
+	ro, err := readOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	memCF, err := columnFamily(cf)
+	if err != nil {
+		return nil, err
+	}
+	return a.db.GetCF(ro, memCF, key)
+}
+
+// MultiGet returns the data associated with the keys, nil for the keys which
+// do not exist.
+func (a *KV) MultiGet(opts *kv.ReadOptions, keys ...[]byte) ([][]byte, error) {
+	return a.MultiGetCF(opts, a.db.DefaultColumnFamily(), keys...)
+}
+
+// MultiGetCF returns the data associated with the keys of the column family.
+func (a *KV) MultiGetCF(opts *kv.ReadOptions, cf kv.ColumnFamily, keys ...[]byte) ([][]byte, error) {
+	ro, err := readOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	memCF, err := columnFamily(cf)
+	if err != nil {
+		return nil, err
+	}
+	return a.db.MultiGetCF(ro, memCF, keys...)
+}
+
+// Put writes data associated with a key.
+func (a *KV) Put(key, value []byte) error {
+	return a.db.Put(key, value)
+}
+
+// PutCF writes data associated with a key of the column family.
+func (a *KV) PutCF(cf kv.ColumnFamily, key, value []byte) error {
+	memCF, err := columnFamily(cf)
+	if err != nil {
+		return err
+	}
+	return a.db.PutCF(memCF, key, value)
+}
+
+// Delete removes the data associated with the key.
+func (a *KV) Delete(key []byte) error {
+	return a.db.Delete(key)
+}
+
+// DeleteCF removes the data associated with the key of the column family.
+func (a *KV) DeleteCF(cf kv.ColumnFamily, key []byte) error {
+	memCF, err := columnFamily(cf)
+	if err != nil {
+		return err
+	}
+	return a.db.DeleteCF(memCF, key)
+}
+
+// SingleDelete removes the data associated with the key, as DB.SingleDelete
+// does.
+func (a *KV) SingleDelete(key []byte) error {
+	return a.db.SingleDelete(key)
+}
+
+// SingleDeleteCF removes the data associated with the key of the column
+// family, as DB.SingleDelete does.
+func (a *KV) SingleDeleteCF(cf kv.ColumnFamily, key []byte) error {
+	memCF, err := columnFamily(cf)
+	if err != nil {
+		return err
+	}
+	return a.db.SingleDeleteCF(memCF, key)
+}
+
+// DeleteRangeCF deletes the keys of the column family in [startKey, endKey).
+func (a *KV) DeleteRangeCF(cf kv.ColumnFamily, startKey, endKey []byte) error {
+	memCF, err := columnFamily(cf)
+	if err != nil {
+		return err
+	}
+	return a.db.DeleteRangeCF(memCF, startKey, endKey)
+}
+
+// Merge merges the operand with the data associated with the key.
+func (a *KV) Merge(key, operand []byte) error {
+	return a.db.Merge(key, operand)
+}
+
+// MergeCF merges the operand with the data associated with the key of the
+// column family.
+func (a *KV) MergeCF(cf kv.ColumnFamily, key, operand []byte) error {
+	memCF, err := columnFamily(cf)
+	if err != nil {
+		return err
+	}
+	return a.db.MergeCF(memCF, key, operand)
+}
+
+// NewWriteBatch returns an empty write batch.
+func (a *KV) NewWriteBatch() kv.WriteBatch {
+	return &kvWriteBatch{db: a.db, b: a.db.NewWriteBatch()}
+}
+
+// Write applies the operations of the batch atomically.
+func (a *KV) Write(batch kv.WriteBatch) error {
+	b, ok := batch.(*kvWriteBatch)
+	if !ok || b.db != a.db {
+		return kv.ErrForeign
+	}
+	if b.err != nil {
+		return b.err
+	}
+	return a.db.Write(b.b)
+}
+
+// NewIterator returns an iterator over the default column family.
+func (a *KV) NewIterator(opts *kv.ReadOptions) kv.Iterator {
+	return a.NewIteratorCF(opts, a.db.DefaultColumnFamily())
+}
+
+// NewIteratorCF returns an iterator over the column family.
+func (a *KV) NewIteratorCF(opts *kv.ReadOptions, cf kv.ColumnFamily) kv.Iterator {
+	ro, err := readOptions(opts)
+	if err != nil {
+		return kv.NewErrorIterator(err)
+	}
+	memCF, err := columnFamily(cf)
+	if err != nil {
+		return kv.NewErrorIterator(err)
+	}
+	return a.db.NewIteratorCF(ro, memCF)
+}
+
+// NewSnapshot returns a snapshot of the database, to be released with
+// ReleaseSnapshot.
+func (a *KV) NewSnapshot() kv.Snapshot {
+	return a.db.NewSnapshot()
+}
+
+// ReleaseSnapshot releases the snapshot.
+func (a *KV) ReleaseSnapshot(snapshot kv.Snapshot) {
+	if s, ok := snapshot.(*Snapshot); ok {
+		a.db.ReleaseSnapshot(s)
+	}
+}
+
+// readOptions returns the options of the kv read options.
+func readOptions(opts *kv.ReadOptions) (*ReadOptions, error) {
+	if opts == nil {
+		return nil, nil
+	}
+
+	ro := &ReadOptions{
+		IterateLowerBound: opts.IterateLowerBound,
+		IterateUpperBound: opts.IterateUpperBound,
+	}
+	if opts.Snapshot != nil {
+		s, ok := opts.Snapshot.(*Snapshot)
+		if !ok {
+			return nil, kv.ErrForeign
+		}
+		ro.Snapshot = s
+	}
+	return ro, nil
+}
+
+// columnFamily returns the column family of the kv column family.
+func columnFamily(cf kv.ColumnFamily) (*ColumnFamily, error) {
+	memCF, ok := cf.(*ColumnFamily)
+	if !ok {
+		return nil, kv.ErrForeign
+	}
+	return memCF, nil
+}
+
+// kvWriteBatch is the write batch of KV, with the first error of its writes.
+type kvWriteBatch struct {
+	db  *DB
+	b   *WriteBatch
+	err error
+}
+
+func (b *kvWriteBatch) columnFamily(cf kv.ColumnFamily) *ColumnFamily {
+	memCF, err := columnFamily(cf)
+	if err != nil && b.err == nil {
+		b.err = err
+	}
+	return memCF
+}
+
+func (b *kvWriteBatch) Put(key, value []byte) {
+	b.b.Put(key, value)
+}
+
+func (b *kvWriteBatch) PutCF(cf kv.ColumnFamily, key, value []byte) {
+	if memCF := b.columnFamily(cf); memCF != nil {
+		b.b.PutCF(memCF, key, value)
+	}
+}
+
+func (b *kvWriteBatch) Delete(key []byte) {
+	b.b.Delete(key)
+}
+
+func (b *kvWriteBatch) DeleteCF(cf kv.ColumnFamily, key []byte) {
+	if memCF := b.columnFamily(cf); memCF != nil {
+		b.b.DeleteCF(memCF, key)
+	}
+}
+
+func (b *kvWriteBatch) SingleDelete(key []byte) {
+	b.b.SingleDelete(key)
+}
+
+func (b *kvWriteBatch) SingleDeleteCF(cf kv.ColumnFamily, key []byte) {
+	if memCF := b.columnFamily(cf); memCF != nil {
+		b.b.SingleDeleteCF(memCF, key)
+	}
+}
+
+func (b *kvWriteBatch) DeleteRangeCF(cf kv.ColumnFamily, startKey, endKey []byte) {
+	if memCF := b.columnFamily(cf); memCF != nil {
+		b.b.DeleteRangeCF(memCF, startKey, endKey)
+	}
+}
+
+func (b *kvWriteBatch) Merge(key, operand []byte) {
+	b.b.Merge(key, operand)
+}
+
+func (b *kvWriteBatch) MergeCF(cf kv.ColumnFamily, key, operand []byte) {
+	if memCF := b.columnFamily(cf); memCF != nil {
+		b.b.MergeCF(memCF, key, operand)
+	}
+}
+
+func (b *kvWriteBatch) Count() int {
+	return b.b.Count()
+}
+
+func (b *kvWriteBatch) Clear() {
+	b.b.Clear()
+	b.err = nil
+}
+
+func (b *kvWriteBatch) Destroy() {}
@@ -0,0 +1,24 @@
+package memdb
+
+import (
+	"testing"
+
+	"github.com/linxGnu/grocksdb/kv"
+	"github.com/linxGnu/grocksdb/kv/kvtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKV(t *testing.T) {
+	t.Parallel()
+
+	db := Open(&Options{MergeOperator: concatOperator{}})
+	defer db.Close()
+	kvtest.Run(t, NewKV(db), db.DefaultColumnFamily())
+
+	// column families and snapshots of other databases are foreign
+	other := Open(nil)
+	defer other.Close()
+	_, err := NewKV(db).Get(&kv.ReadOptions{Snapshot: NewKV(other).NewSnapshot()}, []byte("a"))
+	require.NotNil(t, err)
+	require.NotNil(t, NewKV(db).Write(NewKV(other).NewWriteBatch()))
+}
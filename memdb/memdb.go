@@ -0,0 +1,383 @@
+/*
+Package memdb provides an in-memory database with the semantics of the reads,
+writes, iterators, snapshots, write batches and merges of grocksdb, for unit
+tests which should not link librocksdb. It is pure Go, and does not import
+grocksdb.
+
+	db := memdb.Open(&memdb.Options{MergeOperator: mergeops.NewInt64Counter(mergeops.OverflowSaturate)})
+	err := db.Merge([]byte("visits"), mergeops.EncodeInt64(1))
+	...
+	value, err := db.Get(nil, []byte("visits"))
+
+Keys are ordered by Options.Comparator, and merge operands are combined with
+Options.MergeOperator, which the merge operators of grocksdb implement. Values
+are merged when read, as RocksDB does before compactions: a failed merge is
+reported by the read, not by the write.
+
+Writes are versioned with sequence numbers, so that snapshots and iterators
+read a consistent view of the database. Versions are kept until the database
+is closed: memdb is meant for small data sets.
+
+NewKV adapts a database to kv.KV, which grocksdb.NewGoKV adapts the databases
+of grocksdb to, so that the same code runs against both.
+*/
+package memdb
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrNoMergeOperator is returned by reads of merged keys of a database
+	// opened without merge operator.
+	ErrNoMergeOperator = fmt.Errorf("memdb: merge operator is not set")
+	// ErrMergeFailed is returned by reads of keys whose merge failed.
+	ErrMergeFailed = fmt.Errorf("memdb: could not perform merge")
+	// ErrInvalidRange is returned by range deletions whose end key comes
+	// before their start key.
+	ErrInvalidRange = fmt.Errorf("memdb: end key comes before start key")
+	// ErrColumnFamilyExists is returned when creating a column family whose
+	// name is already used.
+	ErrColumnFamilyExists = fmt.Errorf("memdb: column family already exists")
+	// ErrColumnFamilyDropped is returned by operations on a dropped column
+	// family, or on a column family of another database.
+	ErrColumnFamilyDropped = fmt.Errorf("memdb: column family is dropped or unknown")
+	// ErrClosed is returned by operations on a closed database.
+	ErrClosed = fmt.Errorf("memdb: database is closed")
+)
+
+// DefaultColumnFamilyName is the name of the default column family.
+const DefaultColumnFamilyName = "default"
+
+// MergeOperator merges operands into a value, as grocksdb.MergeOperator does.
+type MergeOperator interface {
+	Name() string
+	// FullMerge merges the operands, oldest first, into the existing value of
+	// the key, which is nil when the key does not exist.
+	FullMerge(key, existingValue []byte, operands [][]byte) ([]byte, bool)
+}
+
+// Options configures a database.
+type Options struct {
+	// Comparator orders keys. Keys are ordered bytewise when nil.
+	Comparator func(a, b []byte) int
+	// MergeOperator merges the operands of Merge. Reads of merged keys fail
+	// when nil.
+	MergeOperator MergeOperator
+}
+
+// ReadOptions configures reads.
+type ReadOptions struct {
+	// Snapshot to read from. The latest data is read when nil.
+	Snapshot *Snapshot
+	// IterateLowerBound is the inclusive lower bound of iterators.
+	IterateLowerBound []byte
+	// IterateUpperBound is the exclusive upper bound of iterators.
+	IterateUpperBound []byte
+}
+
+// DB is an in-memory database. It is safe for concurrent use.
+type DB struct {
+	mu        sync.RWMutex
+	compare   func(a, b []byte) int
+	merger    MergeOperator
+	seq       uint64
+	lastCFID  uint32
+	cfs       map[string]*ColumnFamily
+	snapshots map[*Snapshot]struct{}
+	closed    bool
+}
+
+// Open returns an empty database. Default options are used when opts is nil.
+func Open(opts *Options) *DB {
+	db := &DB{
+		compare:   bytes.Compare,
+		cfs:       make(map[string]*ColumnFamily),
+		snapshots: make(map[*Snapshot]struct{}),
+	}
+	if opts != nil {
+		if opts.Comparator != nil {
+			db.compare = opts.Comparator
+		}
+		db.merger = opts.MergeOperator
+	}
+	db.cfs[DefaultColumnFamilyName] = &ColumnFamily{db: db, name: DefaultColumnFamilyName}
+	return db
+}
+
+// Close closes the database, releasing its data.
+func (db *DB) Close() {
+	db.mu.Lock()
+	db.closed = true
+	db.cfs = nil
+	db.snapshots = nil
+	db.mu.Unlock()
+}
+
+// DefaultColumnFamily returns the default column family, used by the
+// operations which do not take a column family.
+func (db *DB) DefaultColumnFamily() *ColumnFamily {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.cfs[DefaultColumnFamilyName]
+}
+
+// CreateColumnFamily creates a column family.
+func (db *DB) CreateColumnFamily(name string) (*ColumnFamily, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed {
+		return nil, ErrClosed
+	}
+	if _, ok := db.cfs[name]; ok {
+		return nil, ErrColumnFamilyExists
+	}
+
+	db.lastCFID++
+	cf := &ColumnFamily{db: db, id: db.lastCFID, name: name}
+	db.cfs[name] = cf
+	return cf, nil
+}
+
+// DropColumnFamily drops a column family and its data. The default column
+// family can not be dropped.
+func (db *DB) DropColumnFamily(cf *ColumnFamily) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if err := db.checkColumnFamily(cf); err != nil {
+		return err
+	}
+	if cf.id == 0 {
+		return fmt.Errorf("memdb: default column family can not be dropped")
+	}
+
+	delete(db.cfs, cf.name)
+	cf.dropped = true
+	return nil
+}
+
+// ColumnFamilies returns the names of the column families.
+func (db *DB) ColumnFamilies() []string {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	names := make([]string, 0, len(db.cfs))
+	for name := range db.cfs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the data associated with the key, or nil when the key does not
+// exist. Default read options are used when opts is nil.
+func (db *DB) Get(opts *ReadOptions, key []byte) ([]byte, error) {
+	return db.GetCF(opts, db.DefaultColumnFamily(), key)
+}
+
+// GetCF returns the data associated with the key of the column family, or nil
+// when the key does not exist.
+func (db *DB) GetCF(opts *ReadOptions, cf *ColumnFamily, key []byte) ([]byte, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	seq, err := db.readSeq(opts, cf)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok, err := cf.get(key, seq)
+	if !ok || err != nil {
+		return nil, err
+	}
+	return copyBytes(value), nil
+}
+
+// MultiGet returns the data associated with the keys, nil for the keys which
+// do not exist.
+func (db *DB) MultiGet(opts *ReadOptions, keys ...[]byte) ([][]byte, error) {
+	return db.MultiGetCF(opts, db.DefaultColumnFamily(), keys...)
+}
+
+// MultiGetCF returns the data associated with the keys of the column family,
+// nil for the keys which do not exist.
+func (db *DB) MultiGetCF(opts *ReadOptions, cf *ColumnFamily, keys ...[]byte) ([][]byte, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	seq, err := db.readSeq(opts, cf)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		value, ok, err := cf.get(key, seq)
+		if err != nil {
+			return nil, fmt.Errorf("getting %q failed: %w", key, err)
+		}
+		if ok {
+			values[i] = copyBytes(value)
+		}
+	}
+	return values, nil
+}
+
+// Put writes data associated with a key.
+func (db *DB) Put(key, value []byte) error {
+	return db.PutCF(db.DefaultColumnFamily(), key, value)
+}
+
+// PutCF writes data associated with a key of the column family.
+func (db *DB) PutCF(cf *ColumnFamily, key, value []byte) error {
+	return db.write(batchOp{cf: cf, kind: kindPut, key: key, value: value})
+}
+
+// Delete removes the data associated with the key.
+func (db *DB) Delete(key []byte) error {
+	return db.DeleteCF(db.DefaultColumnFamily(), key)
+}
+
+// DeleteCF removes the data associated with the key of the column family.
+func (db *DB) DeleteCF(cf *ColumnFamily, key []byte) error {
+	return db.write(batchOp{cf: cf, kind: kindDelete, key: key})
+}
+
+// SingleDelete removes the data associated with the key, which must have been
+// written once only since it was last deleted, and not merged. As with
+// RocksDB, the result is undefined otherwise: memdb deletes the key.
+func (db *DB) SingleDelete(key []byte) error {
+	return db.SingleDeleteCF(db.DefaultColumnFamily(), key)
+}
+
+// SingleDeleteCF removes the data associated with the key of the column
+// family, as SingleDelete does.
+func (db *DB) SingleDeleteCF(cf *ColumnFamily, key []byte) error {
+	return db.write(batchOp{cf: cf, kind: kindSingleDelete, key: key})
+}
+
+// DeleteRange deletes the keys in [startKey, endKey).
+func (db *DB) DeleteRange(startKey, endKey []byte) error {
+	return db.DeleteRangeCF(db.DefaultColumnFamily(), startKey, endKey)
+}
+
+// DeleteRangeCF deletes the keys of the column family in [startKey, endKey).
+func (db *DB) DeleteRangeCF(cf *ColumnFamily, startKey, endKey []byte) error {
+	return db.write(batchOp{cf: cf, kind: kindDeleteRange, key: startKey, value: endKey})
+}
+
+// Merge merges the operand with the data associated with the key.
+func (db *DB) Merge(key, operand []byte) error {
+	return db.MergeCF(db.DefaultColumnFamily(), key, operand)
+}
+
+// MergeCF merges the operand with the data associated with the key of the
+// column family.
+func (db *DB) MergeCF(cf *ColumnFamily, key, operand []byte) error {
+	return db.write(batchOp{cf: cf, kind: kindMerge, key: key, value: operand})
+}
+
+// Write applies the operations of the batch atomically.
+func (db *DB) Write(batch *WriteBatch) error {
+	return db.write(batch.ops...)
+}
+
+func (db *DB) write(ops ...batchOp) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, op := range ops {
+		if err := db.checkColumnFamily(op.cf); err != nil {
+			return err
+		}
+		if op.kind == kindDeleteRange && db.compare(op.key, op.value) > 0 {
+			return ErrInvalidRange
+		}
+	}
+
+	for _, op := range ops {
+		db.seq++
+		op.cf.apply(db.seq, op)
+	}
+	return nil
+}
+
+// NewSnapshot returns a snapshot of the database, to be released with
+// ReleaseSnapshot.
+func (db *DB) NewSnapshot() *Snapshot {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	s := &Snapshot{db: db, seq: db.seq}
+	if !db.closed {
+		db.snapshots[s] = struct{}{}
+	}
+	return s
+}
+
+// ReleaseSnapshot releases the snapshot.
+func (db *DB) ReleaseSnapshot(snapshot *Snapshot) {
+	db.mu.Lock()
+	delete(db.snapshots, snapshot)
+	snapshot.released = true
+	db.mu.Unlock()
+}
+
+// GetLatestSequenceNumber returns the sequence number of the most recent write.
+func (db *DB) GetLatestSequenceNumber() uint64 {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.seq
+}
+
+// NumSnapshots returns the number of snapshots which are not released.
+func (db *DB) NumSnapshots() int {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return len(db.snapshots)
+}
+
+// readSeq returns the sequence number reads with opts see.
+func (db *DB) readSeq(opts *ReadOptions, cf *ColumnFamily) (uint64, error) {
+	if err := db.checkColumnFamily(cf); err != nil {
+		return 0, err
+	}
+	if opts == nil || opts.Snapshot == nil {
+		return db.seq, nil
+	}
+	if opts.Snapshot.db != db || opts.Snapshot.released {
+		return 0, fmt.Errorf("memdb: snapshot is released or of another database")
+	}
+	return opts.Snapshot.seq, nil
+}
+
+func (db *DB) checkColumnFamily(cf *ColumnFamily) error {
+	if db.closed {
+		return ErrClosed
+	}
+	if cf == nil || cf.db != db || cf.dropped {
+		return ErrColumnFamilyDropped
+	}
+	return nil
+}
+
+// Snapshot is a consistent view of a database.
+type Snapshot struct {
+	db       *DB
+	seq      uint64
+	released bool
+}
+
+// SequenceNumber returns the sequence number of the view of the snapshot.
+func (s *Snapshot) SequenceNumber() uint64 {
+	return s.seq
+}
+
+func copyBytes(b []byte) []byte {
+	return append(make([]byte, 0, len(b)), b...)
+}
@@ -0,0 +1,204 @@
+package memdb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type concatOperator struct{}
+
+func (concatOperator) Name() string { return "memdb.concat" }
+
+func (concatOperator) FullMerge(_, existingValue []byte, operands [][]byte) ([]byte, bool) {
+	merged := append([]byte{}, existingValue...)
+	for _, operand := range operands {
+		if string(operand) == "fail" {
+			return nil, false
+		}
+		merged = append(merged, operand...)
+	}
+	return merged, true
+}
+
+func collect(t *testing.T, iter *Iterator) (kvs []string) {
+	for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+		kvs = append(kvs, string(iter.Key())+"="+string(iter.Value()))
+	}
+	require.Nil(t, iter.Err())
+	iter.Close()
+	return kvs
+}
+
+func TestDBReadWrite(t *testing.T) {
+	t.Parallel()
+
+	db := Open(&Options{MergeOperator: concatOperator{}})
+	defer db.Close()
+
+	require.Nil(t, db.Put([]byte("a"), []byte("1")))
+	require.Nil(t, db.Put([]byte("empty"), nil))
+	require.Nil(t, db.Merge([]byte("a"), []byte("2")))
+	require.Nil(t, db.Merge([]byte("b"), []byte("3")))
+	require.Nil(t, db.Put([]byte("c"), []byte("4")))
+	require.Nil(t, db.Delete([]byte("c")))
+	require.Nil(t, db.Put([]byte("d"), []byte("5")))
+	require.Nil(t, db.SingleDelete([]byte("d")))
+
+	v, err := db.Get(nil, []byte("a"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("12"), v)
+
+	v, err = db.Get(nil, []byte("empty"))
+	require.Nil(t, err)
+	require.NotNil(t, v)
+	require.Empty(t, v)
+
+	values, err := db.MultiGet(nil, []byte("b"), []byte("c"), []byte("d"), []byte("x"))
+	require.Nil(t, err)
+	require.Equal(t, [][]byte{[]byte("3"), nil, nil, nil}, values)
+
+	// merges fail when read
+	require.Nil(t, db.Merge([]byte("a"), []byte("fail")))
+	_, err = db.Get(nil, []byte("a"))
+	require.Equal(t, ErrMergeFailed, err)
+	iter := db.NewIterator(nil)
+	iter.SeekToFirst()
+	require.False(t, iter.Valid())
+	require.Equal(t, ErrMergeFailed, iter.Err())
+
+	// and need a merge operator
+	noMerge := Open(nil)
+	require.Nil(t, noMerge.Merge([]byte("a"), []byte("1")))
+	_, err = noMerge.Get(nil, []byte("a"))
+	require.Equal(t, ErrNoMergeOperator, err)
+}
+
+func TestDBDeleteRange(t *testing.T) {
+	t.Parallel()
+
+	db := Open(&Options{MergeOperator: concatOperator{}})
+	defer db.Close()
+
+	for _, k := range []string{"a", "b", "c", "d"} {
+		require.Nil(t, db.Put([]byte(k), []byte(k)))
+	}
+	require.Nil(t, db.DeleteRange([]byte("b"), []byte("d")))
+	require.Equal(t, []string{"a=a", "d=d"}, collect(t, db.NewIterator(nil)))
+
+	// keys written after the deletion are not deleted, merges start over
+	require.Nil(t, db.Merge([]byte("b"), []byte("1")))
+	require.Nil(t, db.Put([]byte("c"), []byte("2")))
+	require.Equal(t, []string{"a=a", "b=1", "c=2", "d=d"}, collect(t, db.NewIterator(nil)))
+
+	require.Nil(t, db.DeleteRange([]byte("c"), []byte("c")))
+	require.Equal(t, ErrInvalidRange, db.DeleteRange([]byte("c"), []byte("a")))
+	require.Len(t, collect(t, db.NewIterator(nil)), 4)
+}
+
+func TestDBSnapshotAndBatch(t *testing.T) {
+	t.Parallel()
+
+	db := Open(nil)
+	defer db.Close()
+
+	require.Nil(t, db.Put([]byte("a"), []byte("1")))
+	snapshot := db.NewSnapshot()
+	require.EqualValues(t, 1, snapshot.SequenceNumber())
+	require.Equal(t, 1, db.NumSnapshots())
+
+	b := db.NewWriteBatch()
+	b.Put([]byte("a"), []byte("2"))
+	b.Put([]byte("b"), []byte("3"))
+	b.Delete([]byte("b"))
+	b.Put([]byte("c"), []byte("4"))
+	require.Equal(t, 4, b.Count())
+	require.Nil(t, db.Write(b))
+	require.EqualValues(t, 5, db.GetLatestSequenceNumber())
+
+	opts := &ReadOptions{Snapshot: snapshot}
+	v, err := db.Get(opts, []byte("a"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("1"), v)
+	require.Equal(t, []string{"a=1"}, collect(t, db.NewIterator(opts)))
+	require.Equal(t, []string{"a=2", "c=4"}, collect(t, db.NewIterator(nil)))
+
+	db.ReleaseSnapshot(snapshot)
+	require.Zero(t, db.NumSnapshots())
+	_, err = db.Get(opts, []byte("a"))
+	require.Error(t, err)
+
+	// batches are atomic
+	other, err := db.CreateColumnFamily("other")
+	require.Nil(t, err)
+	require.Nil(t, db.DropColumnFamily(other))
+	b.Clear()
+	b.Put([]byte("d"), []byte("5"))
+	b.PutCF(other, []byte("e"), []byte("6"))
+	require.Equal(t, ErrColumnFamilyDropped, db.Write(b))
+	v, err = db.Get(nil, []byte("d"))
+	require.Nil(t, err)
+	require.Nil(t, v)
+}
+
+func TestDBColumnFamilies(t *testing.T) {
+	t.Parallel()
+
+	db := Open(nil)
+
+	cf, err := db.CreateColumnFamily("guide")
+	require.Nil(t, err)
+	require.EqualValues(t, 1, cf.ID())
+	_, err = db.CreateColumnFamily("guide")
+	require.Equal(t, ErrColumnFamilyExists, err)
+	require.Equal(t, []string{"default", "guide"}, db.ColumnFamilies())
+	require.Error(t, db.DropColumnFamily(db.DefaultColumnFamily()))
+
+	require.Nil(t, db.PutCF(cf, []byte("a"), []byte("1")))
+	v, err := db.Get(nil, []byte("a"))
+	require.Nil(t, err)
+	require.Nil(t, v)
+	v, err = db.GetCF(nil, cf, []byte("a"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("1"), v)
+
+	db.Close()
+	require.Equal(t, ErrClosed, db.PutCF(cf, []byte("a"), []byte("2")))
+}
+
+func TestIterator(t *testing.T) {
+	t.Parallel()
+
+	// keys in reverse order
+	db := Open(&Options{Comparator: func(a, b []byte) int { return bytes.Compare(b, a) }})
+	defer db.Close()
+
+	for _, k := range []string{"a", "b", "c", "d", "e"} {
+		require.Nil(t, db.Put([]byte(k), []byte(k)))
+	}
+
+	iter := db.NewIterator(&ReadOptions{IterateLowerBound: []byte("d"), IterateUpperBound: []byte("a")})
+	defer iter.Close()
+
+	iter.SeekToFirst()
+	require.Equal(t, []byte("d"), iter.Key())
+	iter.SeekToLast()
+	require.Equal(t, []byte("b"), iter.Key())
+	iter.Prev()
+	require.Equal(t, []byte("c"), iter.Value())
+
+	iter.Seek([]byte("cc"))
+	require.Equal(t, []byte("c"), iter.Key())
+	iter.SeekForPrev([]byte("cc"))
+	require.Equal(t, []byte("d"), iter.Key())
+	iter.SeekForPrev([]byte("c"))
+	require.Equal(t, []byte("c"), iter.Key())
+	require.True(t, iter.ValidForPrefix([]byte("c")))
+
+	iter.Seek([]byte("a"))
+	require.False(t, iter.Valid())
+	iter.SeekForPrev([]byte("z"))
+	require.False(t, iter.Valid())
+	require.Nil(t, iter.Key())
+}
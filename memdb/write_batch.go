@@ -0,0 +1,84 @@
+package memdb
+
+// batchOp is an operation of a write batch.
+type batchOp struct {
+	cf   *ColumnFamily
+	kind entryKind
+	key  []byte
+	// value, merge operand or end key of range deletions.
+	value []byte
+}
+
+// WriteBatch is a batch of writes applied atomically by DB.Write. Keys and
+// values are copied when the batch is written, not when they are added.
+type WriteBatch struct {
+	db  *DB
+	ops []batchOp
+}
+
+// NewWriteBatch returns an empty batch of writes to the database.
+func (db *DB) NewWriteBatch() *WriteBatch {
+	return &WriteBatch{db: db}
+}
+
+// Put writes data associated with a key.
+func (b *WriteBatch) Put(key, value []byte) {
+	b.PutCF(b.db.DefaultColumnFamily(), key, value)
+}
+
+// PutCF writes data associated with a key of the column family.
+func (b *WriteBatch) PutCF(cf *ColumnFamily, key, value []byte) {
+	b.ops = append(b.ops, batchOp{cf: cf, kind: kindPut, key: key, value: value})
+}
+
+// Delete removes the data associated with the key.
+func (b *WriteBatch) Delete(key []byte) {
+	b.DeleteCF(b.db.DefaultColumnFamily(), key)
+}
+
+// DeleteCF removes the data associated with the key of the column family.
+func (b *WriteBatch) DeleteCF(cf *ColumnFamily, key []byte) {
+	b.ops = append(b.ops, batchOp{cf: cf, kind: kindDelete, key: key})
+}
+
+// SingleDelete removes the data associated with the key, as DB.SingleDelete does.
+func (b *WriteBatch) SingleDelete(key []byte) {
+	b.SingleDeleteCF(b.db.DefaultColumnFamily(), key)
+}
+
+// SingleDeleteCF removes the data associated with the key of the column
+// family, as DB.SingleDelete does.
+func (b *WriteBatch) SingleDeleteCF(cf *ColumnFamily, key []byte) {
+	b.ops = append(b.ops, batchOp{cf: cf, kind: kindSingleDelete, key: key})
+}
+
+// DeleteRange deletes the keys in [startKey, endKey).
+func (b *WriteBatch) DeleteRange(startKey, endKey []byte) {
+	b.DeleteRangeCF(b.db.DefaultColumnFamily(), startKey, endKey)
+}
+
+// DeleteRangeCF deletes the keys of the column family in [startKey, endKey).
+func (b *WriteBatch) DeleteRangeCF(cf *ColumnFamily, startKey, endKey []byte) {
+	b.ops = append(b.ops, batchOp{cf: cf, kind: kindDeleteRange, key: startKey, value: endKey})
+}
+
+// Merge merges the operand with the data associated with the key.
+func (b *WriteBatch) Merge(key, operand []byte) {
+	b.MergeCF(b.db.DefaultColumnFamily(), key, operand)
+}
+
+// MergeCF merges the operand with the data associated with the key of the
+// column family.
+func (b *WriteBatch) MergeCF(cf *ColumnFamily, key, operand []byte) {
+	b.ops = append(b.ops, batchOp{cf: cf, kind: kindMerge, key: key, value: operand})
+}
+
+// Count returns the number of operations in the batch.
+func (b *WriteBatch) Count() int {
+	return len(b.ops)
+}
+
+// Clear removes all the operations of the batch.
+func (b *WriteBatch) Clear() {
+	b.ops = b.ops[:0]
+}
@@ -0,0 +1,144 @@
+package grocksdb
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/linxGnu/grocksdb/memdb"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMemDBEquivalence checks that memdb reads the same data as RocksDB, after
+// the same random writes.
+func TestMemDBEquivalence(t *testing.T) {
+	t.Parallel()
+
+	merger := &mockMergeOperator{
+		fullMerge: func(_, existingValue []byte, operands [][]byte) ([]byte, bool) {
+			merged := append([]byte{}, existingValue...)
+			for _, operand := range operands {
+				merged = append(merged, operand...)
+			}
+			return merged, true
+		},
+	}
+	db := newTestDB(t, func(opts *Options) {
+		opts.SetMergeOperator(merger)
+	})
+	defer db.Close()
+	mem := memdb.Open(&memdb.Options{MergeOperator: merger})
+	defer mem.Close()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	fo := NewDefaultFlushOptions()
+	defer fo.Destroy()
+
+	requireSameData := func(ro *ReadOptions, memRO *memdb.ReadOptions) {
+		var expected []string
+		iter := mem.NewIterator(memRO)
+		for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+			expected = append(expected, string(iter.Key())+"="+string(iter.Value()))
+		}
+		require.Nil(t, iter.Err())
+		iter.Close()
+
+		var actual []string
+		it := db.NewIterator(ro)
+		for it.SeekToFirst(); it.Valid(); it.Next() {
+			actual = append(actual, string(it.Key().Data())+"="+string(it.Value().Data()))
+		}
+		require.Nil(t, it.Err())
+		it.Close()
+
+		require.Equal(t, expected, actual)
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	key := func() []byte { return []byte(fmt.Sprintf("k%02d", rnd.Intn(50))) }
+	// keys put once since they were last deleted, which can be single deleted
+	putOnce := make(map[string]bool)
+
+	var snapshot *Snapshot
+	var memSnapshot *memdb.Snapshot
+	for i := 0; i < 2000; i++ {
+		batch := NewWriteBatch()
+		memBatch := mem.NewWriteBatch()
+
+		for n := rnd.Intn(3) + 1; n > 0; n-- {
+			k := key()
+			switch op := rnd.Intn(10); {
+			case op < 4:
+				v := []byte(fmt.Sprint(i))
+				batch.Put(k, v)
+				memBatch.Put(k, v)
+				_, written := putOnce[string(k)]
+				putOnce[string(k)] = !written
+			case op < 6:
+				v := []byte(fmt.Sprintf("+%d", i))
+				batch.Merge(k, v)
+				memBatch.Merge(k, v)
+				putOnce[string(k)] = false
+			case op < 7:
+				batch.Delete(k)
+				memBatch.Delete(k)
+				delete(putOnce, string(k))
+			case op < 8:
+				if putOnce[string(k)] {
+					batch.SingleDelete(k)
+					memBatch.SingleDelete(k)
+					delete(putOnce, string(k))
+				}
+			default:
+				end := key()
+				if string(end) < string(k) {
+					k, end = end, k
+				}
+				batch.DeleteRange(k, end)
+				memBatch.DeleteRange(k, end)
+				for j := range putOnce {
+					if j >= string(k) && j < string(end) {
+						delete(putOnce, j)
+					}
+				}
+			}
+		}
+
+		require.Nil(t, db.Write(wo, batch))
+		require.Nil(t, mem.Write(memBatch))
+		batch.Destroy()
+
+		if i%100 == 0 {
+			if snapshot != nil {
+				ro := NewDefaultReadOptions()
+				ro.SetSnapshot(snapshot)
+				requireSameData(ro, &memdb.ReadOptions{Snapshot: memSnapshot})
+				ro.Destroy()
+
+				db.ReleaseSnapshot(snapshot)
+				mem.ReleaseSnapshot(memSnapshot)
+			}
+			snapshot, memSnapshot = db.NewSnapshot(), mem.NewSnapshot()
+		}
+		if i%500 == 0 {
+			require.Nil(t, db.Flush(fo))
+		}
+	}
+	db.ReleaseSnapshot(snapshot)
+	mem.ReleaseSnapshot(memSnapshot)
+
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+	requireSameData(ro, nil)
+
+	for i := 0; i < 50; i++ {
+		k := []byte(fmt.Sprintf("k%02d", i))
+		v, err := db.GetBytes(ro, k)
+		require.Nil(t, err)
+		memV, err := mem.Get(nil, k)
+		require.Nil(t, err)
+		require.Equal(t, memV == nil, v == nil, string(k))
+		require.Equal(t, string(memV), string(v), string(k))
+	}
+}
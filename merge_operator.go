@@ -85,16 +85,26 @@ func NewNativeMergeOperator(c unsafe.Pointer) MergeOperator {
 
 type nativeMergeOperator struct {
 	c *C.rocksdb_mergeoperator_t
+
+	name string
+	// Go equivalent of the native merge operator, if known.
+	merger builtinMerger
 }
 
 func (mo *nativeMergeOperator) FullMerge(key, existingValue []byte, operands [][]byte) ([]byte, bool) {
-	return nil, false
+	if mo.merger == nil {
+		return nil, false
+	}
+	return mo.merger.fold(existingValue, existingValue != nil, operands)
 }
 
 func (mo *nativeMergeOperator) PartialMerge(key, leftOperand, rightOperand []byte) ([]byte, bool) {
-	return nil, false
+	if mo.merger == nil {
+		return nil, false
+	}
+	return mo.merger.fold(leftOperand, true, [][]byte{rightOperand})
 }
-func (mo *nativeMergeOperator) Name() string { return "" }
+func (mo *nativeMergeOperator) Name() string { return mo.name }
 func (mo *nativeMergeOperator) Destroy() {
 	C.rocksdb_mergeoperator_destroy(mo.c)
 	mo.c = nil
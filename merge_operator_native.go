@@ -0,0 +1,174 @@
+package grocksdb
+
+// #include <stdlib.h>
+// #include "rocksdb/c.h"
+// #include "grocksdb.h"
+import "C"
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// NewUint64AddMergeOperator creates the RocksDB built-in merge operator
+// adding uint64 values, encoded as fixed 8 bytes little-endian as done
+// by EncodeUint64. Values of another size are treated as 0.
+//
+// Merges are done in native code without calling back into Go.
+func NewUint64AddMergeOperator() MergeOperator {
+	return &nativeMergeOperator{
+		c:      C.gorocksdb_mergeoperator_uint64add_create(),
+		name:   "UInt64AddOperator",
+		merger: builtinMergeFunc(uint64AddMerge),
+	}
+}
+
+// NewStringAppendMergeOperator creates the RocksDB built-in merge operator
+// appending operands to the existing value, separated by delimiter.
+//
+// Merges are done in native code without calling back into Go.
+func NewStringAppendMergeOperator(delimiter string) MergeOperator {
+	cDelim := C.CString(delimiter)
+	mo := &nativeMergeOperator{
+		c:    C.gorocksdb_mergeoperator_stringappend_create(cDelim, C.size_t(len(delimiter))),
+		name: "StringAppendOperator",
+		merger: builtinMergeFunc(func(acc []byte, hasAcc bool, operand []byte) ([]byte, bool) {
+			if hasAcc {
+				acc = append(acc, delimiter...)
+			}
+			return append(acc, operand...), true
+		}),
+	}
+	C.free(unsafe.Pointer(cDelim))
+	return mo
+}
+
+// NewMaxMergeOperator creates the RocksDB built-in merge operator keeping
+// the largest of the existing value and operands, compared byte-wise.
+//
+// Merges are done in native code without calling back into Go.
+func NewMaxMergeOperator() MergeOperator {
+	return &nativeMergeOperator{
+		c:      C.gorocksdb_mergeoperator_max_create(),
+		name:   "MaxOperator",
+		merger: builtinMergeFunc(maxMerge),
+	}
+}
+
+// NewSortListMergeOperator creates the RocksDB built-in merge operator
+// merging lists of comma separated integers, such as "1,5,9", into a
+// single sorted list.
+//
+// Merges are done in native code without calling back into Go.
+func NewSortListMergeOperator() MergeOperator {
+	return &nativeMergeOperator{
+		c:      C.gorocksdb_mergeoperator_sortlist_create(),
+		name:   "MergeSortOperator",
+		merger: builtinMergeFunc(sortListMerge),
+	}
+}
+
+// NewBytesXORMergeOperator creates the RocksDB built-in merge operator
+// XOR-ing operands with the existing value. The result is as long as the
+// longest of its inputs, the shortest one being padded with zeros.
+//
+// Merges are done in native code without calling back into Go.
+func NewBytesXORMergeOperator() MergeOperator {
+	return &nativeMergeOperator{
+		c:      C.gorocksdb_mergeoperator_bytesxor_create(),
+		name:   "BytesXOR",
+		merger: builtinMergeFunc(bytesXORMerge),
+	}
+}
+
+// EncodeUint64 encodes value as expected by NewUint64AddMergeOperator.
+func EncodeUint64(value uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, value)
+	return b
+}
+
+// DecodeUint64 decodes value encoded by EncodeUint64. Like the uint64 add
+// merge operator, it treats values of another size than 8 bytes as 0.
+func DecodeUint64(value []byte) uint64 {
+	if len(value) != 8 {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(value)
+}
+
+// builtinMerger is the Go equivalent of a native merge operator.
+type builtinMerger interface {
+	fold(acc []byte, hasAcc bool, operands [][]byte) ([]byte, bool)
+}
+
+// builtinMergeFunc folds one operand into the accumulated value of an
+// associative merge operator.
+type builtinMergeFunc func(acc []byte, hasAcc bool, operand []byte) ([]byte, bool)
+
+func (fn builtinMergeFunc) fold(acc []byte, hasAcc bool, operands [][]byte) ([]byte, bool) {
+	acc = append([]byte{}, acc...)
+	for _, operand := range operands {
+		var ok bool
+		if acc, ok = fn(acc, hasAcc, operand); !ok {
+			return nil, false
+		}
+		hasAcc = true
+	}
+	return acc, true
+}
+
+func uint64AddMerge(acc []byte, hasAcc bool, operand []byte) ([]byte, bool) {
+	sum := DecodeUint64(operand)
+	if hasAcc {
+		sum += DecodeUint64(acc)
+	}
+	return EncodeUint64(sum), true
+}
+
+func maxMerge(acc []byte, hasAcc bool, operand []byte) ([]byte, bool) {
+	if hasAcc && bytes.Compare(acc, operand) >= 0 {
+		return acc, true
+	}
+	return append(acc[:0], operand...), true
+}
+
+func bytesXORMerge(acc []byte, hasAcc bool, operand []byte) ([]byte, bool) {
+	for len(acc) < len(operand) {
+		acc = append(acc, 0)
+	}
+	for i := range operand {
+		acc[i] ^= operand[i]
+	}
+	return acc, true
+}
+
+func sortListMerge(acc []byte, hasAcc bool, operand []byte) ([]byte, bool) {
+	var list []int64
+	for _, v := range [][]byte{acc, operand} {
+		if len(v) == 0 {
+			continue
+		}
+		for _, s := range strings.Split(strings.TrimSuffix(string(v), ","), ",") {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil || strings.HasPrefix(s, "+") {
+				return nil, false
+			}
+			list = append(list, n)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+
+	acc = acc[:0]
+	for i, n := range list {
+		if i > 0 {
+			acc = append(acc, ',')
+		}
+		acc = strconv.AppendInt(acc, n, 10)
+	}
+	return acc, true
+}
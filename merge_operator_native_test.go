@@ -0,0 +1,169 @@
+package grocksdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNativeMergeOperators(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		newMergeOperator func() MergeOperator
+		name             string
+		existing         []byte
+		operands         [][]byte
+		expected         []byte
+	}{
+		{
+			newMergeOperator: NewUint64AddMergeOperator,
+			name:             "UInt64AddOperator",
+			existing:         EncodeUint64(40),
+			operands:         [][]byte{EncodeUint64(1), []byte("invalid"), EncodeUint64(1)},
+			expected:         EncodeUint64(42),
+		},
+		{
+			newMergeOperator: func() MergeOperator { return NewStringAppendMergeOperator("::") },
+			name:             "StringAppendOperator",
+			existing:         []byte("a"),
+			operands:         [][]byte{[]byte("b"), []byte("c")},
+			expected:         []byte("a::b::c"),
+		},
+		{
+			newMergeOperator: NewMaxMergeOperator,
+			name:             "MaxOperator",
+			existing:         []byte("b"),
+			operands:         [][]byte{[]byte("a"), []byte("bb"), []byte("ba")},
+			expected:         []byte("bb"),
+		},
+		{
+			newMergeOperator: NewSortListMergeOperator,
+			name:             "MergeSortOperator",
+			existing:         []byte("5,10"),
+			operands:         [][]byte{[]byte("1,7"), []byte("-3,12")},
+			expected:         []byte("-3,1,5,7,10,12"),
+		},
+		{
+			newMergeOperator: NewBytesXORMergeOperator,
+			name:             "BytesXOR",
+			existing:         []byte{0x0f, 0xf0},
+			operands:         [][]byte{{0xff}, {0x00, 0x0f, 0x01}},
+			expected:         []byte{0xf0, 0xff, 0x01},
+		},
+	} {
+		// go equivalent
+		mo := tc.newMergeOperator()
+		require.Equal(t, tc.name, mo.Name())
+		merged, ok := mo.FullMerge(nil, tc.existing, tc.operands)
+		require.True(t, ok)
+		require.EqualValues(t, tc.expected, merged, tc.name)
+
+		db := newTestDB(t, func(opts *Options) {
+			opts.SetMergeOperator(tc.newMergeOperator())
+		})
+
+		var (
+			wo       = NewDefaultWriteOptions()
+			ro       = NewDefaultReadOptions()
+			givenKey = []byte("key")
+		)
+		require.Nil(t, db.Put(wo, givenKey, tc.existing))
+		for _, operand := range tc.operands {
+			require.Nil(t, db.Merge(wo, givenKey, operand))
+		}
+
+		v, err := db.GetBytes(ro, givenKey)
+		require.Nil(t, err)
+		require.EqualValues(t, tc.expected, v, tc.name)
+
+		// trigger a compaction to ensure that a merge is performed
+		db.CompactRange(Range{nil, nil})
+
+		v, err = db.GetBytes(ro, givenKey)
+		require.Nil(t, err)
+		require.EqualValues(t, tc.expected, v, tc.name)
+
+		db.Close()
+	}
+}
+
+func TestAddUint64(t *testing.T) {
+	t.Parallel()
+
+	db, cfh, cleanup := newTestDBMultiCF(t, []string{"default", "counters"}, func(opts *Options) {
+		opts.SetMergeOperator(NewUint64AddMergeOperator())
+	})
+	defer cleanup()
+
+	var (
+		wo       = NewDefaultWriteOptions()
+		ro       = NewDefaultReadOptions()
+		givenKey = []byte("hits")
+	)
+
+	v, err := db.GetUint64(ro, givenKey)
+	require.Nil(t, err)
+	require.EqualValues(t, 0, v)
+
+	for i := 0; i < 100; i++ {
+		require.Nil(t, db.AddUint64(wo, givenKey, 2))
+		require.Nil(t, db.AddUint64CF(wo, cfh[1], givenKey, 3))
+	}
+
+	v, err = db.GetUint64(ro, givenKey)
+	require.Nil(t, err)
+	require.EqualValues(t, 200, v)
+
+	v, err = db.GetUint64CF(ro, cfh[1], givenKey)
+	require.Nil(t, err)
+	require.EqualValues(t, 300, v)
+
+	require.EqualValues(t, 0, DecodeUint64([]byte("bad")))
+	require.EqualValues(t, 7, DecodeUint64(EncodeUint64(7)))
+}
+
+func TestSortListMergeOperatorOverflow(t *testing.T) {
+	t.Parallel()
+
+	operands := [][]byte{[]byte("1,9223372036854775808"), []byte("-9223372036854775809")}
+	for _, operand := range operands {
+		// go equivalent
+		_, ok := NewSortListMergeOperator().FullMerge(nil, []byte("1"), [][]byte{operand})
+		require.False(t, ok, string(operand))
+
+		db := newTestDB(t, func(opts *Options) {
+			opts.SetMergeOperator(NewSortListMergeOperator())
+		})
+
+		wo := NewDefaultWriteOptions()
+		ro := NewDefaultReadOptions()
+		require.Nil(t, db.Put(wo, []byte("key"), []byte("1")))
+		require.Nil(t, db.Merge(wo, []byte("key"), operand))
+		_, err := db.GetBytes(ro, []byte("key"))
+		require.NotNil(t, err, string(operand))
+
+		wo.Destroy()
+		ro.Destroy()
+		db.Close()
+	}
+
+	// the bounds of int64 are merged by both
+	merged, ok := NewSortListMergeOperator().FullMerge(nil, []byte("9223372036854775807"), [][]byte{[]byte("-9223372036854775808,0007")})
+	require.True(t, ok)
+	require.Equal(t, "-9223372036854775808,7,9223372036854775807", string(merged))
+
+	db := newTestDB(t, func(opts *Options) {
+		opts.SetMergeOperator(NewSortListMergeOperator())
+	})
+	defer db.Close()
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+	require.Nil(t, db.Put(wo, []byte("key"), []byte("9223372036854775807")))
+	require.Nil(t, db.Merge(wo, []byte("key"), []byte("-9223372036854775808,0007")))
+	v, err := db.GetBytes(ro, []byte("key"))
+	require.Nil(t, err)
+	require.Equal(t, string(merged), string(v))
+}
@@ -3,6 +3,7 @@ package grocksdb
 import (
 	"testing"
 
+	"github.com/linxGnu/grocksdb/mergeops"
 	"github.com/stretchr/testify/require"
 )
 
@@ -152,6 +153,38 @@ func TestMergeMultiOperator(t *testing.T) {
 	v1.Free()
 }
 
+func TestMergeOpsOperator(t *testing.T) {
+	t.Parallel()
+
+	var op interface {
+		MergeOperator
+		PartialMerger
+		MultiMerger
+	} = mergeops.NewSortedSet()
+
+	db := newTestDB(t, func(opts *Options) {
+		opts.SetMergeOperator(op)
+	})
+	defer db.Close()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+
+	givenKey := []byte("tags")
+	require.Nil(t, db.Merge(wo, givenKey, mergeops.SetAdd([]byte("b"), []byte("a"))))
+	require.Nil(t, db.Merge(wo, givenKey, mergeops.SetRemove([]byte("b"))))
+	require.Nil(t, db.Merge(wo, givenKey, mergeops.SetAdd([]byte("c"))))
+	db.CompactRange(Range{nil, nil})
+
+	ro := NewDefaultReadOptions()
+	v, err := db.GetBytes(ro, givenKey)
+	require.Nil(t, err)
+
+	members, err := mergeops.DecodeSet(v)
+	require.Nil(t, err)
+	require.EqualValues(t, [][]byte{[]byte("a"), []byte("c")}, members)
+}
+
 // Mock Objects
 type mockMergeOperator struct {
 	fullMerge func(key, existingValue []byte, operands [][]byte) ([]byte, bool)
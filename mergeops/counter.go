@@ -0,0 +1,201 @@
+package mergeops
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// OverflowPolicy defines how a counter behaves when it goes out of range.
+type OverflowPolicy int
+
+const (
+	// OverflowWrap wraps around, as two's complement arithmetic does.
+	OverflowWrap OverflowPolicy = iota
+	// OverflowSaturate sticks to the bound of the range being exceeded.
+	OverflowSaturate
+	// OverflowError fails the merge.
+	OverflowError
+)
+
+// EncodeInt64 encodes a signed counter value or delta, as fixed 8 bytes little-endian.
+func EncodeInt64(v int64) []byte {
+	return EncodeUint64(uint64(v))
+}
+
+// DecodeInt64 decodes a signed counter value or delta encoded by EncodeInt64.
+func DecodeInt64(b []byte) (int64, error) {
+	v, err := DecodeUint64(b)
+	return int64(v), err
+}
+
+// EncodeUint64 encodes an unsigned counter value, as fixed 8 bytes little-endian.
+func EncodeUint64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, v)
+	return b
+}
+
+// DecodeUint64 decodes an unsigned counter value encoded by EncodeUint64.
+func DecodeUint64(b []byte) (uint64, error) {
+	if len(b) != 8 {
+		return 0, ErrInvalidValue
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+// Int64Counter is a merge operator maintaining a signed 64-bit counter.
+// Values are encoded by EncodeInt64 and operands are deltas, encoded by EncodeInt64.
+// A missing key counts as 0.
+type Int64Counter struct {
+	policy OverflowPolicy
+}
+
+// NewInt64Counter creates an Int64Counter with the given overflow policy.
+func NewInt64Counter(policy OverflowPolicy) *Int64Counter {
+	return &Int64Counter{policy: policy}
+}
+
+// Name returns the name of the merge operator.
+func (c *Int64Counter) Name() string { return "grocksdb.mergeops.Int64Counter" }
+
+// FullMerge adds operands to the existing value.
+func (c *Int64Counter) FullMerge(key, existingValue []byte, operands [][]byte) ([]byte, bool) {
+	var v int64
+	if existingValue != nil {
+		var err error
+		if v, err = DecodeInt64(existingValue); err != nil {
+			return nil, false
+		}
+	}
+
+	for _, operand := range operands {
+		delta, err := DecodeInt64(operand)
+		if err != nil {
+			return nil, false
+		}
+
+		sum := v + delta
+		if (delta > 0 && sum < v) || (delta < 0 && sum > v) {
+			switch c.policy {
+			case OverflowSaturate:
+				if delta > 0 {
+					sum = math.MaxInt64
+				} else {
+					sum = math.MinInt64
+				}
+			case OverflowError:
+				return nil, false
+			}
+		}
+		v = sum
+	}
+	return EncodeInt64(v), true
+}
+
+// PartialMerge sums two deltas. Unless the counter wraps around, deltas of
+// opposite signs are not merged, as the order in which they are applied
+// matters near the bounds.
+func (c *Int64Counter) PartialMerge(key, leftOperand, rightOperand []byte) ([]byte, bool) {
+	return mergeDeltas(c.policy, leftOperand, rightOperand)
+}
+
+// PartialMergeMulti sums deltas, see PartialMerge.
+func (c *Int64Counter) PartialMergeMulti(key []byte, operands [][]byte) ([]byte, bool) {
+	return partialMergeMulti(c.PartialMerge, key, operands)
+}
+
+// Destroy releases the merge operator.
+func (c *Int64Counter) Destroy() {}
+
+// Uint64Counter is a merge operator maintaining an unsigned 64-bit counter.
+// Values are encoded by EncodeUint64 and operands are signed deltas, encoded
+// by EncodeInt64. A missing key counts as 0.
+type Uint64Counter struct {
+	policy OverflowPolicy
+}
+
+// NewUint64Counter creates an Uint64Counter with the given overflow policy.
+func NewUint64Counter(policy OverflowPolicy) *Uint64Counter {
+	return &Uint64Counter{policy: policy}
+}
+
+// Name returns the name of the merge operator.
+func (c *Uint64Counter) Name() string { return "grocksdb.mergeops.Uint64Counter" }
+
+// FullMerge adds operands to the existing value.
+func (c *Uint64Counter) FullMerge(key, existingValue []byte, operands [][]byte) ([]byte, bool) {
+	var v uint64
+	if existingValue != nil {
+		var err error
+		if v, err = DecodeUint64(existingValue); err != nil {
+			return nil, false
+		}
+	}
+
+	for _, operand := range operands {
+		delta, err := DecodeInt64(operand)
+		if err != nil {
+			return nil, false
+		}
+
+		// two's complement addition gives the wrapped result of both
+		// increments and decrements
+		sum := v + uint64(delta)
+		if (delta > 0 && sum < v) || (delta < 0 && sum > v) {
+			switch c.policy {
+			case OverflowSaturate:
+				if delta > 0 {
+					sum = math.MaxUint64
+				} else {
+					sum = 0
+				}
+			case OverflowError:
+				return nil, false
+			}
+		}
+		v = sum
+	}
+	return EncodeUint64(v), true
+}
+
+// PartialMerge sums two deltas. Unless the counter wraps around, deltas of
+// opposite signs are not merged, as the order in which they are applied
+// matters near the bounds.
+func (c *Uint64Counter) PartialMerge(key, leftOperand, rightOperand []byte) ([]byte, bool) {
+	return mergeDeltas(c.policy, leftOperand, rightOperand)
+}
+
+// PartialMergeMulti sums deltas, see PartialMerge.
+func (c *Uint64Counter) PartialMergeMulti(key []byte, operands [][]byte) ([]byte, bool) {
+	return partialMergeMulti(c.PartialMerge, key, operands)
+}
+
+// Destroy releases the merge operator.
+func (c *Uint64Counter) Destroy() {}
+
+// mergeDeltas sums two deltas applied one after the other.
+//
+// With wrapping, addition is associative. Otherwise, applying deltas of the
+// same sign one by one saturates or overflows exactly when applying their
+// sum does, as long as the sum itself does not overflow.
+func mergeDeltas(policy OverflowPolicy, left, right []byte) ([]byte, bool) {
+	l, err := DecodeInt64(left)
+	if err != nil {
+		return nil, false
+	}
+	r, err := DecodeInt64(right)
+	if err != nil {
+		return nil, false
+	}
+
+	sum := l + r
+	if policy != OverflowWrap {
+		if (l < 0 && r > 0) || (l > 0 && r < 0) {
+			return nil, false
+		}
+		if (r > 0 && sum < l) || (r < 0 && sum > l) {
+			return nil, false
+		}
+	}
+	return EncodeInt64(sum), true
+}
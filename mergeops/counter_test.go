@@ -0,0 +1,142 @@
+package mergeops
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// counterModel applies deltas one by one with arbitrary precision.
+func counterModel(policy OverflowPolicy, v *big.Int, deltas []int64, min, max *big.Int) (*big.Int, bool) {
+	size := new(big.Int).Sub(max, min)
+	size.Add(size, big.NewInt(1))
+
+	v = new(big.Int).Set(v)
+	for _, delta := range deltas {
+		v.Add(v, big.NewInt(delta))
+		if v.Cmp(min) >= 0 && v.Cmp(max) <= 0 {
+			continue
+		}
+
+		switch policy {
+		case OverflowWrap:
+			v.Sub(v, min)
+			v.Mod(v, size)
+			v.Add(v, min)
+		case OverflowSaturate:
+			if v.Cmp(max) > 0 {
+				v.Set(max)
+			} else {
+				v.Set(min)
+			}
+		case OverflowError:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func randomDelta(rnd *rand.Rand) int64 {
+	switch rnd.Intn(4) {
+	case 0:
+		return math.MaxInt64 - rnd.Int63n(4)
+	case 1:
+		return math.MinInt64 + rnd.Int63n(4)
+	case 2:
+		return rnd.Int63() - rnd.Int63()
+	default:
+		return rnd.Int63n(21) - 10
+	}
+}
+
+func TestInt64Counter(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(1))
+	min, max := big.NewInt(math.MinInt64), big.NewInt(math.MaxInt64)
+
+	for _, policy := range []OverflowPolicy{OverflowWrap, OverflowSaturate, OverflowError} {
+		op := NewInt64Counter(policy)
+		for i := 0; i < propertyRounds; i++ {
+			var existing []byte
+			var initial int64
+			if rnd.Intn(3) > 0 {
+				initial = randomDelta(rnd)
+				existing = EncodeInt64(initial)
+			}
+
+			deltas := make([]int64, 1+rnd.Intn(6))
+			operands := make([][]byte, len(deltas))
+			for j := range deltas {
+				deltas[j] = randomDelta(rnd)
+				operands[j] = EncodeInt64(deltas[j])
+			}
+
+			expected, expectedOK := counterModel(policy, big.NewInt(initial), deltas, min, max)
+			merged, ok := op.FullMerge(nil, existing, operands)
+			require.Equal(t, expectedOK, ok)
+			if ok {
+				v, err := DecodeInt64(merged)
+				require.NoError(t, err)
+				require.Equal(t, expected.Int64(), v)
+			}
+
+			requireMergeEquivalence(t, rnd, op, existing, operands)
+		}
+	}
+}
+
+func TestUint64Counter(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(1))
+	min, max := big.NewInt(0), new(big.Int).SetUint64(math.MaxUint64)
+
+	for _, policy := range []OverflowPolicy{OverflowWrap, OverflowSaturate, OverflowError} {
+		op := NewUint64Counter(policy)
+		for i := 0; i < propertyRounds; i++ {
+			var existing []byte
+			var initial uint64
+			if rnd.Intn(3) > 0 {
+				initial = uint64(randomDelta(rnd))
+				existing = EncodeUint64(initial)
+			}
+
+			deltas := make([]int64, 1+rnd.Intn(6))
+			operands := make([][]byte, len(deltas))
+			for j := range deltas {
+				deltas[j] = randomDelta(rnd)
+				operands[j] = EncodeInt64(deltas[j])
+			}
+
+			expected, expectedOK := counterModel(policy, new(big.Int).SetUint64(initial), deltas, min, max)
+			merged, ok := op.FullMerge(nil, existing, operands)
+			require.Equal(t, expectedOK, ok)
+			if ok {
+				v, err := DecodeUint64(merged)
+				require.NoError(t, err)
+				require.Equal(t, expected.Uint64(), v)
+			}
+
+			requireMergeEquivalence(t, rnd, op, existing, operands)
+		}
+	}
+}
+
+func TestCounterInvalidOperand(t *testing.T) {
+	t.Parallel()
+
+	op := NewInt64Counter(OverflowWrap)
+	_, ok := op.FullMerge(nil, []byte{1}, [][]byte{EncodeInt64(1)})
+	require.False(t, ok)
+	_, ok = op.FullMerge(nil, nil, [][]byte{{1, 2}})
+	require.False(t, ok)
+	_, ok = op.PartialMerge(nil, EncodeInt64(1), []byte{1})
+	require.False(t, ok)
+
+	_, err := DecodeUint64(nil)
+	require.ErrorIs(t, err, ErrInvalidValue)
+}
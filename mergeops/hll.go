@@ -0,0 +1,260 @@
+package mergeops
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+const (
+	// MinHLLPrecision is the smallest precision of a HyperLogLog.
+	MinHLLPrecision = 4
+	// MaxHLLPrecision is the largest precision of a HyperLogLog.
+	MaxHLLPrecision = 16
+)
+
+// Encodings of a HyperLogLog: all registers, or only the non-zero ones.
+const (
+	hllDense  byte = 'D'
+	hllSparse byte = 'S'
+)
+
+// ErrHLLPrecision indicates an invalid or mismatching HyperLogLog precision.
+var ErrHLLPrecision = fmt.Errorf("mergeops: HyperLogLog precision must be between %d and %d and match", MinHLLPrecision, MaxHLLPrecision)
+
+// HyperLogLog estimates the number of distinct items added to it, using
+// 2^precision bytes of registers. The standard error of the estimation is
+// about 1.04 / sqrt(2^precision).
+type HyperLogLog struct {
+	precision uint8
+	registers []uint8
+}
+
+// NewHyperLogLog creates an empty HyperLogLog.
+func NewHyperLogLog(precision uint8) (*HyperLogLog, error) {
+	if precision < MinHLLPrecision || precision > MaxHLLPrecision {
+		return nil, ErrHLLPrecision
+	}
+	return &HyperLogLog{
+		precision: precision,
+		registers: make([]uint8, 1<<precision),
+	}, nil
+}
+
+// HLLAdd builds an operand of HLLUnion adding items to a HyperLogLog of the
+// given precision.
+func HLLAdd(precision uint8, items ...[]byte) ([]byte, error) {
+	h, err := NewHyperLogLog(precision)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		h.Add(item)
+	}
+	return h.MarshalBinary()
+}
+
+// Precision returns the precision of the HyperLogLog.
+func (h *HyperLogLog) Precision() uint8 {
+	return h.precision
+}
+
+// Add adds an item.
+func (h *HyperLogLog) Add(item []byte) {
+	hash := hashItem(item)
+	idx := hash >> (64 - h.precision)
+	// rank of the first set bit of the remaining bits, the sentinel bit
+	// bounds it when they are all zero
+	rank := uint8(bits.LeadingZeros64(hash<<h.precision|1<<(h.precision-1))) + 1
+	if rank > h.registers[idx] {
+		h.registers[idx] = rank
+	}
+}
+
+// Merge adds the items of other, which must have the same precision.
+func (h *HyperLogLog) Merge(other *HyperLogLog) error {
+	if h.precision != other.precision {
+		return ErrHLLPrecision
+	}
+	for i, r := range other.registers {
+		if r > h.registers[i] {
+			h.registers[i] = r
+		}
+	}
+	return nil
+}
+
+// Estimate returns the estimated number of distinct items added.
+func (h *HyperLogLog) Estimate() uint64 {
+	m := float64(len(h.registers))
+
+	sum, zeros := 0.0, 0
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	var alpha float64
+	switch len(h.registers) {
+	case 16:
+		alpha = 0.673
+	case 32:
+		alpha = 0.697
+	case 64:
+		alpha = 0.709
+	default:
+		alpha = 0.7213 / (1 + 1.079/m)
+	}
+
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		// linear counting for small cardinalities
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+// MarshalBinary encodes the HyperLogLog, as a value or operand of HLLUnion.
+func (h *HyperLogLog) MarshalBinary() ([]byte, error) {
+	nonZero := 0
+	for _, r := range h.registers {
+		if r != 0 {
+			nonZero++
+		}
+	}
+
+	// sparse entries take up to 3 bytes of index and 1 of rank
+	if nonZero*4 >= len(h.registers) {
+		b := make([]byte, 0, 2+len(h.registers))
+		b = append(b, hllDense, h.precision)
+		return append(b, h.registers...), nil
+	}
+
+	b := []byte{hllSparse, h.precision}
+	for i, r := range h.registers {
+		if r != 0 {
+			b = appendUvarint(b, uint64(i))
+			b = append(b, r)
+		}
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes a HyperLogLog encoded by MarshalBinary.
+func (h *HyperLogLog) UnmarshalBinary(b []byte) error {
+	if len(b) < 2 {
+		return ErrInvalidValue
+	}
+
+	decoded, err := NewHyperLogLog(b[1])
+	if err != nil {
+		return err
+	}
+
+	switch b[0] {
+	case hllDense:
+		if len(b)-2 != len(decoded.registers) {
+			return ErrInvalidValue
+		}
+		copy(decoded.registers, b[2:])
+	case hllSparse:
+		for b = b[2:]; len(b) > 0; {
+			idx, size := binary.Uvarint(b)
+			if size <= 0 || idx >= uint64(len(decoded.registers)) || len(b) == size {
+				return ErrInvalidValue
+			}
+			decoded.registers[idx] = b[size]
+			b = b[size+1:]
+		}
+	default:
+		return ErrInvalidValue
+	}
+
+	*h = *decoded
+	return nil
+}
+
+// hashItem hashes item with FNV-1a, finalized as MurmurHash3 does to
+// spread its bits.
+func hashItem(item []byte) uint64 {
+	f := fnv.New64a()
+	_, _ = f.Write(item)
+	x := f.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// HLLUnion is a merge operator maintaining a HyperLogLog, as the union of
+// the existing one and operands. Operands are built by HLLAdd or
+// HyperLogLog.MarshalBinary and must all have the same precision.
+// A missing key is an empty HyperLogLog.
+type HLLUnion struct{}
+
+// NewHLLUnion creates a HLLUnion merge operator.
+func NewHLLUnion() *HLLUnion {
+	return &HLLUnion{}
+}
+
+// Name returns the name of the merge operator.
+func (u *HLLUnion) Name() string { return "grocksdb.mergeops.HLLUnion" }
+
+// FullMerge returns the union of the existing HyperLogLog and operands.
+func (u *HLLUnion) FullMerge(key, existingValue []byte, operands [][]byte) ([]byte, bool) {
+	if existingValue == nil {
+		return u.union(operands)
+	}
+	return u.union(append([][]byte{existingValue}, operands...))
+}
+
+// PartialMerge returns the union of both operands.
+func (u *HLLUnion) PartialMerge(key, leftOperand, rightOperand []byte) ([]byte, bool) {
+	return u.union([][]byte{leftOperand, rightOperand})
+}
+
+// PartialMergeMulti returns the union of operands.
+func (u *HLLUnion) PartialMergeMulti(key []byte, operands [][]byte) ([]byte, bool) {
+	return u.union(operands)
+}
+
+// Destroy releases the merge operator.
+func (u *HLLUnion) Destroy() {}
+
+func (u *HLLUnion) union(sketches [][]byte) ([]byte, bool) {
+	var result *HyperLogLog
+	for _, sketch := range sketches {
+		h := &HyperLogLog{}
+		if err := h.UnmarshalBinary(sketch); err != nil {
+			return nil, false
+		}
+
+		if result == nil {
+			result = h
+		} else if err := result.Merge(h); err != nil {
+			return nil, false
+		}
+	}
+	if result == nil {
+		return nil, false
+	}
+
+	b, err := result.MarshalBinary()
+	return b, err == nil
+}
+
+// EstimateHLL returns the estimated number of distinct items of a value
+// maintained by HLLUnion.
+func EstimateHLL(value []byte) (uint64, error) {
+	h := &HyperLogLog{}
+	if err := h.UnmarshalBinary(value); err != nil {
+		return 0, err
+	}
+	return h.Estimate(), nil
+}
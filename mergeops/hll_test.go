@@ -0,0 +1,132 @@
+package mergeops
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHyperLogLog(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewHyperLogLog(MinHLLPrecision - 1)
+	require.ErrorIs(t, err, ErrHLLPrecision)
+	_, err = NewHyperLogLog(MaxHLLPrecision + 1)
+	require.ErrorIs(t, err, ErrHLLPrecision)
+
+	h, err := NewHyperLogLog(12)
+	require.NoError(t, err)
+	require.EqualValues(t, 12, h.Precision())
+	require.Zero(t, h.Estimate())
+
+	for _, n := range []int{10, 1000, 100000} {
+		for i := 0; i < n; i++ {
+			// duplicates do not count
+			h.Add([]byte(fmt.Sprintf("item-%d", i)))
+			h.Add([]byte(fmt.Sprintf("item-%d", i/2)))
+		}
+
+		// 1.04 / sqrt(4096) is about 1.6%, allow 4 standard errors
+		estimate := float64(h.Estimate())
+		require.InDelta(t, float64(n), estimate, math.Max(1, 0.065*float64(n)))
+	}
+
+	other, err := NewHyperLogLog(10)
+	require.NoError(t, err)
+	require.ErrorIs(t, h.Merge(other), ErrHLLPrecision)
+}
+
+func TestHyperLogLogEncoding(t *testing.T) {
+	t.Parallel()
+
+	h, err := NewHyperLogLog(8)
+	require.NoError(t, err)
+
+	for _, n := range []int{0, 5, 1000} {
+		for i := 0; i < n; i++ {
+			h.Add([]byte(fmt.Sprint(i)))
+		}
+
+		b, err := h.MarshalBinary()
+		require.NoError(t, err)
+		if n == 1000 {
+			require.Equal(t, hllDense, b[0])
+		} else {
+			require.Equal(t, hllSparse, b[0])
+		}
+
+		decoded := &HyperLogLog{}
+		require.NoError(t, decoded.UnmarshalBinary(b))
+		require.Equal(t, h, decoded)
+
+		if b[0] == hllDense {
+			for i := 0; i < len(b); i++ {
+				require.Error(t, decoded.UnmarshalBinary(b[:i]))
+			}
+		} else {
+			// index without rank, then index out of range
+			require.Error(t, decoded.UnmarshalBinary(append(b, 1)))
+			require.Error(t, decoded.UnmarshalBinary(append(b, 0x80, 0x02, 1)))
+		}
+	}
+
+	require.ErrorIs(t, (&HyperLogLog{}).UnmarshalBinary([]byte{'X', 8}), ErrInvalidValue)
+	require.ErrorIs(t, (&HyperLogLog{}).UnmarshalBinary([]byte{hllSparse, 1}), ErrHLLPrecision)
+}
+
+func TestHLLUnion(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(1))
+	op := NewHLLUnion()
+
+	for i := 0; i < propertyRounds/10; i++ {
+		model := make(map[int]struct{})
+		randomItems := func() [][]byte {
+			items := make([][]byte, rnd.Intn(200))
+			for j := range items {
+				n := rnd.Intn(1000)
+				model[n] = struct{}{}
+				items[j] = []byte(fmt.Sprint(n))
+			}
+			return items
+		}
+
+		var existing []byte
+		if rnd.Intn(3) > 0 {
+			var err error
+			existing, err = HLLAdd(10, randomItems()...)
+			require.NoError(t, err)
+		}
+
+		operands := make([][]byte, 1+rnd.Intn(6))
+		for j := range operands {
+			var err error
+			operands[j], err = HLLAdd(10, randomItems()...)
+			require.NoError(t, err)
+		}
+
+		merged, ok := op.FullMerge(nil, existing, operands)
+		require.True(t, ok)
+		estimate, err := EstimateHLL(merged)
+		require.NoError(t, err)
+		// 1.04 / sqrt(1024) is about 3.3%, allow 4 standard errors
+		require.InDelta(t, float64(len(model)), float64(estimate), math.Max(2, 0.13*float64(len(model))))
+
+		requireMergeEquivalence(t, rnd, op, existing, operands)
+	}
+
+	operand, err := HLLAdd(12, []byte("a"))
+	require.NoError(t, err)
+	_, ok := op.FullMerge(nil, operand, [][]byte{mustHLLAdd(t, 10)})
+	require.False(t, ok)
+}
+
+func mustHLLAdd(t *testing.T, precision uint8, items ...[]byte) []byte {
+	b, err := HLLAdd(precision, items...)
+	require.NoError(t, err)
+	return b
+}
@@ -0,0 +1,150 @@
+package mergeops
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// JSONMergePatch is a merge operator applying JSON merge patches, as defined
+// by RFC 7386, to a JSON document. Operands are merge patches and a missing
+// key is treated as a missing document.
+//
+// Merged documents are re-encoded, with object keys sorted.
+type JSONMergePatch struct{}
+
+// NewJSONMergePatch creates a JSONMergePatch merge operator.
+func NewJSONMergePatch() *JSONMergePatch {
+	return &JSONMergePatch{}
+}
+
+// Name returns the name of the merge operator.
+func (p *JSONMergePatch) Name() string { return "grocksdb.mergeops.JSONMergePatch" }
+
+// FullMerge applies patches to the existing document.
+func (p *JSONMergePatch) FullMerge(key, existingValue []byte, operands [][]byte) ([]byte, bool) {
+	var doc interface{}
+	if existingValue != nil {
+		var err error
+		if doc, err = decodeJSON(existingValue); err != nil {
+			return nil, false
+		}
+	}
+
+	for _, operand := range operands {
+		patch, err := decodeJSON(operand)
+		if err != nil {
+			return nil, false
+		}
+		doc = applyMergePatch(doc, patch)
+	}
+
+	value, err := json.Marshal(doc)
+	return value, err == nil
+}
+
+// PartialMerge combines two patches into one. Patches replacing a member
+// with an object to which the next patch applies can not be combined: the
+// combined patch would merge the object instead of replacing the member.
+func (p *JSONMergePatch) PartialMerge(key, leftOperand, rightOperand []byte) ([]byte, bool) {
+	left, err := decodeJSON(leftOperand)
+	if err != nil {
+		return nil, false
+	}
+	right, err := decodeJSON(rightOperand)
+	if err != nil {
+		return nil, false
+	}
+
+	combined, ok := combineMergePatches(left, right)
+	if !ok {
+		return nil, false
+	}
+
+	value, err := json.Marshal(combined)
+	return value, err == nil
+}
+
+// PartialMergeMulti combines patches into one, see PartialMerge.
+func (p *JSONMergePatch) PartialMergeMulti(key []byte, operands [][]byte) ([]byte, bool) {
+	return partialMergeMulti(p.PartialMerge, key, operands)
+}
+
+// Destroy releases the merge operator.
+func (p *JSONMergePatch) Destroy() {}
+
+func decodeJSON(b []byte) (v interface{}, err error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err = dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err = dec.Token(); err != io.EOF {
+		return nil, ErrInvalidValue
+	}
+	return v, nil
+}
+
+// applyMergePatch implements the MergePatch function of RFC 7386.
+func applyMergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	result := make(map[string]interface{}, len(targetObj)+len(patchObj))
+	if ok {
+		for k, v := range targetObj {
+			result[k] = v
+		}
+	}
+
+	for k, v := range patchObj {
+		if v == nil {
+			delete(result, k)
+		} else {
+			result[k] = applyMergePatch(result[k], v)
+		}
+	}
+	return result
+}
+
+// combineMergePatches returns a patch equivalent to applying left then right.
+func combineMergePatches(left, right interface{}) (interface{}, bool) {
+	rightObj, ok := right.(map[string]interface{})
+	if !ok {
+		// right replaces the whole document
+		return right, true
+	}
+	leftObj, ok := left.(map[string]interface{})
+	if !ok {
+		// left replaces the whole document, which right then patches
+		return nil, false
+	}
+
+	combined := make(map[string]interface{}, len(leftObj)+len(rightObj))
+	for k, v := range leftObj {
+		combined[k] = v
+	}
+
+	for k, r := range rightObj {
+		if _, isObj := r.(map[string]interface{}); !isObj {
+			combined[k] = r
+			continue
+		}
+
+		l, exists := leftObj[k]
+		if !exists {
+			combined[k] = r
+			continue
+		}
+
+		c, ok := combineMergePatches(l, r)
+		if !ok {
+			return nil, false
+		}
+		combined[k] = c
+	}
+	return combined, true
+}
@@ -0,0 +1,128 @@
+package mergeops
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONMergePatchRFC7386(t *testing.T) {
+	t.Parallel()
+
+	// test cases of RFC 7386, Appendix A
+	cases := []struct{ target, patch, result string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	op := NewJSONMergePatch()
+	for _, c := range cases {
+		merged, ok := op.FullMerge(nil, []byte(c.target), [][]byte{[]byte(c.patch)})
+		require.True(t, ok)
+		require.JSONEq(t, c.result, string(merged))
+	}
+
+	merged, ok := op.FullMerge(nil, nil, [][]byte{[]byte(`{"a":{"b":null,"c":1}}`)})
+	require.True(t, ok)
+	require.JSONEq(t, `{"a":{"c":1}}`, string(merged))
+
+	// large numbers are kept as is
+	merged, ok = op.FullMerge(nil, []byte(`{"n":18446744073709551615}`), [][]byte{[]byte(`{}`)})
+	require.True(t, ok)
+	require.Equal(t, `{"n":18446744073709551615}`, string(merged))
+
+	_, ok = op.FullMerge(nil, []byte(`{`), [][]byte{[]byte(`{}`)})
+	require.False(t, ok)
+	_, ok = op.FullMerge(nil, nil, [][]byte{[]byte(`{} {}`)})
+	require.False(t, ok)
+}
+
+func TestJSONMergePatch(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(1))
+	op := NewJSONMergePatch()
+
+	var randomJSON func(depth int) interface{}
+	randomJSON = func(depth int) interface{} {
+		switch n := rnd.Intn(6); {
+		case n == 0:
+			return nil
+		case n == 1:
+			return rnd.Intn(3)
+		case n == 2:
+			return []interface{}{"x"}
+		case depth > 2:
+			return "s"
+		default:
+			obj := make(map[string]interface{})
+			for _, k := range []string{"a", "b", "c"} {
+				if rnd.Intn(2) == 0 {
+					obj[k] = randomJSON(depth + 1)
+				}
+			}
+			return obj
+		}
+	}
+	encode := func(v interface{}) []byte {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		return b
+	}
+
+	combined := 0
+	for i := 0; i < propertyRounds; i++ {
+		var existing []byte
+		if rnd.Intn(3) > 0 {
+			existing = encode(randomJSON(0))
+		}
+
+		operands := make([][]byte, 1+rnd.Intn(5))
+		for j := range operands {
+			operands[j] = encode(randomJSON(0))
+		}
+		if len(operands) > 1 {
+			if _, ok := op.PartialMergeMulti(nil, operands); ok {
+				combined++
+			}
+		}
+
+		requireMergeEquivalence(t, rnd, op, existing, operands)
+	}
+	require.NotZero(t, combined)
+}
+
+func TestJSONMergePatchDecline(t *testing.T) {
+	t.Parallel()
+
+	op := NewJSONMergePatch()
+
+	// replacing a member by an object, then patching it
+	_, ok := op.PartialMerge(nil, []byte(`{"a":{"x":1}}`), []byte(`{"a":{"y":2}}`))
+	require.True(t, ok)
+	_, ok = op.PartialMerge(nil, []byte(`{"a":1}`), []byte(`{"a":{"y":2}}`))
+	require.False(t, ok)
+	_, ok = op.PartialMerge(nil, []byte(`{"a":null}`), []byte(`{"a":{"y":2}}`))
+	require.False(t, ok)
+	_, ok = op.PartialMerge(nil, []byte(`[1]`), []byte(`{"y":2}`))
+	require.False(t, ok)
+
+	merged, ok := op.PartialMerge(nil, []byte(`{"a":{"x":1}}`), []byte(`[1]`))
+	require.True(t, ok)
+	require.Equal(t, `[1]`, string(merged))
+}
@@ -0,0 +1,68 @@
+package mergeops
+
+// EncodeList builds a value or operand of BoundedList made of items.
+func EncodeList(items ...[]byte) []byte {
+	return appendList(nil, items)
+}
+
+// DecodeList decodes the items of a value or operand of BoundedList.
+func DecodeList(b []byte) ([][]byte, error) {
+	return decodeList(b)
+}
+
+// BoundedList is a merge operator appending items to a list, keeping only
+// the last ones. Values and operands are built by EncodeList.
+// A missing key is an empty list.
+type BoundedList struct {
+	limit int
+}
+
+// NewBoundedList creates a BoundedList merge operator keeping at most
+// limit items.
+func NewBoundedList(limit int) *BoundedList {
+	if limit < 0 {
+		limit = 0
+	}
+	return &BoundedList{limit: limit}
+}
+
+// Name returns the name of the merge operator.
+func (l *BoundedList) Name() string { return "grocksdb.mergeops.BoundedList" }
+
+// FullMerge appends items of operands to the existing list.
+func (l *BoundedList) FullMerge(key, existingValue []byte, operands [][]byte) ([]byte, bool) {
+	if existingValue == nil {
+		return l.concat(operands)
+	}
+	return l.concat(append([][]byte{existingValue}, operands...))
+}
+
+// PartialMerge concatenates items of both operands.
+func (l *BoundedList) PartialMerge(key, leftOperand, rightOperand []byte) ([]byte, bool) {
+	return l.concat([][]byte{leftOperand, rightOperand})
+}
+
+// PartialMergeMulti concatenates items of operands.
+func (l *BoundedList) PartialMergeMulti(key []byte, operands [][]byte) ([]byte, bool) {
+	return l.concat(operands)
+}
+
+// Destroy releases the merge operator.
+func (l *BoundedList) Destroy() {}
+
+// concat concatenates items of lists, keeping the last ones.
+func (l *BoundedList) concat(lists [][]byte) ([]byte, bool) {
+	var items [][]byte
+	for _, list := range lists {
+		decoded, err := DecodeList(list)
+		if err != nil {
+			return nil, false
+		}
+		items = append(items, decoded...)
+	}
+
+	if len(items) > l.limit {
+		items = items[len(items)-l.limit:]
+	}
+	return appendList(nil, items), true
+}
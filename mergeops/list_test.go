@@ -0,0 +1,61 @@
+package mergeops
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoundedList(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(1))
+
+	for i := 0; i < propertyRounds; i++ {
+		limit := rnd.Intn(6)
+		op := NewBoundedList(limit)
+
+		var model [][]byte
+		var existing []byte
+		if rnd.Intn(3) > 0 {
+			model = [][]byte{[]byte("x"), []byte("y")}
+			existing = EncodeList(model...)
+		}
+
+		operands := make([][]byte, 1+rnd.Intn(6))
+		for j := range operands {
+			items := make([][]byte, rnd.Intn(3))
+			for k := range items {
+				items[k] = []byte(fmt.Sprintf("%d.%d", j, k))
+			}
+			operands[j] = EncodeList(items...)
+			model = append(model, items...)
+		}
+		if len(model) > limit {
+			model = model[len(model)-limit:]
+		}
+
+		merged, ok := op.FullMerge(nil, existing, operands)
+		require.True(t, ok)
+		items, err := DecodeList(merged)
+		require.NoError(t, err)
+		require.Equal(t, len(model), len(items))
+		for j := range model {
+			require.Equal(t, model[j], items[j])
+		}
+
+		requireMergeEquivalence(t, rnd, op, existing, operands)
+	}
+}
+
+func TestBoundedListInvalid(t *testing.T) {
+	t.Parallel()
+
+	op := NewBoundedList(2)
+	_, ok := op.FullMerge(nil, []byte{5}, [][]byte{EncodeList([]byte("a"))})
+	require.False(t, ok)
+
+	require.Equal(t, 0, NewBoundedList(-1).limit)
+}
@@ -0,0 +1,88 @@
+package mergeops
+
+import (
+	"encoding/binary"
+)
+
+const lwwTimestampSize = 8
+
+// EncodeLWW builds a value or operand of LWWRegister, written at timestamp ts.
+func EncodeLWW(ts uint64, value []byte) []byte {
+	b := make([]byte, lwwTimestampSize, lwwTimestampSize+len(value))
+	binary.BigEndian.PutUint64(b, ts)
+	return append(b, value...)
+}
+
+// DecodeLWW decodes a value or operand built by EncodeLWW.
+func DecodeLWW(b []byte) (ts uint64, value []byte, err error) {
+	if len(b) < lwwTimestampSize {
+		return 0, nil, ErrInvalidValue
+	}
+	return binary.BigEndian.Uint64(b), b[lwwTimestampSize:], nil
+}
+
+// LWWRegister is a last-writer-wins register merge operator: the value
+// with the greatest timestamp wins, ties being won by the last written one.
+// Values and operands are built by EncodeLWW.
+type LWWRegister struct{}
+
+// NewLWWRegister creates a LWWRegister merge operator.
+func NewLWWRegister() *LWWRegister {
+	return &LWWRegister{}
+}
+
+// Name returns the name of the merge operator.
+func (r *LWWRegister) Name() string { return "grocksdb.mergeops.LWWRegister" }
+
+// FullMerge returns the winner of the existing value and operands.
+func (r *LWWRegister) FullMerge(key, existingValue []byte, operands [][]byte) ([]byte, bool) {
+	winner := existingValue
+	if winner != nil {
+		if _, _, err := DecodeLWW(winner); err != nil {
+			return nil, false
+		}
+	}
+
+	for _, operand := range operands {
+		var ok bool
+		if winner, ok = lastWriterWins(winner, operand); !ok {
+			return nil, false
+		}
+	}
+	return append([]byte{}, winner...), true
+}
+
+// PartialMerge returns the winner of both operands.
+func (r *LWWRegister) PartialMerge(key, leftOperand, rightOperand []byte) ([]byte, bool) {
+	if _, _, err := DecodeLWW(leftOperand); err != nil {
+		return nil, false
+	}
+	winner, ok := lastWriterWins(leftOperand, rightOperand)
+	if !ok {
+		return nil, false
+	}
+	return append([]byte{}, winner...), true
+}
+
+// PartialMergeMulti returns the winner of operands.
+func (r *LWWRegister) PartialMergeMulti(key []byte, operands [][]byte) ([]byte, bool) {
+	return partialMergeMulti(r.PartialMerge, key, operands)
+}
+
+// Destroy releases the merge operator.
+func (r *LWWRegister) Destroy() {}
+
+// lastWriterWins returns the winner of current, which is nil or valid, and
+// the later written next.
+func lastWriterWins(current, next []byte) ([]byte, bool) {
+	nextTs, _, err := DecodeLWW(next)
+	if err != nil {
+		return nil, false
+	}
+	if current != nil {
+		if currentTs, _, _ := DecodeLWW(current); currentTs > nextTs {
+			return current, true
+		}
+	}
+	return next, true
+}
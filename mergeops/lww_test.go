@@ -0,0 +1,57 @@
+package mergeops
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLWWRegister(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(1))
+	op := NewLWWRegister()
+
+	for i := 0; i < propertyRounds; i++ {
+		var existing []byte
+		var modelTs uint64
+		var modelValue []byte
+		if rnd.Intn(3) > 0 {
+			modelTs, modelValue = uint64(rnd.Intn(10)), []byte("existing")
+			existing = EncodeLWW(modelTs, modelValue)
+		}
+
+		operands := make([][]byte, 1+rnd.Intn(6))
+		for j := range operands {
+			ts, value := uint64(rnd.Intn(10)), []byte(fmt.Sprintf("v%d", j))
+			operands[j] = EncodeLWW(ts, value)
+			if modelValue == nil || ts >= modelTs {
+				modelTs, modelValue = ts, value
+			}
+		}
+
+		merged, ok := op.FullMerge(nil, existing, operands)
+		require.True(t, ok)
+		ts, value, err := DecodeLWW(merged)
+		require.NoError(t, err)
+		require.Equal(t, modelTs, ts)
+		require.Equal(t, modelValue, value)
+
+		requireMergeEquivalence(t, rnd, op, existing, operands)
+	}
+}
+
+func TestLWWRegisterInvalid(t *testing.T) {
+	t.Parallel()
+
+	op := NewLWWRegister()
+	_, ok := op.FullMerge(nil, []byte{1}, [][]byte{EncodeLWW(1, nil)})
+	require.False(t, ok)
+	_, ok = op.PartialMerge(nil, EncodeLWW(1, nil), []byte{1})
+	require.False(t, ok)
+
+	_, _, err := DecodeLWW([]byte{1, 2, 3})
+	require.ErrorIs(t, err, ErrInvalidValue)
+}
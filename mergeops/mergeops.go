@@ -0,0 +1,92 @@
+/*
+Package mergeops provides merge operators implemented in Go, to be set with
+grocksdb.Options.SetMergeOperator.
+
+	opts.SetMergeOperator(mergeops.NewInt64Counter(mergeops.OverflowSaturate))
+	...
+	err = db.Merge(wo, []byte("visits"), mergeops.EncodeInt64(1))
+
+All operators implement grocksdb.MergeOperator, grocksdb.PartialMerger and
+grocksdb.MultiMerger. Partial merges only combine operands when the result is
+guaranteed to be the same as applying them one by one, otherwise they decline
+and RocksDB keeps the operands until a base value is available.
+
+Each operator defines the encoding of its values and operands, built with
+the helpers of this package. Invalid values or operands make the merge fail,
+which RocksDB reports as a corruption error.
+*/
+package mergeops
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// ErrInvalidValue indicates a value or operand which was not built with the
+// helpers of this package.
+var ErrInvalidValue = fmt.Errorf("mergeops: invalid value")
+
+// partialMergeFunc merges two operands into one.
+type partialMergeFunc func(key, leftOperand, rightOperand []byte) ([]byte, bool)
+
+// partialMergeMulti folds operands pairwise with partial.
+func partialMergeMulti(partial partialMergeFunc, key []byte, operands [][]byte) ([]byte, bool) {
+	if len(operands) == 0 {
+		return nil, false
+	}
+
+	merged := operands[0]
+	for _, operand := range operands[1:] {
+		var ok bool
+		if merged, ok = partial(key, merged, operand); !ok {
+			return nil, false
+		}
+	}
+	return merged, true
+}
+
+// appendList appends items to dst, prefixed by their count and each of
+// them prefixed by its length.
+func appendList(dst []byte, items [][]byte) []byte {
+	dst = appendUvarint(dst, uint64(len(items)))
+	for _, item := range items {
+		dst = appendUvarint(dst, uint64(len(item)))
+		dst = append(dst, item...)
+	}
+	return dst
+}
+
+func appendUvarint(dst []byte, v uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	return append(dst, b[:n]...)
+}
+
+// readList reads a list encoded by appendList and returns the rest of b.
+func readList(b []byte) (items [][]byte, rest []byte, err error) {
+	n, size := binary.Uvarint(b)
+	if size <= 0 || n > uint64(len(b)) {
+		return nil, nil, ErrInvalidValue
+	}
+	b = b[size:]
+
+	items = make([][]byte, 0, n)
+	for i := uint64(0); i < n; i++ {
+		length, size := binary.Uvarint(b)
+		if size <= 0 || length > uint64(len(b)-size) {
+			return nil, nil, ErrInvalidValue
+		}
+		items = append(items, b[size:size+int(length)])
+		b = b[size+int(length):]
+	}
+	return items, b, nil
+}
+
+// decodeList decodes a list encoded by appendList, which must span all of b.
+func decodeList(b []byte) ([][]byte, error) {
+	items, rest, err := readList(b)
+	if err == nil && len(rest) > 0 {
+		err = ErrInvalidValue
+	}
+	return items, err
+}
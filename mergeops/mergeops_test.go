@@ -0,0 +1,97 @@
+package mergeops
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const propertyRounds = 500
+
+// multiMerger is the set of methods RocksDB calls on merge operators.
+type multiMerger interface {
+	FullMerge(key, existingValue []byte, operands [][]byte) ([]byte, bool)
+	PartialMerge(key, leftOperand, rightOperand []byte) ([]byte, bool)
+	PartialMergeMulti(key []byte, operands [][]byte) ([]byte, bool)
+}
+
+// requireMergeEquivalence checks that partially merging random runs of
+// operands, as RocksDB may do during flushes and compactions, does not
+// change the result of the full merge.
+func requireMergeEquivalence(t *testing.T, rnd *rand.Rand, op multiMerger, existingValue []byte, operands [][]byte) {
+	t.Helper()
+
+	key := []byte("key")
+	expected, expectedOK := op.FullMerge(key, existingValue, operands)
+
+	for round := 0; round < 4; round++ {
+		var grouped [][]byte
+		for i := 0; i < len(operands); {
+			n := 1 + rnd.Intn(len(operands)-i)
+			run := operands[i : i+n]
+			i += n
+
+			var merged []byte
+			var ok bool
+			if n == 2 && rnd.Intn(2) == 0 {
+				merged, ok = op.PartialMerge(key, run[0], run[1])
+			} else if n > 1 {
+				merged, ok = op.PartialMergeMulti(key, run)
+			}
+			if ok {
+				grouped = append(grouped, merged)
+			} else {
+				grouped = append(grouped, run...)
+			}
+		}
+
+		actual, actualOK := op.FullMerge(key, existingValue, grouped)
+		require.Equal(t, expectedOK, actualOK)
+		if expectedOK {
+			require.Equal(t, expected, actual)
+		}
+	}
+}
+
+func TestPartialMergeMulti(t *testing.T) {
+	t.Parallel()
+
+	concat := func(key, left, right []byte) ([]byte, bool) {
+		return append(append([]byte{}, left...), right...), true
+	}
+
+	_, ok := partialMergeMulti(concat, nil, nil)
+	require.False(t, ok)
+
+	merged, ok := partialMergeMulti(concat, nil, [][]byte{[]byte("a"), []byte("b"), []byte("c")})
+	require.True(t, ok)
+	require.Equal(t, []byte("abc"), merged)
+
+	decline := func(key, left, right []byte) ([]byte, bool) { return nil, false }
+	_, ok = partialMergeMulti(decline, nil, [][]byte{[]byte("a"), []byte("b")})
+	require.False(t, ok)
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+
+	items := [][]byte{[]byte("a"), {}, []byte("ccc")}
+	b := appendList(nil, items)
+
+	decoded, err := decodeList(b)
+	require.NoError(t, err)
+	require.Equal(t, items, decoded)
+
+	decoded, rest, err := readList(append(b, 'x'))
+	require.NoError(t, err)
+	require.Equal(t, items, decoded)
+	require.Equal(t, []byte("x"), rest)
+
+	_, err = decodeList(append(b, 'x'))
+	require.ErrorIs(t, err, ErrInvalidValue)
+	for i := 0; i < len(b); i++ {
+		_, err = decodeList(b[:i])
+		require.ErrorIs(t, err, ErrInvalidValue)
+	}
+}
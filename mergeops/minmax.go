@@ -0,0 +1,134 @@
+package mergeops
+
+import (
+	"bytes"
+)
+
+// CompareInt64 compares values encoded by EncodeInt64, values of an
+// invalid size being lower than any valid one.
+func CompareInt64(a, b []byte) int {
+	x, errX := DecodeInt64(a)
+	y, errY := DecodeInt64(b)
+	switch {
+	case errX != nil || errY != nil:
+		return compareValidity(errX == nil, errY == nil)
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// CompareUint64 compares values encoded by EncodeUint64, values of an
+// invalid size being lower than any valid one.
+func CompareUint64(a, b []byte) int {
+	x, errX := DecodeUint64(a)
+	y, errY := DecodeUint64(b)
+	switch {
+	case errX != nil || errY != nil:
+		return compareValidity(errX == nil, errY == nil)
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareValidity(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// Max is a merge operator keeping the largest of the existing value and
+// operands, according to a comparison function.
+type Max struct {
+	compare func(a, b []byte) int
+}
+
+// NewMax creates a Max merge operator. Values are compared byte-wise
+// when compare is nil.
+func NewMax(compare func(a, b []byte) int) *Max {
+	if compare == nil {
+		compare = bytes.Compare
+	}
+	return &Max{compare: compare}
+}
+
+// Name returns the name of the merge operator.
+func (m *Max) Name() string { return "grocksdb.mergeops.Max" }
+
+// FullMerge returns the largest of the existing value and operands.
+func (m *Max) FullMerge(key, existingValue []byte, operands [][]byte) ([]byte, bool) {
+	return extremum(m.compare, 1, existingValue, operands), true
+}
+
+// PartialMerge returns the largest operand.
+func (m *Max) PartialMerge(key, leftOperand, rightOperand []byte) ([]byte, bool) {
+	return extremum(m.compare, 1, leftOperand, [][]byte{rightOperand}), true
+}
+
+// PartialMergeMulti returns the largest operand.
+func (m *Max) PartialMergeMulti(key []byte, operands [][]byte) ([]byte, bool) {
+	return partialMergeMulti(m.PartialMerge, key, operands)
+}
+
+// Destroy releases the merge operator.
+func (m *Max) Destroy() {}
+
+// Min is a merge operator keeping the smallest of the existing value and
+// operands, according to a comparison function.
+type Min struct {
+	compare func(a, b []byte) int
+}
+
+// NewMin creates a Min merge operator. Values are compared byte-wise
+// when compare is nil.
+func NewMin(compare func(a, b []byte) int) *Min {
+	if compare == nil {
+		compare = bytes.Compare
+	}
+	return &Min{compare: compare}
+}
+
+// Name returns the name of the merge operator.
+func (m *Min) Name() string { return "grocksdb.mergeops.Min" }
+
+// FullMerge returns the smallest of the existing value and operands.
+func (m *Min) FullMerge(key, existingValue []byte, operands [][]byte) ([]byte, bool) {
+	return extremum(m.compare, -1, existingValue, operands), true
+}
+
+// PartialMerge returns the smallest operand.
+func (m *Min) PartialMerge(key, leftOperand, rightOperand []byte) ([]byte, bool) {
+	return extremum(m.compare, -1, leftOperand, [][]byte{rightOperand}), true
+}
+
+// PartialMergeMulti returns the smallest operand.
+func (m *Min) PartialMergeMulti(key []byte, operands [][]byte) ([]byte, bool) {
+	return partialMergeMulti(m.PartialMerge, key, operands)
+}
+
+// Destroy releases the merge operator.
+func (m *Min) Destroy() {}
+
+// extremum returns the first of initial, when not nil, and operands whose
+// comparison with all others has the sign of direction or is 0.
+func extremum(compare func(a, b []byte) int, direction int, initial []byte, operands [][]byte) []byte {
+	best := initial
+	for _, operand := range operands {
+		if best == nil || compare(operand, best)*direction > 0 {
+			best = operand
+		}
+	}
+	return append([]byte{}, best...)
+}
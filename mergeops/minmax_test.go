@@ -0,0 +1,75 @@
+package mergeops
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxMin(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(1))
+	max, min := NewMax(CompareInt64), NewMin(CompareInt64)
+
+	for i := 0; i < propertyRounds; i++ {
+		values := make([]int64, 1+rnd.Intn(6))
+		for j := range values {
+			values[j] = randomDelta(rnd)
+		}
+
+		var existing []byte
+		operands := make([][]byte, 0, len(values))
+		for j, v := range values {
+			if j == 0 && rnd.Intn(2) == 0 {
+				existing = EncodeInt64(v)
+			} else {
+				operands = append(operands, EncodeInt64(v))
+			}
+		}
+		if len(operands) == 0 {
+			continue
+		}
+
+		expectedMax, expectedMin := values[0], values[0]
+		for _, v := range values {
+			if v > expectedMax {
+				expectedMax = v
+			}
+			if v < expectedMin {
+				expectedMin = v
+			}
+		}
+
+		merged, ok := max.FullMerge(nil, existing, operands)
+		require.True(t, ok)
+		require.Equal(t, EncodeInt64(expectedMax), merged)
+		requireMergeEquivalence(t, rnd, max, existing, operands)
+
+		merged, ok = min.FullMerge(nil, existing, operands)
+		require.True(t, ok)
+		require.Equal(t, EncodeInt64(expectedMin), merged)
+		requireMergeEquivalence(t, rnd, min, existing, operands)
+	}
+}
+
+func TestMaxMinCompare(t *testing.T) {
+	t.Parallel()
+
+	operands := [][]byte{[]byte("b"), []byte("c"), []byte("a")}
+
+	merged, ok := NewMax(nil).FullMerge(nil, nil, operands)
+	require.True(t, ok)
+	require.Equal(t, []byte("c"), merged)
+
+	merged, ok = NewMin(nil).FullMerge(nil, []byte("0"), operands)
+	require.True(t, ok)
+	require.Equal(t, []byte("0"), merged)
+
+	require.Equal(t, -1, CompareUint64(EncodeUint64(1), EncodeUint64(2)))
+	require.Equal(t, 1, CompareUint64(EncodeUint64(1<<63), EncodeUint64(1)))
+	require.Equal(t, -1, CompareInt64(EncodeInt64(-1), EncodeInt64(1)))
+	require.Equal(t, -1, CompareInt64([]byte{1}, EncodeInt64(-1)))
+	require.Equal(t, 0, CompareUint64(nil, []byte{1}))
+}
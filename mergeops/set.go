@@ -0,0 +1,126 @@
+package mergeops
+
+import (
+	"bytes"
+	"sort"
+)
+
+// SetAdd builds a SortedSet operand adding members to the set.
+func SetAdd(members ...[]byte) []byte {
+	return encodeSetOperand(members, nil)
+}
+
+// SetRemove builds a SortedSet operand removing members from the set.
+func SetRemove(members ...[]byte) []byte {
+	return encodeSetOperand(nil, members)
+}
+
+// DecodeSet decodes the members of a set maintained by SortedSet,
+// in ascending byte-wise order.
+func DecodeSet(value []byte) ([][]byte, error) {
+	return decodeList(value)
+}
+
+// SortedSet is a merge operator maintaining a set of byte strings, stored
+// in ascending byte-wise order. Operands are built by SetAdd and SetRemove,
+// values are decoded by DecodeSet. A missing key is an empty set.
+type SortedSet struct{}
+
+// NewSortedSet creates a SortedSet merge operator.
+func NewSortedSet() *SortedSet {
+	return &SortedSet{}
+}
+
+// Name returns the name of the merge operator.
+func (s *SortedSet) Name() string { return "grocksdb.mergeops.SortedSet" }
+
+// FullMerge applies additions and removals of operands to the existing set.
+func (s *SortedSet) FullMerge(key, existingValue []byte, operands [][]byte) ([]byte, bool) {
+	members := make(map[string]struct{})
+	if existingValue != nil {
+		existing, err := DecodeSet(existingValue)
+		if err != nil {
+			return nil, false
+		}
+		for _, m := range existing {
+			members[string(m)] = struct{}{}
+		}
+	}
+
+	for _, operand := range operands {
+		adds, removes, err := decodeSetOperand(operand)
+		if err != nil {
+			return nil, false
+		}
+		for _, m := range removes {
+			delete(members, string(m))
+		}
+		for _, m := range adds {
+			members[string(m)] = struct{}{}
+		}
+	}
+
+	return appendList(nil, sortedMembers(members)), true
+}
+
+// PartialMerge combines two operands into one having the same effect.
+func (s *SortedSet) PartialMerge(key, leftOperand, rightOperand []byte) ([]byte, bool) {
+	leftAdds, leftRemoves, err := decodeSetOperand(leftOperand)
+	if err != nil {
+		return nil, false
+	}
+	rightAdds, rightRemoves, err := decodeSetOperand(rightOperand)
+	if err != nil {
+		return nil, false
+	}
+
+	// within an operand, removals are applied before additions
+	adds := make(map[string]struct{})
+	removes := make(map[string]struct{})
+	for _, m := range leftRemoves {
+		removes[string(m)] = struct{}{}
+	}
+	for _, m := range leftAdds {
+		adds[string(m)] = struct{}{}
+	}
+	for _, m := range rightRemoves {
+		delete(adds, string(m))
+		removes[string(m)] = struct{}{}
+	}
+	for _, m := range rightAdds {
+		delete(removes, string(m))
+		adds[string(m)] = struct{}{}
+	}
+
+	return encodeSetOperand(sortedMembers(adds), sortedMembers(removes)), true
+}
+
+// PartialMergeMulti combines operands into one having the same effect.
+func (s *SortedSet) PartialMergeMulti(key []byte, operands [][]byte) ([]byte, bool) {
+	return partialMergeMulti(s.PartialMerge, key, operands)
+}
+
+// Destroy releases the merge operator.
+func (s *SortedSet) Destroy() {}
+
+func encodeSetOperand(adds, removes [][]byte) []byte {
+	return appendList(appendList(nil, adds), removes)
+}
+
+func decodeSetOperand(operand []byte) (adds, removes [][]byte, err error) {
+	adds, rest, err := readList(operand)
+	if err != nil {
+		return nil, nil, err
+	}
+	removes, err = decodeList(rest)
+	return adds, removes, err
+}
+
+func sortedMembers(members map[string]struct{}) [][]byte {
+	sorted := make([][]byte, 0, len(members))
+	for m := range members {
+		sorted = append(sorted, []byte(m))
+	}
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i], sorted[j]) < 0 })
+	return sorted
+}
@@ -0,0 +1,78 @@
+package mergeops
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortedSet(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(1))
+	op := NewSortedSet()
+	randomMembers := func() [][]byte {
+		members := make([][]byte, rnd.Intn(4))
+		for i := range members {
+			members[i] = []byte(fmt.Sprintf("m%d", rnd.Intn(8)))
+		}
+		return members
+	}
+
+	for i := 0; i < propertyRounds; i++ {
+		model := make(map[string]struct{})
+
+		var existing []byte
+		if rnd.Intn(3) > 0 {
+			for _, m := range randomMembers() {
+				model[string(m)] = struct{}{}
+			}
+			existing = appendList(nil, sortedMembers(model))
+		}
+
+		operands := make([][]byte, 1+rnd.Intn(6))
+		for j := range operands {
+			members := randomMembers()
+			if rnd.Intn(2) == 0 {
+				operands[j] = SetAdd(members...)
+				for _, m := range members {
+					model[string(m)] = struct{}{}
+				}
+			} else {
+				operands[j] = SetRemove(members...)
+				for _, m := range members {
+					delete(model, string(m))
+				}
+			}
+		}
+
+		merged, ok := op.FullMerge(nil, existing, operands)
+		require.True(t, ok)
+		members, err := DecodeSet(merged)
+		require.NoError(t, err)
+		require.Equal(t, sortedMembers(model), members)
+
+		requireMergeEquivalence(t, rnd, op, existing, operands)
+	}
+}
+
+func TestSortedSetOrder(t *testing.T) {
+	t.Parallel()
+
+	op := NewSortedSet()
+	merged, ok := op.FullMerge(nil, nil, [][]byte{
+		SetAdd([]byte("b"), []byte("a"), []byte("c")),
+		SetRemove([]byte("c")),
+		SetAdd([]byte("a")),
+	})
+	require.True(t, ok)
+
+	members, err := DecodeSet(merged)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("a"), []byte("b")}, members)
+
+	_, ok = op.FullMerge(nil, nil, [][]byte{[]byte("invalid")})
+	require.False(t, ok)
+}
@@ -5,6 +5,7 @@ package grocksdb
 import "C"
 
 import (
+	"sync"
 	"unsafe"
 )
 
@@ -13,6 +14,16 @@ type OptimisticTransactionDB struct {
 	c    *C.rocksdb_optimistictransactiondb_t
 	name string
 	opts *Options
+	// base is the base database, serving the operations made outside of
+	// transactions.
+	base *DB
+	// batchOpts are the options of the reads of the write batches of
+	// transactions.
+	batchOpts *Options
+
+	// commitMu serializes the commits of transactions having ranges read for
+	// update with the other commits and writes of the database.
+	commitMu sync.RWMutex
 }
 
 // OpenOptimisticTransactionDb opens a database with the specified options.
@@ -29,10 +40,12 @@ func OpenOptimisticTransactionDb(
 		opts.c, cName, &cErr)
 	if err = fromCError(cErr); err == nil {
 		tdb = &OptimisticTransactionDB{
-			name: name,
-			c:    db,
-			opts: opts,
+			name:      name,
+			c:         db,
+			opts:      opts,
+			batchOpts: NewDefaultOptions(),
 		}
+		tdb.base = tdb.GetBaseDB()
 	}
 
 	C.free(unsafe.Pointer(cName))
@@ -77,10 +90,12 @@ func OpenOptimisticTransactionDbColumnFamilies(
 	)
 	if err = fromCError(cErr); err == nil {
 		db = &OptimisticTransactionDB{
-			name: name,
-			c:    _db,
-			opts: opts,
+			name:      name,
+			c:         _db,
+			opts:      opts,
+			batchOpts: NewDefaultOptions(),
 		}
+		db.base = db.GetBaseDB()
 		cfHandles = make([]*ColumnFamilyHandle, numColumnFamilies)
 		for i, c := range cHandles {
 			cfHandles[i] = newNativeColumnFamilyHandle(c)
@@ -108,11 +123,19 @@ func (db *OptimisticTransactionDB) TransactionBegin(
 			transactionOpts.c,
 			oldTransaction.c,
 		)
-		return newNativeTransaction(cTx)
+		return db.newTransaction(cTx, transactionOpts.setSnapshot)
 	}
 
 	cTx := C.rocksdb_optimistictransaction_begin(db.c, opts.c, transactionOpts.c, nil)
-	return newNativeTransaction(cTx)
+	return db.newTransaction(cTx, transactionOpts.setSnapshot)
+}
+
+func (db *OptimisticTransactionDB) newTransaction(cTx *C.rocksdb_transaction_t, hasSnapshot bool) *Transaction {
+	txn := newNativeTransaction(cTx)
+	txn.optimisticDB = db
+	txn.hasSnapshot = hasSnapshot
+	txn.commitMu = &db.commitMu
+	return txn
 }
 
 // NewCheckpoint creates a new Checkpoint for this db.
@@ -123,7 +146,7 @@ func (db *OptimisticTransactionDB) NewCheckpoint() (cp *Checkpoint, err error) {
 		db.c, &cErr,
 	)
 	if err = fromCError(cErr); err == nil {
-		cp = newNativeCheckpoint(cCheckpoint)
+		cp = newNativeCheckpoint(cCheckpoint, db.name, db.base)
 	}
 
 	return
@@ -133,6 +156,9 @@ func (db *OptimisticTransactionDB) NewCheckpoint() (cp *Checkpoint, err error) {
 func (db *OptimisticTransactionDB) Write(opts *WriteOptions, batch *WriteBatch) (err error) {
 	var cErr *C.char
 
+	db.commitMu.RLock()
+	defer db.commitMu.RUnlock()
+
 	C.rocksdb_optimistictransactiondb_write(db.c, opts.c, batch.c, &cErr)
 	err = fromCError(cErr)
 
@@ -141,6 +167,8 @@ func (db *OptimisticTransactionDB) Write(opts *WriteOptions, batch *WriteBatch)
 
 // Close closes the database.
 func (db *OptimisticTransactionDB) Close() {
+	db.CloseBaseDB(db.base)
+	db.batchOpts.Destroy()
 	C.rocksdb_optimistictransactiondb_close(db.c)
 	db.c = nil
 }
@@ -159,3 +187,144 @@ func (db *OptimisticTransactionDB) CloseBaseDB(base *DB) {
 	C.rocksdb_optimistictransactiondb_close_base_db(base.c)
 	base.c = nil
 }
+
+// Get returns the data associated with the key from the database.
+func (db *OptimisticTransactionDB) Get(opts *ReadOptions, key []byte) (*Slice, error) {
+	return db.base.Get(opts, key)
+}
+
+// GetCF returns the data associated with the key from the database and column family.
+func (db *OptimisticTransactionDB) GetCF(opts *ReadOptions, cf *ColumnFamilyHandle, key []byte) (*Slice, error) {
+	return db.base.GetCF(opts, cf, key)
+}
+
+// GetPinned returns the data associated with the key from the database.
+func (db *OptimisticTransactionDB) GetPinned(opts *ReadOptions, key []byte) (*PinnableSlice, error) {
+	return db.base.GetPinned(opts, key)
+}
+
+// GetPinnedCF returns the data associated with the key from the database and column family.
+func (db *OptimisticTransactionDB) GetPinnedCF(opts *ReadOptions, cf *ColumnFamilyHandle, key []byte) (*PinnableSlice, error) {
+	return db.base.GetPinnedCF(opts, cf, key)
+}
+
+// MultiGet returns the data associated with the passed keys from the database.
+func (db *OptimisticTransactionDB) MultiGet(opts *ReadOptions, keys ...[]byte) (Slices, error) {
+	return db.base.MultiGet(opts, keys...)
+}
+
+// MultiGetCF returns the data associated with the passed keys from the database and column family.
+func (db *OptimisticTransactionDB) MultiGetCF(opts *ReadOptions, cf *ColumnFamilyHandle, keys ...[]byte) (Slices, error) {
+	return db.base.MultiGetCF(opts, cf, keys...)
+}
+
+// Put writes data associated with a key to the database.
+func (db *OptimisticTransactionDB) Put(opts *WriteOptions, key, value []byte) error {
+	db.commitMu.RLock()
+	defer db.commitMu.RUnlock()
+	return db.base.Put(opts, key, value)
+}
+
+// PutCF writes data associated with a key to the database and column family.
+func (db *OptimisticTransactionDB) PutCF(opts *WriteOptions, cf *ColumnFamilyHandle, key, value []byte) error {
+	db.commitMu.RLock()
+	defer db.commitMu.RUnlock()
+	return db.base.PutCF(opts, cf, key, value)
+}
+
+// Delete removes the data associated with the key from the database.
+func (db *OptimisticTransactionDB) Delete(opts *WriteOptions, key []byte) error {
+	db.commitMu.RLock()
+	defer db.commitMu.RUnlock()
+	return db.base.Delete(opts, key)
+}
+
+// DeleteCF removes the data associated with the key from the database and column family.
+func (db *OptimisticTransactionDB) DeleteCF(opts *WriteOptions, cf *ColumnFamilyHandle, key []byte) error {
+	db.commitMu.RLock()
+	defer db.commitMu.RUnlock()
+	return db.base.DeleteCF(opts, cf, key)
+}
+
+// SingleDelete removes the database entry for "key", as DB.SingleDelete does.
+func (db *OptimisticTransactionDB) SingleDelete(opts *WriteOptions, key []byte) error {
+	db.commitMu.RLock()
+	defer db.commitMu.RUnlock()
+	return db.base.SingleDelete(opts, key)
+}
+
+// SingleDeleteCF removes the database entry for "key" of the column family, as
+// DB.SingleDeleteCF does.
+func (db *OptimisticTransactionDB) SingleDeleteCF(opts *WriteOptions, cf *ColumnFamilyHandle, key []byte) error {
+	db.commitMu.RLock()
+	defer db.commitMu.RUnlock()
+	return db.base.SingleDeleteCF(opts, cf, key)
+}
+
+// DeleteRangeCF deletes keys that are between [startKey, endKey)
+func (db *OptimisticTransactionDB) DeleteRangeCF(opts *WriteOptions, cf *ColumnFamilyHandle, startKey, endKey []byte) error {
+	db.commitMu.RLock()
+	defer db.commitMu.RUnlock()
+	return db.base.DeleteRangeCF(opts, cf, startKey, endKey)
+}
+
+// Merge merges the data associated with the key with the actual data in the database.
+func (db *OptimisticTransactionDB) Merge(opts *WriteOptions, key, value []byte) error {
+	db.commitMu.RLock()
+	defer db.commitMu.RUnlock()
+	return db.base.Merge(opts, key, value)
+}
+
+// MergeCF merges the data associated with the key with the actual data in the
+// database and column family.
+func (db *OptimisticTransactionDB) MergeCF(opts *WriteOptions, cf *ColumnFamilyHandle, key, value []byte) error {
+	db.commitMu.RLock()
+	defer db.commitMu.RUnlock()
+	return db.base.MergeCF(opts, cf, key, value)
+}
+
+// NewIterator returns an Iterator over the the database that uses the
+// ReadOptions given.
+func (db *OptimisticTransactionDB) NewIterator(opts *ReadOptions) *Iterator {
+	return db.base.NewIterator(opts)
+}
+
+// NewIteratorCF returns an Iterator over the the database and column family
+// that uses the ReadOptions given.
+func (db *OptimisticTransactionDB) NewIteratorCF(opts *ReadOptions, cf *ColumnFamilyHandle) *Iterator {
+	return db.base.NewIteratorCF(opts, cf)
+}
+
+// NewSnapshot creates a new snapshot of the database.
+func (db *OptimisticTransactionDB) NewSnapshot() *Snapshot {
+	return db.base.NewSnapshot()
+}
+
+// ReleaseSnapshot releases the snapshot and its resources.
+func (db *OptimisticTransactionDB) ReleaseSnapshot(snapshot *Snapshot) {
+	db.base.ReleaseSnapshot(snapshot)
+}
+
+// GetSnapshots returns the live snapshots created by NewSnapshot, oldest first.
+// Snapshots taken by transactions are not listed.
+func (db *OptimisticTransactionDB) GetSnapshots() []SnapshotInfo {
+	return db.base.GetSnapshots()
+}
+
+// GetApproximateSizes returns the approximate number of bytes of file system
+// space used by one or more key ranges.
+//
+// The keys counted will begin at Range.Start and end on the key before
+// Range.Limit.
+func (db *OptimisticTransactionDB) GetApproximateSizes(ranges []Range) ([]uint64, error) {
+	return db.base.GetApproximateSizes(ranges)
+}
+
+// GetApproximateSizesCF returns the approximate number of bytes of file system
+// space used by one or more key ranges in the column family.
+//
+// The keys counted will begin at Range.Start and end on the key before
+// Range.Limit.
+func (db *OptimisticTransactionDB) GetApproximateSizesCF(cf *ColumnFamilyHandle, ranges []Range) ([]uint64, error) {
+	return db.base.GetApproximateSizesCF(cf, ranges)
+}
@@ -111,6 +111,9 @@ type Options struct {
 	cmo  *C.rocksdb_mergeoperator_t
 	cst  *C.rocksdb_slicetransform_t
 	ccf  *C.rocksdb_compactionfilter_t
+
+	// walDir is the directory of the WAL files, if set.
+	walDir string
 }
 
 // NewDefaultOptions creates the default Options.
@@ -1094,6 +1097,7 @@ func (opts *Options) SetDbLogDir(value string) {
 // When destroying the db, all log files and the dir itopts is deleted.
 // Default: empty
 func (opts *Options) SetWalDir(value string) {
+	opts.walDir = value
 	cvalue := C.CString(value)
 	C.rocksdb_options_set_wal_dir(opts.c, cvalue)
 	C.free(unsafe.Pointer(cvalue))
@@ -64,7 +64,8 @@ const (
 
 // BackupEngineOptions represents options for backup engine.
 type BackupEngineOptions struct {
-	c *C.rocksdb_backup_engine_options_t
+	c   *C.rocksdb_backup_engine_options_t
+	dir string
 }
 
 // NewBackupableDBOptions
@@ -72,7 +73,7 @@ func NewBackupableDBOptions(backupDir string) *BackupEngineOptions {
 	cDir := C.CString(backupDir)
 	op := C.rocksdb_backup_engine_options_create(cDir)
 	C.free(unsafe.Pointer(cDir))
-	return &BackupEngineOptions{c: op}
+	return &BackupEngineOptions{c: op, dir: backupDir}
 }
 
 // SetBackupDir sets where to keep the backup files. Has to be different than dbname_
@@ -81,6 +82,7 @@ func (b *BackupEngineOptions) SetBackupDir(dir string) {
 	cDir := C.CString(dir)
 	C.rocksdb_backup_engine_options_set_backup_dir(b.c, cDir)
 	C.free(unsafe.Pointer(cDir))
+	b.dir = dir
 }
 
 // SetEnv to be used for backup file I/O. If it's
@@ -29,10 +29,13 @@ const (
 // database.
 type ReadOptions struct {
 	c              *C.rocksdb_readoptions_t
+	snapshot       *Snapshot
 	iterUpperBound []byte
 	iterLowerBound []byte
 	timestamp      []byte
 	timestampStart []byte
+	// autoReadaheadSize is kept as the C API can't read it back.
+	autoReadaheadSize bool
 }
 
 // NewDefaultReadOptions creates a default ReadOptions object.
@@ -77,10 +80,15 @@ func (opts *ReadOptions) FillCache() bool {
 
 // SetSnapshot sets the snapshot which should be used for the read.
 // The snapshot must belong to the DB that is being read and must
-// not have been released.
+// not have been released. A nil snapshot reads the latest state.
 //
 // Default: nil
 func (opts *ReadOptions) SetSnapshot(snap *Snapshot) {
+	opts.snapshot = snap
+	if snap == nil {
+		C.rocksdb_readoptions_set_snapshot(opts.c, nil)
+		return
+	}
 	C.rocksdb_readoptions_set_snapshot(opts.c, snap.c)
 }
 
@@ -318,6 +326,45 @@ func (opts *ReadOptions) GetIOTimeout() uint64 {
 	return uint64(C.rocksdb_readoptions_get_io_timeout(opts.c))
 }
 
+// clone returns a copy of the options, which can be changed while the options
+// are used concurrently. The settings the C API can't read back are kept by
+// the Go options.
+func (opts *ReadOptions) clone() *ReadOptions {
+	cloned := NewDefaultReadOptions()
+	cloned.SetVerifyChecksums(opts.VerifyChecksums())
+	cloned.SetFillCache(opts.FillCache())
+	cloned.SetReadTier(opts.GetReadTier())
+	cloned.SetTailing(opts.Tailing())
+	cloned.SetReadaheadSize(opts.GetReadaheadSize())
+	cloned.SetPrefixSameAsStart(opts.PrefixSameAsStart())
+	cloned.SetPinData(opts.PinData())
+	cloned.SetTotalOrderSeek(opts.GetTotalOrderSeek())
+	cloned.SetMaxSkippableInternalKeys(opts.GetMaxSkippableInternalKeys())
+	cloned.SetBackgroundPurgeOnIteratorCleanup(opts.GetBackgroundPurgeOnIteratorCleanup())
+	cloned.SetIgnoreRangeDeletions(opts.IgnoreRangeDeletions())
+	cloned.SetDeadline(opts.GetDeadline())
+	cloned.SetIOTimeout(opts.GetIOTimeout())
+	cloned.SetAsyncIO(opts.IsAsyncIO())
+	cloned.SetAutoReadaheadSize(opts.autoReadaheadSize)
+
+	if opts.snapshot != nil {
+		cloned.SetSnapshot(opts.snapshot)
+	}
+	if opts.iterUpperBound != nil {
+		cloned.SetIterateUpperBound(opts.iterUpperBound)
+	}
+	if opts.iterLowerBound != nil {
+		cloned.SetIterateLowerBound(opts.iterLowerBound)
+	}
+	if opts.timestamp != nil {
+		cloned.SetTimestamp(opts.timestamp)
+	}
+	if opts.timestampStart != nil {
+		cloned.SetIterStartTimestamp(opts.timestampStart)
+	}
+	return cloned
+}
+
 // Destroy deallocates the ReadOptions object.
 func (opts *ReadOptions) Destroy() {
 	C.rocksdb_readoptions_destroy(opts.c)
@@ -363,5 +410,6 @@ func (opts *ReadOptions) SetIterStartTimestamp(ts []byte) {
 //
 // Default: false
 func (opts *ReadOptions) SetAutoReadaheadSize(enable bool) {
+	opts.autoReadaheadSize = enable
 	C.rocksdb_readoptions_set_auto_readahead_size(opts.c, boolToChar(enable))
 }
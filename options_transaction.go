@@ -7,6 +7,9 @@ import "C"
 // a transaction on the database.
 type TransactionOptions struct {
 	c *C.rocksdb_transaction_options_t
+	// setSnapshot is whether transactions begun with the options have a
+	// snapshot.
+	setSnapshot bool
 }
 
 // NewDefaultTransactionOptions creates a default TransactionOptions object.
@@ -22,6 +25,7 @@ func newNativeTransactionOptions(c *C.rocksdb_transaction_options_t) *Transactio
 // SetSetSnapshot to true is the same as calling
 // Transaction::SetSnapshot().
 func (opts *TransactionOptions) SetSetSnapshot(value bool) {
+	opts.setSnapshot = value
 	C.rocksdb_transaction_options_set_set_snapshot(opts.c, boolToChar(value))
 }
 
@@ -74,6 +78,9 @@ func (opts *TransactionOptions) Destroy() {
 // a optimistic transaction on the database.
 type OptimisticTransactionOptions struct {
 	c *C.rocksdb_optimistictransaction_options_t
+	// setSnapshot is whether transactions begun with the options have a
+	// snapshot.
+	setSnapshot bool
 }
 
 // NewDefaultOptimisticTransactionOptions creates a default TransactionOptions object.
@@ -89,6 +96,7 @@ func newNativeOptimisticTransactionOptions(c *C.rocksdb_optimistictransaction_op
 // SetSetSnapshot to true is the same as calling
 // Transaction::SetSnapshot().
 func (opts *OptimisticTransactionOptions) SetSetSnapshot(value bool) {
+	opts.setSnapshot = value
 	C.rocksdb_optimistictransaction_options_set_set_snapshot(opts.c, boolToChar(value))
 }
 
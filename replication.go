@@ -0,0 +1,710 @@
+package grocksdb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrReplicationDiverged is returned by followers whose database is not at
+// the sequence number of the batches of the primary, such as when it is
+// written otherwise than by replication.
+var ErrReplicationDiverged = fmt.Errorf("Corruption: Follower diverged from the primary")
+
+const (
+	defaultReplicationPollInterval = 100 * time.Millisecond
+	replicationChunkSize           = 1 << 20
+)
+
+// ReplicationStatus is the status of a follower of a ReplicationPrimary.
+type ReplicationStatus struct {
+	// Name is the name of the follower.
+	Name string
+	// Applied is the latest sequence number the follower acknowledged.
+	Applied uint64
+	// Lag is the number of sequence numbers the follower is behind the
+	// primary.
+	Lag uint64
+}
+
+// ReplicationPrimary ships the batches written to the WAL of a database to
+// ReplicationFollowers, over Transports. Followers without data, or too far
+// behind for the WAL, are bootstrapped from a checkpoint of the database.
+//
+// The WAL must be kept long enough for the followers, see GetUpdatesSince.
+// Writes without WAL, column families created after the followers are
+// bootstrapped, transactions and values written to blob files are not
+// replicated.
+type ReplicationPrimary struct {
+	db *DB
+
+	mu           sync.Mutex
+	followers    []*ReplicationStatus
+	pollInterval time.Duration
+	stagingDir   string
+}
+
+// NewReplicationPrimary returns a primary replicating the database.
+func NewReplicationPrimary(db *DB) *ReplicationPrimary {
+	return &ReplicationPrimary{
+		db:           db,
+		pollInterval: defaultReplicationPollInterval,
+	}
+}
+
+// SetPollInterval sets how long the primary waits for new batches once it
+// has sent all of them to a follower.
+//
+// Default: 100ms
+func (p *ReplicationPrimary) SetPollInterval(interval time.Duration) {
+	p.mu.Lock()
+	p.pollInterval = interval
+	p.mu.Unlock()
+}
+
+// SetStagingDir sets the directory in which the checkpoints bootstrapping the
+// followers are staged, which should be on the file system of the database.
+//
+// Default: the directory of the database
+func (p *ReplicationPrimary) SetStagingDir(dir string) {
+	p.mu.Lock()
+	p.stagingDir = dir
+	p.mu.Unlock()
+}
+
+// Followers returns the status of the followers being served.
+func (p *ReplicationPrimary) Followers() []ReplicationStatus {
+	latest := p.db.GetLatestSequenceNumber()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	followers := make([]ReplicationStatus, len(p.followers))
+	for i, status := range p.followers {
+		followers[i] = *status
+		if latest > status.Applied {
+			followers[i].Lag = latest - status.Applied
+		}
+	}
+	return followers
+}
+
+// Serve replicates the database to the follower at the other end of the
+// transport, until ctx is done or the transport fails. The transport is
+// closed once Serve returns.
+func (p *ReplicationPrimary) Serve(ctx context.Context, t Transport) error {
+	status := &ReplicationStatus{}
+	p.mu.Lock()
+	p.followers = append(p.followers, status)
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		for i, s := range p.followers {
+			if s == status {
+				p.followers = append(p.followers[:i], p.followers[i+1:]...)
+				break
+			}
+		}
+		p.mu.Unlock()
+	}()
+
+	return runTransport(ctx, t, func(ctx context.Context) error {
+		return p.serve(ctx, t, status)
+	})
+}
+
+func (p *ReplicationPrimary) serve(ctx context.Context, t Transport, status *ReplicationStatus) error {
+	subscribes := make(chan uint64)
+	errs := make(chan error, 1)
+
+	go func() {
+		for {
+			msg, err := t.Receive()
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			switch msg.Type {
+			case ReplicationSubscribe:
+				p.mu.Lock()
+				status.Name = msg.Name
+				p.mu.Unlock()
+
+				select {
+				case subscribes <- msg.Sequence:
+				case <-ctx.Done():
+					return
+				}
+			case ReplicationAck:
+				p.mu.Lock()
+				status.Applied = msg.Sequence
+				p.mu.Unlock()
+			}
+		}
+	}()
+
+	for {
+		var next uint64
+		select {
+		case next = <-subscribes:
+		case err := <-errs:
+			return err
+		}
+
+		if next > 0 {
+			if err := p.stream(ctx, t, next, errs); err != ErrWALGap {
+				return err
+			}
+		}
+
+		if err := p.bootstrap(t); err != nil {
+			return err
+		}
+	}
+}
+
+// stream sends the batches from the sequence number next to the follower.
+func (p *ReplicationPrimary) stream(ctx context.Context, t Transport, next uint64, errs <-chan error) error {
+	tail := walTail{db: p.db}
+	defer tail.close()
+
+	for {
+		err := tail.poll(func() uint64 { return next }, func(batch *WriteBatch, seq uint64) error {
+			if seq < next {
+				// already sent
+				if seq+uint64(batch.Count()) <= next {
+					return nil
+				}
+				return ErrWALGap
+			}
+			if seq > next {
+				return ErrWALGap
+			}
+
+			data := append([]byte(nil), batch.Data()...)
+			if err := t.Send(&ReplicationMessage{Type: ReplicationBatch, Sequence: seq, Data: data}); err != nil {
+				return err
+			}
+			next = seq + uint64(batch.Count())
+			return nil
+		})
+		if err == nil {
+			err = t.Send(&ReplicationMessage{Type: ReplicationHeartbeat, Sequence: p.db.GetLatestSequenceNumber()})
+		}
+		if err != nil {
+			return err
+		}
+
+		p.mu.Lock()
+		interval := p.pollInterval
+		p.mu.Unlock()
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case err = <-errs:
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// bootstrap sends a checkpoint of the database to the follower.
+func (p *ReplicationPrimary) bootstrap(t Transport) (err error) {
+	p.mu.Lock()
+	stagingDir := p.stagingDir
+	p.mu.Unlock()
+
+	tmp, err := checkpointStagingDir(p.db.Name(), stagingDir)
+	if err != nil {
+		return
+	}
+	defer os.RemoveAll(tmp)
+
+	checkpoint, err := p.db.NewCheckpoint()
+	if err != nil {
+		return
+	}
+	defer checkpoint.Destroy()
+
+	dir := filepath.Join(tmp, "checkpoint")
+	if err = checkpoint.CreateCheckpoint(dir, 0); err != nil {
+		return
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	if err = t.Send(&ReplicationMessage{Type: ReplicationCheckpointBegin}); err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if err = sendCheckpointFile(t, dir, entry.Name()); err != nil {
+			return
+		}
+	}
+	return t.Send(&ReplicationMessage{Type: ReplicationCheckpointEnd})
+}
+
+func sendCheckpointFile(t Transport, dir, name string) (err error) {
+	f, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	for first := true; ; first = false {
+		chunk := make([]byte, replicationChunkSize)
+		n, err := io.ReadFull(f, chunk)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+
+		// empty files are sent as an empty chunk
+		if n > 0 || first {
+			msg := &ReplicationMessage{Type: ReplicationCheckpointFile, Name: name, Data: chunk[:n]}
+			if serr := t.Send(msg); serr != nil {
+				return serr
+			}
+		}
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+// ReplicationFollower is a replica of the database of a ReplicationPrimary,
+// stored in a directory. It is bootstrapped from a checkpoint of the
+// primary, then applies the batches written to the primary in order, each
+// batch getting the sequence numbers it has on the primary.
+//
+// The database of the follower is read by acquiring it with Acquire, while
+// the follower runs, but must not be written. Each bootstrap receives the
+// checkpoint in a new sub directory of the directory of the follower, whose
+// database replaces the previous one once opened: the previous database is
+// closed and removed once released by its readers.
+type ReplicationFollower struct {
+	dir    string
+	opts   *Options
+	cfOpts map[string]*Options
+	wo     *WriteOptions
+
+	mu         sync.Mutex
+	name       string
+	replica    *Replica
+	generation uint64
+	applied    uint64
+	primary    uint64
+}
+
+// Replica is a database of a ReplicationFollower, acquired with Acquire. It
+// remains open until released with Release, even once the follower is
+// bootstrapped again.
+type Replica struct {
+	db      *DB
+	dir     string
+	cfNames []string
+	cfs     []*ColumnFamilyHandle
+	// refs counts the follower, while the replica is its database, and the
+	// acquisitions which are not released.
+	refs int
+	// obsolete is whether the directory of the replica is removed once the
+	// replica is closed.
+	obsolete bool
+}
+
+// DB returns the database of the replica.
+func (r *Replica) DB() *DB {
+	return r.db
+}
+
+// ColumnFamily returns the handle of the column family of the database of
+// the replica, nil if not found.
+func (r *Replica) ColumnFamily(name string) *ColumnFamilyHandle {
+	for i, cfName := range r.cfNames {
+		if cfName == name {
+			return r.cfs[i]
+		}
+	}
+	return nil
+}
+
+const replicaDirPrefix = "replica-"
+
+// NewReplicationFollower returns a follower stored in the directory. The
+// database is opened with opts, and its column families with the options
+// set with SetColumnFamilyOptions, opts otherwise. The options must be
+// compatible with the options of the primary, such as its comparators and
+// its merge operators.
+func NewReplicationFollower(dir string, opts *Options) *ReplicationFollower {
+	return &ReplicationFollower{
+		dir:    dir,
+		opts:   opts,
+		cfOpts: make(map[string]*Options),
+		wo:     NewDefaultWriteOptions(),
+	}
+}
+
+// SetColumnFamilyOptions sets the options the column family is opened with.
+// It must be called before Run.
+func (f *ReplicationFollower) SetColumnFamilyOptions(name string, opts *Options) {
+	f.cfOpts[name] = opts
+}
+
+// SetName sets the name of the follower, reported by the primary.
+func (f *ReplicationFollower) SetName(name string) {
+	f.mu.Lock()
+	f.name = name
+	f.mu.Unlock()
+}
+
+// Acquire returns the database of the follower, nil until bootstrapped. It
+// must be released with Release.
+func (f *ReplicationFollower) Acquire() *Replica {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.replica != nil {
+		f.replica.refs++
+	}
+	return f.replica
+}
+
+// Release releases the database acquired with Acquire, closing it if the
+// follower no longer uses it.
+func (f *ReplicationFollower) Release(r *Replica) {
+	if r == nil {
+		return
+	}
+	f.mu.Lock()
+	f.unref(r)
+	f.mu.Unlock()
+}
+
+// Applied returns the latest sequence number applied by the follower.
+func (f *ReplicationFollower) Applied() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.applied
+}
+
+// Lag returns the number of sequence numbers the follower is behind the
+// primary, as of the latest batch or heartbeat of the primary.
+func (f *ReplicationFollower) Lag() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.primary > f.applied {
+		return f.primary - f.applied
+	}
+	return 0
+}
+
+// Run replicates the primary at the other end of the transport, until ctx
+// is done or the replication fails. The transport is closed once Run
+// returns.
+func (f *ReplicationFollower) Run(ctx context.Context, t Transport) error {
+	return runTransport(ctx, t, func(ctx context.Context) error {
+		return f.run(t)
+	})
+}
+
+func (f *ReplicationFollower) run(t Transport) (err error) {
+	f.mu.Lock()
+	if f.replica == nil {
+		err = f.openLatest()
+	}
+	subscribe := &ReplicationMessage{Type: ReplicationSubscribe, Name: f.name}
+	if f.replica != nil {
+		subscribe.Sequence = f.applied + 1
+	}
+	f.mu.Unlock()
+
+	if err != nil {
+		return
+	}
+	if err = t.Send(subscribe); err != nil {
+		return
+	}
+
+	// directory and file of the checkpoint being received
+	var (
+		bootDir string
+		file    *os.File
+	)
+	defer func() {
+		if file != nil {
+			_ = file.Close()
+		}
+		if bootDir != "" {
+			_ = os.RemoveAll(bootDir)
+		}
+	}()
+
+	for {
+		var msg *ReplicationMessage
+		if msg, err = t.Receive(); err != nil {
+			return
+		}
+
+		switch msg.Type {
+		case ReplicationCheckpointBegin:
+			if file != nil {
+				_ = file.Close()
+				file = nil
+			}
+			f.mu.Lock()
+			f.generation++
+			bootDir = filepath.Join(f.dir, replicaDirPrefix+strconv.FormatUint(f.generation, 10)+".tmp")
+			f.mu.Unlock()
+
+			if err = os.RemoveAll(bootDir); err == nil {
+				err = os.MkdirAll(bootDir, 0o755)
+			}
+
+		case ReplicationCheckpointFile:
+			if bootDir == "" {
+				return fmt.Errorf("Corruption: Checkpoint file out of a checkpoint")
+			}
+			name := filepath.Join(bootDir, filepath.Base(msg.Name))
+			if file != nil && file.Name() != name {
+				err = syncAndClose(file)
+				file = nil
+			}
+			if err == nil && file == nil {
+				file, err = os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+			}
+			if err == nil {
+				_, err = file.Write(msg.Data)
+			}
+
+		case ReplicationCheckpointEnd:
+			if bootDir == "" {
+				return fmt.Errorf("Corruption: Checkpoint end out of a checkpoint")
+			}
+			if file != nil {
+				err = syncAndClose(file)
+				file = nil
+			}
+			// the checkpoint is complete once renamed
+			dir := strings.TrimSuffix(bootDir, ".tmp")
+			if err == nil {
+				err = os.Rename(bootDir, dir)
+			}
+			if err == nil {
+				bootDir = ""
+				err = f.swap(dir)
+			}
+			if err == nil {
+				f.mu.Lock()
+				subscribe.Sequence = f.applied + 1
+				f.mu.Unlock()
+				err = t.Send(subscribe)
+			}
+
+		case ReplicationBatch:
+			err = f.apply(msg.Sequence, msg.Data)
+
+		case ReplicationHeartbeat:
+			f.mu.Lock()
+			if msg.Sequence > f.primary {
+				f.primary = msg.Sequence
+			}
+			ack := &ReplicationMessage{Type: ReplicationAck, Sequence: f.applied}
+			f.mu.Unlock()
+
+			err = t.Send(ack)
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// apply writes the batch, whose first change has the sequence number seq on
+// the primary.
+func (f *ReplicationFollower) apply(seq uint64, data []byte) (err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// the batch gets the sequence numbers it has on the primary as long as
+	// the database is only written by the replication
+	if f.replica == nil || seq != f.replica.db.GetLatestSequenceNumber()+1 {
+		return ErrReplicationDiverged
+	}
+
+	batch := WriteBatchFrom(data)
+	err = f.replica.db.Write(f.wo, batch)
+	batch.Destroy()
+	if err != nil {
+		return
+	}
+
+	f.applied = f.replica.db.GetLatestSequenceNumber()
+	if f.applied > f.primary {
+		f.primary = f.applied
+	}
+	return
+}
+
+// openLatest opens the database of the latest bootstrap, if any, removing
+// the directories of the previous bootstraps and of the incomplete ones.
+func (f *ReplicationFollower) openLatest() error {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var (
+		latest    string
+		latestGen uint64
+	)
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, replicaDirPrefix) {
+			continue
+		}
+		generation, perr := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, replicaDirPrefix), ".tmp"), 10, 64)
+		if perr != nil {
+			continue
+		}
+		if generation > f.generation {
+			f.generation = generation
+		}
+		if !strings.HasSuffix(name, ".tmp") && (latest == "" || generation > latestGen) {
+			latest, latestGen = name, generation
+		}
+	}
+
+	for _, entry := range entries {
+		if name := entry.Name(); strings.HasPrefix(name, replicaDirPrefix) && name != latest {
+			if err = os.RemoveAll(filepath.Join(f.dir, name)); err != nil {
+				return err
+			}
+		}
+	}
+	if latest == "" {
+		return nil
+	}
+
+	r, err := f.open(filepath.Join(f.dir, latest))
+	if err != nil {
+		return err
+	}
+	f.replica = r
+	f.applied = r.db.GetLatestSequenceNumber()
+	return nil
+}
+
+// swap opens the database of the bootstrap in the directory, replacing the
+// database of the follower, which is closed and removed once released.
+func (f *ReplicationFollower) swap(dir string) error {
+	r, err := f.open(dir)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	previous := f.replica
+	f.replica = r
+	f.applied = r.db.GetLatestSequenceNumber()
+	if previous != nil {
+		previous.obsolete = true
+		f.unref(previous)
+	}
+	return nil
+}
+
+// open opens the database of the directory, with all its column families.
+func (f *ReplicationFollower) open(dir string) (r *Replica, err error) {
+	names, err := ListColumnFamilies(f.opts, dir)
+	if err != nil {
+		return
+	}
+
+	cfOpts := make([]*Options, len(names))
+	for i, name := range names {
+		if cfOpts[i] = f.cfOpts[name]; cfOpts[i] == nil {
+			cfOpts[i] = f.opts
+		}
+	}
+
+	db, cfs, err := OpenDbColumnFamilies(f.opts, dir, names, cfOpts)
+	if err != nil {
+		return
+	}
+	return &Replica{db: db, dir: dir, cfNames: names, cfs: cfs, refs: 1}, nil
+}
+
+// unref drops a reference to the replica, closing it once unreferenced. It
+// must be called with the lock held.
+func (f *ReplicationFollower) unref(r *Replica) {
+	if r.refs--; r.refs > 0 {
+		return
+	}
+
+	for _, cf := range r.cfs {
+		cf.Destroy()
+	}
+	r.db.Close()
+	if r.obsolete {
+		_ = os.RemoveAll(r.dir)
+	}
+}
+
+// Close closes the database of the follower, once it no longer runs. The
+// database is closed once released by its readers.
+func (f *ReplicationFollower) Close() {
+	f.mu.Lock()
+	if f.replica != nil {
+		f.unref(f.replica)
+		f.replica = nil
+	}
+	f.mu.Unlock()
+	f.wo.Destroy()
+}
+
+// runTransport runs fn until ctx is done, closing the transport once ctx is
+// done or fn returns.
+func runTransport(ctx context.Context, t Transport, fn func(ctx context.Context) error) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		<-runCtx.Done()
+		_ = t.Close()
+	}()
+
+	err := fn(runCtx)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func syncAndClose(f *os.File) (err error) {
+	if err = f.Sync(); err == nil {
+		return f.Close()
+	}
+	_ = f.Close()
+	return
+}
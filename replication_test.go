@@ -0,0 +1,191 @@
+package grocksdb
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplication(t *testing.T) {
+	t.Parallel()
+
+	db := newTestDB(t, func(opts *Options) {
+		opts.SetWALTtlSeconds(3600)
+	})
+	defer db.Close()
+
+	opts := NewDefaultOptions()
+	defer opts.Destroy()
+	cfOpts := NewDefaultOptions()
+	defer cfOpts.Destroy()
+	cfOpts.SetMergeOperator(&mockMergeOperator{
+		fullMerge: func(_, existingValue []byte, operands [][]byte) ([]byte, bool) {
+			return append(existingValue, operands[len(operands)-1]...), true
+		},
+	})
+	cf, err := db.CreateColumnFamily(cfOpts, "other")
+	require.Nil(t, err)
+	defer cf.Destroy()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	require.Nil(t, db.Put(wo, []byte("a"), []byte("1")))
+	require.Nil(t, db.PutCF(wo, cf, []byte("b"), []byte("2")))
+
+	primary := NewReplicationPrimary(db)
+	primary.SetPollInterval(10 * time.Millisecond)
+	stagingDir := t.TempDir()
+	primary.SetStagingDir(stagingDir)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer ln.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() { _ = primary.Serve(ctx, NewConnTransport(conn)) }()
+		}
+	}()
+
+	dir := filepath.Join(t.TempDir(), "follower")
+	follower := NewReplicationFollower(dir, opts)
+	follower.SetName("follower")
+	follower.SetColumnFamilyOptions("other", cfOpts)
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	require.Nil(t, err)
+	runCtx, stop := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- follower.Run(runCtx, NewConnTransport(conn)) }()
+
+	caughtUp := func(f *ReplicationFollower) func() bool {
+		return func() bool {
+			return f.Applied() == db.GetLatestSequenceNumber() && f.Lag() == 0
+		}
+	}
+	require.Eventually(t, caughtUp(follower), 10*time.Second, 10*time.Millisecond)
+
+	// bootstrapped from a checkpoint
+	replica := follower.Acquire()
+	require.NotNil(t, replica)
+	fdb := replica.DB()
+	fcf := replica.ColumnFamily("other")
+	require.NotNil(t, fcf)
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+	expectValue := func(cf *ColumnFamilyHandle, key, value string) {
+		v, err := fdb.GetCF(ro, cf, []byte(key))
+		require.Nil(t, err)
+		require.Equal(t, value, string(v.Data()))
+		v.Free()
+	}
+	expectValue(fdb.GetDefaultColumnFamily(), "a", "1")
+	expectValue(fcf, "b", "2")
+
+	// staged in the staging directory
+	entries, err := os.ReadDir(stagingDir)
+	require.Nil(t, err)
+	require.Empty(t, entries)
+
+	// then replicated from the WAL, with the sequence numbers of the primary
+	batch := NewWriteBatch()
+	batch.Put([]byte("c"), []byte("3"))
+	batch.PutCF(cf, []byte("b"), []byte("4"))
+	batch.Delete([]byte("a"))
+	batch.MergeCF(cf, []byte("m"), []byte("x"))
+	require.Nil(t, db.Write(wo, batch))
+	batch.Destroy()
+
+	require.Eventually(t, caughtUp(follower), 10*time.Second, 10*time.Millisecond)
+	require.Equal(t, db.GetLatestSequenceNumber(), fdb.GetLatestSequenceNumber())
+	expectValue(fdb.GetDefaultColumnFamily(), "a", "")
+	expectValue(fdb.GetDefaultColumnFamily(), "c", "3")
+	expectValue(fcf, "b", "4")
+	// read with the options of the column family
+	expectValue(fcf, "m", "x")
+
+	require.Eventually(t, func() bool {
+		followers := primary.Followers()
+		return len(followers) == 1 && followers[0].Name == "follower" &&
+			followers[0].Applied == db.GetLatestSequenceNumber() && followers[0].Lag == 0
+	}, 10*time.Second, 10*time.Millisecond)
+
+	stop()
+	require.ErrorIs(t, <-done, context.Canceled)
+	follower.Close()
+
+	// the database acquired remains open until released
+	expectValue(fcf, "b", "4")
+	follower.Release(replica)
+
+	// resumed from the sequence number applied
+	require.Nil(t, db.Put(wo, []byte("d"), []byte("5")))
+
+	follower = NewReplicationFollower(dir, opts)
+	follower.SetColumnFamilyOptions("other", cfOpts)
+	defer follower.Close()
+	pt, ft := NewPipeTransport()
+	go func() { _ = primary.Serve(ctx, pt) }()
+	go func() { _ = follower.Run(ctx, ft) }()
+
+	require.Eventually(t, caughtUp(follower), 10*time.Second, 10*time.Millisecond)
+	replica = follower.Acquire()
+	fdb = replica.DB()
+	expectValue(fdb.GetDefaultColumnFamily(), "d", "5")
+	require.Equal(t, db.GetLatestSequenceNumber(), fdb.GetLatestSequenceNumber())
+
+	// bootstrapped again, the previous database being kept until released
+	checkpoint, err := db.NewCheckpoint()
+	require.Nil(t, err)
+	bootDir := filepath.Join(dir, "replica-9")
+	require.Nil(t, checkpoint.CreateCheckpoint(bootDir, 0))
+	checkpoint.Destroy()
+	require.Nil(t, follower.swap(bootDir))
+	expectValue(fdb.GetDefaultColumnFamily(), "d", "5")
+	previous := replica.dir
+	follower.Release(replica)
+	_, err = os.Stat(previous)
+	require.True(t, os.IsNotExist(err))
+	replica = follower.Acquire()
+	require.Equal(t, bootDir, replica.dir)
+	follower.Release(replica)
+}
+
+func TestConnTransport(t *testing.T) {
+	t.Parallel()
+
+	c1, c2 := net.Pipe()
+	t1, t2 := NewConnTransport(c1), NewConnTransport(c2)
+
+	sent := []*ReplicationMessage{
+		{Type: ReplicationSubscribe, Sequence: 42, Name: "follower", Data: []byte{}},
+		{Type: ReplicationCheckpointFile, Name: "000001.sst", Data: []byte("data")},
+	}
+	go func() {
+		for _, msg := range sent {
+			require.Nil(t, t1.Send(msg))
+		}
+		require.Nil(t, t1.Close())
+	}()
+
+	for _, msg := range sent {
+		received, err := t2.Receive()
+		require.Nil(t, err)
+		require.Equal(t, msg, received)
+	}
+	_, err := t2.Receive()
+	require.ErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,207 @@
+package grocksdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// ErrTransportClosed is returned by the transports of NewPipeTransport once
+// closed.
+var ErrTransportClosed = fmt.Errorf("IOError: Transport closed")
+
+// maxReplicationMessageSize bounds the names and the data of the messages
+// received by the transports of NewConnTransport.
+const maxReplicationMessageSize = 1 << 30
+
+// ReplicationMessageType is the type of a ReplicationMessage.
+type ReplicationMessageType uint8
+
+const (
+	// ReplicationSubscribe is sent by followers to receive the batches from
+	// Sequence, or a checkpoint first when Sequence is 0. Name is the name
+	// of the follower.
+	ReplicationSubscribe ReplicationMessageType = iota + 1
+	// ReplicationCheckpointBegin starts the checkpoint of the primary
+	// bootstrapping a follower.
+	ReplicationCheckpointBegin
+	// ReplicationCheckpointFile is a chunk, Data, of the file Name of the
+	// checkpoint.
+	ReplicationCheckpointFile
+	// ReplicationCheckpointEnd ends the checkpoint.
+	ReplicationCheckpointEnd
+	// ReplicationBatch is a serialized write batch, Data, whose first change
+	// has the sequence number Sequence on the primary.
+	ReplicationBatch
+	// ReplicationHeartbeat is sent by the primary once it has sent all the
+	// batches written so far. Sequence is its latest sequence number.
+	ReplicationHeartbeat
+	// ReplicationAck is sent by followers in response to heartbeats.
+	// Sequence is the latest sequence number they applied.
+	ReplicationAck
+)
+
+// ReplicationMessage is a message between a primary and a follower.
+type ReplicationMessage struct {
+	Type     ReplicationMessageType
+	Sequence uint64
+	Name     string
+	Data     []byte
+}
+
+// Transport carries the messages between a ReplicationPrimary and a
+// ReplicationFollower. Send and Receive are called concurrently with each
+// other, but not with themselves. Close unblocks them.
+type Transport interface {
+	// Send sends the message to the peer, which owns it once sent.
+	Send(msg *ReplicationMessage) error
+	// Receive returns the next message of the peer.
+	Receive() (*ReplicationMessage, error)
+	// Close closes the transport.
+	Close() error
+}
+
+// pipeTransport is a transport of NewPipeTransport.
+type pipeTransport struct {
+	send   chan<- *ReplicationMessage
+	recv   <-chan *ReplicationMessage
+	closed chan struct{}
+	once   *sync.Once
+}
+
+// NewPipeTransport returns two transports connected to each other in
+// process, such as for a primary and a follower in the same process.
+// Closing either transport closes both of them.
+func NewPipeTransport() (Transport, Transport) {
+	a, b := make(chan *ReplicationMessage, 64), make(chan *ReplicationMessage, 64)
+	closed, once := make(chan struct{}), new(sync.Once)
+	return &pipeTransport{send: a, recv: b, closed: closed, once: once},
+		&pipeTransport{send: b, recv: a, closed: closed, once: once}
+}
+
+func (t *pipeTransport) Send(msg *ReplicationMessage) error {
+	select {
+	case <-t.closed:
+		return ErrTransportClosed
+	default:
+	}
+
+	select {
+	case t.send <- msg:
+		return nil
+	case <-t.closed:
+		return ErrTransportClosed
+	}
+}
+
+func (t *pipeTransport) Receive() (*ReplicationMessage, error) {
+	select {
+	case msg := <-t.recv:
+		return msg, nil
+	case <-t.closed:
+		return nil, ErrTransportClosed
+	}
+}
+
+func (t *pipeTransport) Close() error {
+	t.once.Do(func() { close(t.closed) })
+	return nil
+}
+
+// connTransport is a transport of NewConnTransport.
+type connTransport struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+// NewConnTransport returns a transport over the connection, such as a TCP
+// connection between a primary and a follower. Closing the transport closes
+// the connection.
+//
+// Each message is written as its type, followed by its sequence number, the
+// length of its name, its name, the length of its data and its data, the
+// numbers being varints.
+func NewConnTransport(conn net.Conn) Transport {
+	return &connTransport{
+		conn: conn,
+		r:    bufio.NewReader(conn),
+		w:    bufio.NewWriter(conn),
+	}
+}
+
+func (t *connTransport) Send(msg *ReplicationMessage) (err error) {
+	var buf [1 + 3*binary.MaxVarintLen64]byte
+	buf[0] = byte(msg.Type)
+	n := 1
+	n += binary.PutUvarint(buf[n:], msg.Sequence)
+	n += binary.PutUvarint(buf[n:], uint64(len(msg.Name)))
+
+	if _, err = t.w.Write(buf[:n]); err != nil {
+		return
+	}
+	if _, err = t.w.WriteString(msg.Name); err != nil {
+		return
+	}
+	n = binary.PutUvarint(buf[:], uint64(len(msg.Data)))
+	if _, err = t.w.Write(buf[:n]); err != nil {
+		return
+	}
+	if _, err = t.w.Write(msg.Data); err != nil {
+		return
+	}
+	return t.w.Flush()
+}
+
+func (t *connTransport) Receive() (msg *ReplicationMessage, err error) {
+	typ, err := t.r.ReadByte()
+	if err != nil {
+		return
+	}
+
+	msg = &ReplicationMessage{Type: ReplicationMessageType(typ)}
+	if msg.Sequence, err = binary.ReadUvarint(t.r); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	name, err := t.readBytes()
+	if err != nil {
+		return nil, err
+	}
+	msg.Name = string(name)
+	if msg.Data, err = t.readBytes(); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (t *connTransport) readBytes() ([]byte, error) {
+	n, err := binary.ReadUvarint(t.r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if n > maxReplicationMessageSize {
+		return nil, fmt.Errorf("Corruption: Replication message too large: %d bytes", n)
+	}
+
+	b := make([]byte, n)
+	if _, err = io.ReadFull(t.r, b); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return b, nil
+}
+
+func (t *connTransport) Close() error {
+	return t.conn.Close()
+}
+
+// unexpectedEOF reports the end of the connection in the middle of a
+// message as io.ErrUnexpectedEOF.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
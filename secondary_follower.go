@@ -0,0 +1,232 @@
+package grocksdb
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultSecondaryCatchUpInterval = time.Second
+
+// SecondaryStatus is how far a secondary instance is behind its primary.
+type SecondaryStatus struct {
+	// Applied is the latest sequence number of the secondary instance.
+	Applied uint64
+	// Primary is the latest sequence number of the primary, as returned by
+	// the function set with SetPrimarySequence, or Applied if greater. It is
+	// 0 when no function is set.
+	Primary uint64
+	// Lag is the number of sequence numbers the secondary instance is behind
+	// the primary.
+	Lag uint64
+	// CaughtUpAt is when the secondary instance last caught up with the
+	// primary, zero if never.
+	CaughtUpAt time.Time
+}
+
+// SecondaryFollower keeps a secondary instance, opened with
+// OpenDbAsSecondary or OpenDbAsSecondaryColumnFamilies, caught up with its
+// primary: periodically, and whenever notified of changes to the files of
+// the primary, such as by a filesystem watcher.
+//
+// The latest sequence number of the primary is not known to the secondary
+// instance: the lag of the follower is only known once given a source of it
+// with SetPrimarySequence.
+type SecondaryFollower struct {
+	// number of catch-ups applying changes, read atomically
+	generation uint64
+
+	db     *DB
+	notify chan struct{}
+
+	mu         sync.Mutex
+	interval   time.Duration
+	applied    uint64
+	caughtUpAt time.Time
+	primary    func() (uint64, error)
+}
+
+// NewSecondaryFollower returns a follower keeping the secondary instance
+// caught up with its primary.
+func NewSecondaryFollower(db *DB) *SecondaryFollower {
+	return &SecondaryFollower{
+		db:       db,
+		notify:   make(chan struct{}, 1),
+		interval: defaultSecondaryCatchUpInterval,
+		applied:  db.GetLatestSequenceNumber(),
+	}
+}
+
+// SetPrimarySequence sets the function returning the latest sequence number
+// of the primary, reported by Status, such as a call to the process of the
+// primary returning its GetLatestSequenceNumber.
+func (f *SecondaryFollower) SetPrimarySequence(fn func() (uint64, error)) {
+	f.mu.Lock()
+	f.primary = fn
+	f.mu.Unlock()
+}
+
+// SetCatchUpInterval sets how often Run catches up with the primary when
+// not notified.
+//
+// Default: 1s
+func (f *SecondaryFollower) SetCatchUpInterval(interval time.Duration) {
+	f.mu.Lock()
+	f.interval = interval
+	f.mu.Unlock()
+}
+
+// DB returns the secondary instance.
+func (f *SecondaryFollower) DB() *DB {
+	return f.db
+}
+
+// Notify makes Run catch up with the primary without waiting for the
+// interval, such as when the files of the primary change. It does not
+// block.
+func (f *SecondaryFollower) Notify() {
+	select {
+	case f.notify <- struct{}{}:
+	default:
+	}
+}
+
+// CatchUp catches up with the primary.
+func (f *SecondaryFollower) CatchUp() (err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err = f.db.TryCatchUpWithPrimary(); err != nil {
+		return
+	}
+	f.caughtUpAt = time.Now()
+
+	if applied := f.db.GetLatestSequenceNumber(); applied != f.applied {
+		f.applied = applied
+		atomic.AddUint64(&f.generation, 1)
+	}
+	return
+}
+
+// Status returns how far the secondary instance is behind the primary.
+func (f *SecondaryFollower) Status() (status SecondaryStatus, err error) {
+	f.mu.Lock()
+	status.Applied, status.CaughtUpAt = f.applied, f.caughtUpAt
+	primary := f.primary
+	f.mu.Unlock()
+
+	if primary == nil {
+		return
+	}
+	// called without blocking catch-ups
+	if status.Primary, err = primary(); err != nil {
+		return
+	}
+
+	if status.Primary > status.Applied {
+		status.Lag = status.Primary - status.Applied
+	} else {
+		status.Primary = status.Applied
+	}
+	return
+}
+
+// Run catches up with the primary periodically and when notified, until
+// ctx is done or catching up fails.
+func (f *SecondaryFollower) Run(ctx context.Context) error {
+	for {
+		if err := f.CatchUp(); err != nil {
+			return err
+		}
+
+		f.mu.Lock()
+		interval := f.interval
+		f.mu.Unlock()
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-f.notify:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// SecondaryIterator is an iterator of a secondary instance refreshed, with
+// Iterator.Refresh, once the secondary instance catches up with changes of
+// the primary. Iterators are refreshed when seeking, so that they are
+// positioned anew, and are used from a single goroutine as usual.
+type SecondaryIterator struct {
+	*Iterator
+
+	follower   *SecondaryFollower
+	opts       *ReadOptions
+	cf         *ColumnFamilyHandle
+	generation uint64
+}
+
+// NewIterator returns an iterator of the default column family refreshed
+// once the secondary instance catches up.
+func (f *SecondaryFollower) NewIterator(opts *ReadOptions) *SecondaryIterator {
+	return f.NewIteratorCF(opts, f.db.GetDefaultColumnFamily())
+}
+
+// NewIteratorCF returns an iterator of the column family refreshed once the
+// secondary instance catches up.
+func (f *SecondaryFollower) NewIteratorCF(opts *ReadOptions, cf *ColumnFamilyHandle) *SecondaryIterator {
+	return &SecondaryIterator{
+		generation: atomic.LoadUint64(&f.generation),
+		Iterator:   f.db.NewIteratorCF(opts, cf),
+		follower:   f,
+		opts:       opts,
+		cf:         cf,
+	}
+}
+
+// refresh refreshes the iterator if the secondary instance caught up since
+// it was created or last refreshed.
+func (iter *SecondaryIterator) refresh() {
+	generation := atomic.LoadUint64(&iter.follower.generation)
+	if generation == iter.generation {
+		return
+	}
+
+	// iterators which cannot be refreshed are created anew
+	if err := iter.Iterator.Refresh(); err != nil {
+		iter.Iterator.Close()
+		iter.Iterator = iter.follower.db.NewIteratorCF(iter.opts, iter.cf)
+	}
+	iter.generation = generation
+}
+
+// SeekToFirst refreshes the iterator if needed, then moves it to the first
+// key in the source.
+func (iter *SecondaryIterator) SeekToFirst() {
+	iter.refresh()
+	iter.Iterator.SeekToFirst()
+}
+
+// SeekToLast refreshes the iterator if needed, then moves it to the last key
+// in the source.
+func (iter *SecondaryIterator) SeekToLast() {
+	iter.refresh()
+	iter.Iterator.SeekToLast()
+}
+
+// Seek refreshes the iterator if needed, then moves it to the position
+// where the key is, or the next key.
+func (iter *SecondaryIterator) Seek(key []byte) {
+	iter.refresh()
+	iter.Iterator.Seek(key)
+}
+
+// SeekForPrev refreshes the iterator if needed, then moves it to the
+// position where the key is, or the previous key.
+func (iter *SecondaryIterator) SeekForPrev(key []byte) {
+	iter.refresh()
+	iter.Iterator.SeekForPrev(key)
+}
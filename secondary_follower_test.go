@@ -0,0 +1,84 @@
+package grocksdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecondaryFollower(t *testing.T) {
+	t.Parallel()
+
+	db := newTestDB(t, nil)
+	defer db.Close()
+
+	opts := NewDefaultOptions()
+	defer opts.Destroy()
+	opts.SetMaxOpenFiles(-1)
+	secondary, err := OpenDbAsSecondary(opts, db.Name(), t.TempDir())
+	require.Nil(t, err)
+	defer secondary.Close()
+
+	follower := NewSecondaryFollower(secondary)
+	follower.SetCatchUpInterval(time.Hour)
+
+	// the lag is unknown without the sequence number of the primary
+	status, err := follower.Status()
+	require.Nil(t, err)
+	require.Zero(t, status.Primary)
+	require.Zero(t, status.Lag)
+	follower.SetPrimarySequence(func() (uint64, error) {
+		return db.GetLatestSequenceNumber(), nil
+	})
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	require.Nil(t, db.Put(wo, []byte("a"), []byte("1")))
+	require.Nil(t, db.Put(wo, []byte("b"), []byte("2")))
+
+	status, err = follower.Status()
+	require.Nil(t, err)
+	require.Equal(t, db.GetLatestSequenceNumber(), status.Primary)
+	require.Equal(t, status.Primary-status.Applied, status.Lag)
+	require.EqualValues(t, 2, status.Lag)
+	require.True(t, status.CaughtUpAt.IsZero())
+
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+	iter := follower.NewIterator(ro)
+	defer iter.Close()
+	iter.SeekToFirst()
+	require.False(t, iter.Valid())
+
+	require.Nil(t, follower.CatchUp())
+	status, err = follower.Status()
+	require.Nil(t, err)
+	require.Equal(t, db.GetLatestSequenceNumber(), status.Applied)
+	require.Zero(t, status.Lag)
+	require.False(t, status.CaughtUpAt.IsZero())
+
+	// long-lived iterators are refreshed
+	iter.SeekToFirst()
+	require.True(t, iter.Valid())
+	require.EqualValues(t, "a", iter.Key().Data())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- follower.Run(ctx) }()
+
+	require.Nil(t, db.Put(wo, []byte("c"), []byte("3")))
+	follower.Notify()
+	require.Eventually(t, func() bool {
+		status, err := follower.Status()
+		return err == nil && status.Applied == db.GetLatestSequenceNumber() && status.Lag == 0
+	}, 10*time.Second, 10*time.Millisecond)
+
+	iter.Seek([]byte("c"))
+	require.True(t, iter.Valid())
+	require.EqualValues(t, "3", iter.Value().Data())
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
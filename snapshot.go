@@ -2,7 +2,13 @@ package grocksdb
 
 // #include "rocksdb/c.h"
 import "C"
-import "unsafe"
+
+import (
+	"sort"
+	"sync"
+	"time"
+	"unsafe"
+)
 
 // Snapshot provides a consistent view of read operations in a DB.
 type Snapshot struct {
@@ -24,3 +30,54 @@ func (snapshot *Snapshot) Destroy() {
 	C.rocksdb_free(unsafe.Pointer(snapshot.c))
 	snapshot.c = nil
 }
+
+// SnapshotInfo describes a live snapshot of a database.
+type SnapshotInfo struct {
+	SequenceNumber uint64
+	CreatedAt      time.Time
+	// Age is the time elapsed since the snapshot was created.
+	Age time.Duration
+}
+
+// snapshotRegistry keeps track of the live snapshots of a database, since
+// RocksDB does not list them.
+type snapshotRegistry struct {
+	mu        sync.Mutex
+	snapshots map[*Snapshot]time.Time
+}
+
+func (r *snapshotRegistry) add(snapshot *Snapshot) {
+	r.mu.Lock()
+	if r.snapshots == nil {
+		r.snapshots = make(map[*Snapshot]time.Time)
+	}
+	r.snapshots[snapshot] = time.Now()
+	r.mu.Unlock()
+}
+
+func (r *snapshotRegistry) remove(snapshot *Snapshot) {
+	r.mu.Lock()
+	delete(r.snapshots, snapshot)
+	r.mu.Unlock()
+}
+
+// list returns the live snapshots, oldest first.
+func (r *snapshotRegistry) list() []SnapshotInfo {
+	now := time.Now()
+
+	r.mu.Lock()
+	infos := make([]SnapshotInfo, 0, len(r.snapshots))
+	for snapshot, createdAt := range r.snapshots {
+		infos = append(infos, SnapshotInfo{
+			SequenceNumber: snapshot.GetSequenceNumber(),
+			CreatedAt:      createdAt,
+			Age:            now.Sub(createdAt),
+		})
+	}
+	r.mu.Unlock()
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].CreatedAt.Before(infos[j].CreatedAt)
+	})
+	return infos
+}
@@ -6,16 +6,58 @@ import "C"
 
 import (
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+var (
+	// ErrTransactionWriteBatchFull is returned by untracked writes exceeding
+	// the max write batch size of the transaction.
+	ErrTransactionWriteBatchFull = fmt.Errorf("Operation aborted: Memory limit reached")
+	// ErrTransactionSnapshotRequired is returned by Transaction.GetWithTS when
+	// neither the read options nor the transaction have a snapshot.
+	ErrTransactionSnapshotRequired = fmt.Errorf("Invalid argument: Snapshot required")
 )
 
 // Transaction is used with TransactionDB for transaction support.
 type Transaction struct {
 	c *C.rocksdb_transaction_t
+
+	// db is set for transactions of a TransactionDB only.
+	db    *TransactionDB
+	name  string
+	state int32
+
+	// optimisticDB is set for transactions of an OptimisticTransactionDB.
+	optimisticDB *OptimisticTransactionDB
+	// hasSnapshot is whether the transaction was begun with a snapshot.
+	hasSnapshot bool
+	// commitMu is the commit lock of the database of the transaction.
+	commitMu *sync.RWMutex
+
+	savePoints []transactionSavePoint
+	numPuts    uint64
+	numDeletes uint64
+	numMerges  uint64
+	begin      time.Time
+	// ranges read for update.
+	ranges []trackedRange
+}
+
+// transactionSavePoint records the state of a transaction at SetSavePoint.
+type transactionSavePoint struct {
+	numPuts    uint64
+	numDeletes uint64
+	numMerges  uint64
+	numRanges  int
 }
 
 // NewNativeTransaction creates a Transaction object.
 func newNativeTransaction(c *C.rocksdb_transaction_t) *Transaction {
-	return &Transaction{c: c}
+	return &Transaction{c: c, begin: time.Now()}
 }
 
 // SetName of transaction.
@@ -26,7 +68,12 @@ func (transaction *Transaction) SetName(name string) (err error) {
 	)
 
 	C.rocksdb_transaction_set_name(transaction.c, name_, C.size_t(len(name)), &cErr)
-	err = fromCError(cErr)
+	if err = fromCError(cErr); err == nil {
+		transaction.name = name
+		if transaction.db != nil {
+			transaction.db.registerTransaction(transaction)
+		}
+	}
 
 	return err
 }
@@ -41,24 +88,42 @@ func (transaction *Transaction) GetName() string {
 // Prepare transaction.
 func (transaction *Transaction) Prepare() (err error) {
 	var cErr *C.char
+	prev := transaction.setState(TransactionAwaitingPrepare)
 	C.rocksdb_transaction_prepare(transaction.c, &cErr)
 	err = fromCError(cErr)
+	transaction.stateAfter(TransactionPrepared, prev, err)
 	return err
 }
 
 // Commit commits the transaction to the database.
 func (transaction *Transaction) Commit() (err error) {
 	var cErr *C.char
+	unlock := transaction.lockCommit()
+	defer unlock()
+	if err = transaction.validateRanges(); err != nil {
+		return err
+	}
+
+	prev := transaction.setState(TransactionAwaitingCommit)
 	C.rocksdb_transaction_commit(transaction.c, &cErr)
 	err = fromCError(cErr)
+	transaction.stateAfter(TransactionCommitted, prev, err)
+	if err == nil {
+		transaction.clear()
+	}
 	return err
 }
 
 // Rollback performs a rollback on the transaction.
 func (transaction *Transaction) Rollback() (err error) {
 	var cErr *C.char
+	prev := transaction.setState(TransactionAwaitingRollback)
 	C.rocksdb_transaction_rollback(transaction.c, &cErr)
 	err = fromCError(cErr)
+	transaction.stateAfter(TransactionRolledBack, prev, err)
+	if err == nil {
+		transaction.clear()
+	}
 	return err
 }
 
@@ -128,6 +193,146 @@ func (transaction *Transaction) GetPinnedWithCF(opts *ReadOptions, cf *ColumnFam
 	return handle, err
 }
 
+// GetWithTS returns the data and timestamp associated with the key from the
+// database given this transaction, whose comparator has user-defined timestamps.
+// Data written by the transaction is returned with an empty timestamp, as it is
+// assigned the commit timestamp of the transaction only once committed.
+//
+// Data not written by the transaction is read from the snapshot of opts, or
+// else from the snapshot of the transaction, begun with
+// TransactionOptions.SetSetSnapshot: GetWithTS fails with
+// ErrTransactionSnapshotRequired when there is none. Keys found in the
+// database are read once more through the transaction when it has writes,
+// to tell the keys it deletes apart.
+func (transaction *Transaction) GetWithTS(opts *ReadOptions, key []byte) (value, timestamp *Slice, err error) {
+	return transaction.getWithTS(opts, nil, key)
+}
+
+// GetCFWithTS returns the data and timestamp associated with the key from the
+// database and column family given this transaction, as GetWithTS does.
+func (transaction *Transaction) GetCFWithTS(opts *ReadOptions, cf *ColumnFamilyHandle, key []byte) (value, timestamp *Slice, err error) {
+	return transaction.getWithTS(opts, cf, key)
+}
+
+func (transaction *Transaction) getWithTS(opts *ReadOptions, cf *ColumnFamilyHandle, key []byte) (value, timestamp *Slice, err error) {
+	// data written by the transaction is read from its write batch
+	value, written := transaction.getFromBatch(cf, key)
+	if written {
+		if value == nil {
+			// merged by the transaction, with data of the database
+			if value, err = transaction.get(opts, cf, key); err != nil {
+				return nil, nil, err
+			}
+		}
+		return value, NewSlice(nil, 0), nil
+	}
+
+	if opts.snapshot == nil {
+		if !transaction.hasSnapshot {
+			return nil, nil, ErrTransactionSnapshotRequired
+		}
+		snapshot := transaction.GetSnapshot()
+		defer snapshot.Destroy()
+
+		// the options given may be used concurrently
+		opts = opts.clone()
+		defer opts.Destroy()
+		opts.SetSnapshot(snapshot)
+	}
+
+	base := transaction.base()
+	if cf == nil {
+		value, timestamp, err = base.GetWithTS(opts, key)
+	} else {
+		value, timestamp, err = base.GetCFWithTS(opts, cf, key)
+	}
+	if err != nil || !value.Exists() || transaction.batchCount() == 0 {
+		return value, timestamp, err
+	}
+
+	// keys deleted by the transaction are not found in its write batch either,
+	// and are read once more through the transaction
+	read, err := transaction.get(opts, cf, key)
+	if err != nil || !read.Exists() {
+		value.Free()
+		timestamp.Free()
+		if err != nil {
+			return nil, nil, err
+		}
+		return read, NewSlice(nil, 0), nil
+	}
+	read.Free()
+	return value, timestamp, nil
+}
+
+func (transaction *Transaction) get(opts *ReadOptions, cf *ColumnFamilyHandle, key []byte) (*Slice, error) {
+	if cf == nil {
+		return transaction.Get(opts, key)
+	}
+	return transaction.GetWithCF(opts, cf, key)
+}
+
+// base returns the base database of the database of the transaction.
+func (transaction *Transaction) base() *DB {
+	if transaction.db != nil {
+		return transaction.db.base
+	}
+	return transaction.optimisticDB.base
+}
+
+// batchCount returns the number of writes of the write batch of the transaction.
+func (transaction *Transaction) batchCount() int {
+	// the returned wrapper does not own the write batch of the transaction
+	wi := C.rocksdb_transaction_get_writebatch_wi(transaction.c)
+	defer C.free(unsafe.Pointer(wi))
+	return int(C.rocksdb_writebatch_wi_count(wi))
+}
+
+// getFromBatch returns the data written by the transaction for the key, and
+// whether the key is written by it. Keys merged by the transaction are
+// returned as written with nil data, as their data is only known with the
+// data of the database.
+func (transaction *Transaction) getFromBatch(cf *ColumnFamilyHandle, key []byte) (value *Slice, written bool) {
+	var (
+		cErr    *C.char
+		cValLen C.size_t
+		cKey    = refGoBytes(key)
+	)
+
+	// the returned wrapper does not own the write batch of the transaction
+	wi := C.rocksdb_transaction_get_writebatch_wi(transaction.c)
+	defer C.free(unsafe.Pointer(wi))
+
+	// options are only used to merge values, with no merge operator
+	opts := transaction.batchOptions()
+
+	var cValue *C.char
+	if cf == nil {
+		cValue = C.rocksdb_writebatch_wi_get_from_batch(wi, opts.c, cKey, C.size_t(len(key)), &cValLen, &cErr)
+	} else {
+		cValue = C.rocksdb_writebatch_wi_get_from_batch_cf(wi, opts.c, cf.c, cKey, C.size_t(len(key)), &cValLen, &cErr)
+	}
+
+	if cErr != nil {
+		C.rocksdb_free(unsafe.Pointer(cErr))
+		C.rocksdb_free(unsafe.Pointer(cValue))
+		return nil, true
+	}
+	if cValue == nil {
+		return nil, false
+	}
+	return NewSlice(cValue, cValLen), true
+}
+
+// batchOptions returns the options of the reads of the write batch of the
+// transaction.
+func (transaction *Transaction) batchOptions() *Options {
+	if transaction.db != nil {
+		return transaction.db.batchOpts
+	}
+	return transaction.optimisticDB.batchOpts
+}
+
 // GetForUpdate returns the data associated with the key and puts an exclusive lock on the key
 // from the database given this transaction.
 func (transaction *Transaction) GetForUpdate(opts *ReadOptions, key []byte) (slice *Slice, err error) {
@@ -140,7 +345,8 @@ func (transaction *Transaction) GetForUpdate(opts *ReadOptions, key []byte) (sli
 	cValue := C.rocksdb_transaction_get_for_update(
 		transaction.c, opts.c, cKey, C.size_t(len(key)), &cValLen, C.uchar(byte(1)) /*exclusive*/, &cErr,
 	)
-	if err = fromCError(cErr); err == nil {
+	err = fromCError(cErr)
+	if err == nil {
 		slice = NewSlice(cValue, cValLen)
 	}
 
@@ -159,7 +365,8 @@ func (transaction *Transaction) GetPinnedForUpdate(opts *ReadOptions, key []byte
 		transaction.c, opts.c, cKey, C.size_t(len(key)),
 		C.uchar(byte(1)), /*exclusive*/
 		&cErr)
-	if err = fromCError(cErr); err == nil {
+	err = fromCError(cErr)
+	if err == nil {
 		handle = newNativePinnableSlice(cHandle)
 	}
 
@@ -178,7 +385,8 @@ func (transaction *Transaction) GetForUpdateWithCF(opts *ReadOptions, cf *Column
 	cValue := C.rocksdb_transaction_get_for_update_cf(
 		transaction.c, opts.c, cf.c, cKey, C.size_t(len(key)), &cValLen, C.uchar(byte(1)) /*exclusive*/, &cErr,
 	)
-	if err = fromCError(cErr); err == nil {
+	err = fromCError(cErr)
+	if err == nil {
 		slice = NewSlice(cValue, cValLen)
 	}
 
@@ -197,7 +405,8 @@ func (transaction *Transaction) GetPinnedForUpdateWithCF(opts *ReadOptions, cf *
 		transaction.c, opts.c, cf.c, cKey, C.size_t(len(key)),
 		C.uchar(byte(1)), /*exclusive*/
 		&cErr)
-	if err = fromCError(cErr); err == nil {
+	err = fromCError(cErr)
+	if err == nil {
 		handle = newNativePinnableSlice(cHandle)
 	}
 
@@ -294,6 +503,85 @@ func (transaction *Transaction) MultiGetWithCF(opts *ReadOptions, cf *ColumnFami
 	return slices, nil
 }
 
+// MultiGetForUpdate returns the data associated with the passed keys from the
+// transaction and puts an exclusive lock on each of them, as GetForUpdate does.
+func (transaction *Transaction) MultiGetForUpdate(opts *ReadOptions, keys ...[]byte) (Slices, error) {
+	return transaction.multiGetForUpdate(opts, nil, keys)
+}
+
+// MultiGetForUpdateWithCF returns the data associated with the passed keys,
+// which belong to a specific column family, from the transaction and puts an
+// exclusive lock on each of them, as GetForUpdateWithCF does.
+func (transaction *Transaction) MultiGetForUpdateWithCF(opts *ReadOptions, cf *ColumnFamilyHandle, keys ...[]byte) (Slices, error) {
+	return transaction.multiGetForUpdate(opts, cf, keys)
+}
+
+func (transaction *Transaction) multiGetForUpdate(opts *ReadOptions, cf *ColumnFamilyHandle, keys [][]byte) (Slices, error) {
+	// will destroy `cKeys` before return
+	cKeys, cKeySizes := byteSlicesToCSlices(keys)
+	defer cKeys.Destroy()
+
+	vals := make(charsSlice, len(keys))
+	valSizes := make(sizeTSlice, len(keys))
+	rocksErrs := make(charsSlice, len(keys))
+
+	if cf == nil {
+		C.rocksdb_transaction_multi_get_for_update(
+			transaction.c,
+			opts.c,
+			C.size_t(len(keys)),
+			cKeys.c(),
+			cKeySizes.c(),
+			vals.c(),
+			valSizes.c(),
+			rocksErrs.c(),
+		)
+	} else {
+		cfs := make(ColumnFamilyHandles, len(keys))
+		for i := range keys {
+			cfs[i] = cf
+		}
+
+		C.rocksdb_transaction_multi_get_for_update_cf(
+			transaction.c,
+			opts.c,
+			cfs.toCSlice().c(),
+			C.size_t(len(keys)),
+			cKeys.c(),
+			cKeySizes.c(),
+			vals.c(),
+			valSizes.c(),
+			rocksErrs.c(),
+		)
+	}
+
+	var errs []error
+
+	for i, rocksErr := range rocksErrs {
+		err := fromCError(rocksErr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("getting %q failed: %w", string(keys[i]), err))
+		}
+	}
+
+	if len(errs) > 0 {
+		for i, val := range vals {
+			if val != nil {
+				C.rocksdb_free(unsafe.Pointer(val))
+				vals[i] = nil
+			}
+		}
+		return nil, fmt.Errorf("failed to get %d keys, first error: %w", len(errs), errs[0])
+	}
+
+	slices := make(Slices, len(keys))
+	for i, val := range vals {
+		slices[i] = NewSlice(val, valSizes[i])
+	}
+
+	return slices, nil
+}
+
 // Put writes data associated with a key to the transaction.
 func (transaction *Transaction) Put(key, value []byte) (err error) {
 	var (
@@ -306,6 +594,7 @@ func (transaction *Transaction) Put(key, value []byte) (err error) {
 		transaction.c, cKey, C.size_t(len(key)), cValue, C.size_t(len(value)), &cErr,
 	)
 	err = fromCError(cErr)
+	transaction.counted(&transaction.numPuts, err)
 
 	return err
 }
@@ -322,6 +611,7 @@ func (transaction *Transaction) PutCF(cf *ColumnFamilyHandle, key, value []byte)
 		transaction.c, cf.c, cKey, C.size_t(len(key)), cValue, C.size_t(len(value)), &cErr,
 	)
 	err = fromCError(cErr)
+	transaction.counted(&transaction.numPuts, err)
 
 	return err
 }
@@ -338,6 +628,7 @@ func (transaction *Transaction) Merge(key, value []byte) (err error) {
 		transaction.c, cKey, C.size_t(len(key)), cValue, C.size_t(len(value)), &cErr,
 	)
 	err = fromCError(cErr)
+	transaction.counted(&transaction.numMerges, err)
 
 	return err
 }
@@ -354,6 +645,7 @@ func (transaction *Transaction) MergeCF(cf *ColumnFamilyHandle, key, value []byt
 		transaction.c, cf.c, cKey, C.size_t(len(key)), cValue, C.size_t(len(value)), &cErr,
 	)
 	err = fromCError(cErr)
+	transaction.counted(&transaction.numMerges, err)
 
 	return err
 }
@@ -367,6 +659,7 @@ func (transaction *Transaction) Delete(key []byte) (err error) {
 
 	C.rocksdb_transaction_delete(transaction.c, cKey, C.size_t(len(key)), &cErr)
 	err = fromCError(cErr)
+	transaction.counted(&transaction.numDeletes, err)
 
 	return err
 }
@@ -380,10 +673,88 @@ func (transaction *Transaction) DeleteCF(cf *ColumnFamilyHandle, key []byte) (er
 
 	C.rocksdb_transaction_delete_cf(transaction.c, cf.c, cKey, C.size_t(len(key)), &cErr)
 	err = fromCError(cErr)
+	transaction.counted(&transaction.numDeletes, err)
 
 	return err
 }
 
+// PutUntracked writes data associated with a key to the transaction, without
+// locking the key nor tracking it for conflict checking. It should be used for
+// keys known not to be written concurrently.
+func (transaction *Transaction) PutUntracked(key, value []byte) error {
+	cKey := refGoBytes(key)
+	cValue := refGoBytes(value)
+	return transaction.writeUntracked(&transaction.numPuts, func(wi *C.rocksdb_writebatch_wi_t) {
+		C.rocksdb_writebatch_wi_put(wi, cKey, C.size_t(len(key)), cValue, C.size_t(len(value)))
+	})
+}
+
+// PutUntrackedCF writes data associated with a key (belongs to specific column
+// family) to the transaction, as PutUntracked does.
+func (transaction *Transaction) PutUntrackedCF(cf *ColumnFamilyHandle, key, value []byte) error {
+	cKey := refGoBytes(key)
+	cValue := refGoBytes(value)
+	return transaction.writeUntracked(&transaction.numPuts, func(wi *C.rocksdb_writebatch_wi_t) {
+		C.rocksdb_writebatch_wi_put_cf(wi, cf.c, cKey, C.size_t(len(key)), cValue, C.size_t(len(value)))
+	})
+}
+
+// MergeUntracked merges data associated with a key in the transaction, without
+// locking the key nor tracking it for conflict checking.
+func (transaction *Transaction) MergeUntracked(key, value []byte) error {
+	cKey := refGoBytes(key)
+	cValue := refGoBytes(value)
+	return transaction.writeUntracked(&transaction.numMerges, func(wi *C.rocksdb_writebatch_wi_t) {
+		C.rocksdb_writebatch_wi_merge(wi, cKey, C.size_t(len(key)), cValue, C.size_t(len(value)))
+	})
+}
+
+// MergeUntrackedCF merges data associated with a key (belongs to specific
+// column family) in the transaction, as MergeUntracked does.
+func (transaction *Transaction) MergeUntrackedCF(cf *ColumnFamilyHandle, key, value []byte) error {
+	cKey := refGoBytes(key)
+	cValue := refGoBytes(value)
+	return transaction.writeUntracked(&transaction.numMerges, func(wi *C.rocksdb_writebatch_wi_t) {
+		C.rocksdb_writebatch_wi_merge_cf(wi, cf.c, cKey, C.size_t(len(key)), cValue, C.size_t(len(value)))
+	})
+}
+
+// DeleteUntracked removes the data associated with the key from the
+// transaction, without locking the key nor tracking it for conflict checking.
+func (transaction *Transaction) DeleteUntracked(key []byte) error {
+	cKey := refGoBytes(key)
+	return transaction.writeUntracked(&transaction.numDeletes, func(wi *C.rocksdb_writebatch_wi_t) {
+		C.rocksdb_writebatch_wi_delete(wi, cKey, C.size_t(len(key)))
+	})
+}
+
+// DeleteUntrackedCF removes the data associated with the key (belongs to
+// specific column family) from the transaction, as DeleteUntracked does.
+func (transaction *Transaction) DeleteUntrackedCF(cf *ColumnFamilyHandle, key []byte) error {
+	cKey := refGoBytes(key)
+	return transaction.writeUntracked(&transaction.numDeletes, func(wi *C.rocksdb_writebatch_wi_t) {
+		C.rocksdb_writebatch_wi_delete_cf(wi, cf.c, cKey, C.size_t(len(key)))
+	})
+}
+
+// writeUntracked adds a write, counted by counter, to the write batch of the
+// transaction, as RocksDB does for untracked writes.
+func (transaction *Transaction) writeUntracked(counter *uint64, write func(wi *C.rocksdb_writebatch_wi_t)) error {
+	// the returned wrapper does not own the write batch of the transaction
+	wi := C.rocksdb_transaction_get_writebatch_wi(transaction.c)
+	defer C.free(unsafe.Pointer(wi))
+
+	// writes exceeding the max write batch size of the transaction are dropped
+	count := C.rocksdb_writebatch_wi_count(wi)
+	write(wi)
+	if C.rocksdb_writebatch_wi_count(wi) == count {
+		return ErrTransactionWriteBatchFull
+	}
+
+	*counter++
+	return nil
+}
+
 // NewIterator returns an iterator that will iterate on all keys in the default
 // column family including both keys in the DB and uncommitted keys in this
 // transaction.
@@ -417,6 +788,12 @@ func (transaction *Transaction) NewIteratorCF(opts *ReadOptions, cf *ColumnFamil
 // points.
 func (transaction *Transaction) SetSavePoint() {
 	C.rocksdb_transaction_set_savepoint(transaction.c)
+	transaction.savePoints = append(transaction.savePoints, transactionSavePoint{
+		numPuts:    transaction.numPuts,
+		numDeletes: transaction.numDeletes,
+		numMerges:  transaction.numMerges,
+		numRanges:  len(transaction.ranges),
+	})
 }
 
 // RollbackToSavePoint undo all operations in this transaction (Put, Merge, Delete, PutLogData)
@@ -425,7 +802,13 @@ func (transaction *Transaction) SetSavePoint() {
 func (transaction *Transaction) RollbackToSavePoint() (err error) {
 	var cErr *C.char
 	C.rocksdb_transaction_rollback_to_savepoint(transaction.c, &cErr)
-	err = fromCError(cErr)
+	if err = fromCError(cErr); err == nil && len(transaction.savePoints) > 0 {
+		sp := transaction.savePoints[len(transaction.savePoints)-1]
+		transaction.savePoints = transaction.savePoints[:len(transaction.savePoints)-1]
+
+		transaction.numPuts, transaction.numDeletes, transaction.numMerges = sp.numPuts, sp.numDeletes, sp.numMerges
+		transaction.ranges = transaction.ranges[:sp.numRanges]
+	}
 	return err
 }
 
@@ -438,6 +821,7 @@ func (transaction *Transaction) GetSnapshot() *Snapshot {
 func (transaction *Transaction) Destroy() {
 	C.rocksdb_transaction_destroy(transaction.c)
 	transaction.c = nil
+	transaction.clear()
 }
 
 // GetWriteBatchWI returns underlying write batch wi.
@@ -487,3 +871,66 @@ func (transaction *Transaction) SetCommitTimestamp(ts uint64) {
 func (transaction *Transaction) SetReadTimestampForValidation(ts uint64) {
 	C.rocksdb_transaction_set_read_timestamp_for_validation(transaction.c, C.uint64_t(ts))
 }
+
+// GetState returns the state of the transaction.
+func (transaction *Transaction) GetState() TransactionState {
+	return TransactionState(atomic.LoadInt32(&transaction.state))
+}
+
+func (transaction *Transaction) setState(state TransactionState) (prev TransactionState) {
+	return TransactionState(atomic.SwapInt32(&transaction.state, int32(state)))
+}
+
+// stateAfter sets the state of the transaction once an operation which
+// would move it to state is done.
+func (transaction *Transaction) stateAfter(state, prev TransactionState, err error) {
+	switch {
+	case err == nil:
+		transaction.setState(state)
+	case strings.HasPrefix(err.Error(), "Operation expired"):
+		transaction.setState(TransactionLocksStolen)
+	default:
+		transaction.setState(prev)
+	}
+}
+
+// GetNumPuts returns the number of Puts done in this transaction.
+func (transaction *Transaction) GetNumPuts() uint64 {
+	return transaction.numPuts
+}
+
+// GetNumDeletes returns the number of Deletes done in this transaction.
+func (transaction *Transaction) GetNumDeletes() uint64 {
+	return transaction.numDeletes
+}
+
+// GetNumMerges returns the number of Merges done in this transaction.
+func (transaction *Transaction) GetNumMerges() uint64 {
+	return transaction.numMerges
+}
+
+// GetElapsedTime returns the time elapsed since the transaction began. For
+// prepared transactions returned by TransactionDB.GetPreparedTransactions, it
+// is the time elapsed since they were first returned.
+func (transaction *Transaction) GetElapsedTime() time.Duration {
+	return time.Since(transaction.begin)
+}
+
+// counted counts the write made by an operation with counter, once it
+// succeeded.
+func (transaction *Transaction) counted(counter *uint64, err error) {
+	if err == nil {
+		*counter++
+	}
+}
+
+// clear resets the transaction, once it is committed, rolled back or destroyed.
+func (transaction *Transaction) clear() {
+	if transaction.db != nil && transaction.name != "" {
+		transaction.db.unregisterTransaction(transaction)
+	}
+
+	transaction.savePoints = nil
+	transaction.ranges = nil
+	transaction.numPuts, transaction.numDeletes, transaction.numMerges = 0, 0, 0
+}
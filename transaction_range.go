@@ -0,0 +1,179 @@
+package grocksdb
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ErrPhantomKey is returned by Transaction.Commit when a key was written by
+// another transaction in a range read with GetRangeForUpdate. It is classified
+// as ConflictBusy by ClassifyTransactionError, so that the transaction can be
+// retried.
+var ErrPhantomKey = fmt.Errorf("key written in a range read for update")
+
+// trackedRange is a range of keys read by GetRangeForUpdate, with the keys
+// read in it.
+type trackedRange struct {
+	cf           *ColumnFamilyHandle
+	start, limit []byte
+	keys         map[string]struct{}
+}
+
+// contains reports whether key is in the range.
+func (r trackedRange) contains(key []byte) bool {
+	return bytes.Compare(key, r.start) >= 0 && (r.limit == nil || bytes.Compare(key, r.limit) < 0)
+}
+
+// GetRangeForUpdate returns the keys in [start, limit) of the default column
+// family, with their data, given this transaction, and protects the range
+// against phantoms: each key returned is read with GetForUpdate, and Commit
+// fails with ErrPhantomKey when another transaction wrote a key of the range
+// which was not read by it. A nil limit means no upper bound.
+//
+// The range is validated at commit, serially with the commits of the other
+// transactions of the database and with the writes made through the
+// database. Writes made through the base database returned by GetBaseDB, or
+// by other processes, are not serialized with the validation: they may be
+// missed. Writes of the database waiting on keys locked by a transaction
+// having ranges delay its commit until they time out.
+func (transaction *Transaction) GetRangeForUpdate(opts *ReadOptions, start, limit []byte) (keys, values [][]byte, err error) {
+	return transaction.getRangeForUpdate(opts, nil, start, limit)
+}
+
+// GetRangeForUpdateWithCF returns the keys in [start, limit) of the column
+// family, with their data, given this transaction, as GetRangeForUpdate does.
+func (transaction *Transaction) GetRangeForUpdateWithCF(opts *ReadOptions, cf *ColumnFamilyHandle, start, limit []byte) (keys, values [][]byte, err error) {
+	return transaction.getRangeForUpdate(opts, cf, start, limit)
+}
+
+func (transaction *Transaction) getRangeForUpdate(opts *ReadOptions, cf *ColumnFamilyHandle, start, limit []byte) (keys, values [][]byte, err error) {
+	r := trackedRange{cf: cf, start: append([]byte{}, start...)}
+	if limit != nil {
+		r.limit = append([]byte{}, limit...)
+	}
+
+	var iter *Iterator
+	if cf == nil {
+		iter = transaction.NewIterator(opts)
+	} else {
+		iter = transaction.NewIteratorCF(opts, cf)
+	}
+	for iter.Seek(r.start); iter.Valid(); iter.Next() {
+		key := iter.Key().Data()
+		if !r.contains(key) {
+			break
+		}
+		keys = append(keys, append([]byte{}, key...))
+	}
+	err = iter.Err()
+	iter.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the keys found are tracked, so that updates of them are conflicts
+	r.keys = make(map[string]struct{}, len(keys))
+	values = make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		r.keys[string(key)] = struct{}{}
+		var value *Slice
+		if cf == nil {
+			value, err = transaction.GetForUpdate(opts, key)
+		} else {
+			value, err = transaction.GetForUpdateWithCF(opts, cf, key)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		values = append(values, append([]byte{}, value.Data()...))
+		value.Free()
+	}
+
+	transaction.ranges = append(transaction.ranges, r)
+	return keys, values, nil
+}
+
+// lockCommit serializes the commits of transactions having ranges with the
+// other writes of the database. It returns the function releasing the lock.
+func (transaction *Transaction) lockCommit() (unlock func()) {
+	mu := transaction.commitMu
+	switch {
+	case mu == nil:
+		return func() {}
+	case len(transaction.ranges) == 0:
+		mu.RLock()
+		return mu.RUnlock
+	default:
+		mu.Lock()
+		return mu.Unlock
+	}
+}
+
+// validateRanges checks that the keys of the ranges read for update were all
+// read by the transaction. It must be called with the commit lock held.
+func (transaction *Transaction) validateRanges() (err error) {
+	if len(transaction.ranges) == 0 {
+		return nil
+	}
+
+	base := transaction.base()
+
+	opts := NewDefaultReadOptions()
+	defer opts.Destroy()
+
+	for _, r := range transaction.ranges {
+		var iter *Iterator
+		if r.cf == nil {
+			iter = base.NewIterator(opts)
+		} else {
+			iter = base.NewIteratorCF(opts, r.cf)
+		}
+		for iter.Seek(r.start); iter.Valid(); iter.Next() {
+			key := iter.Key().Data()
+			if !r.contains(key) {
+				break
+			}
+			if _, ok := r.keys[string(key)]; ok {
+				continue
+			}
+
+			// keys deleted by the transaction before reading the range are
+			// not read, and are not phantoms
+			var deleted bool
+			if deleted, err = transaction.deletes(opts, r.cf, key); err == nil && !deleted {
+				err = ErrPhantomKey
+			}
+			if err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = iter.Err()
+		}
+		iter.Close()
+
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deletes reports whether the key, which is in the database, is deleted by
+// the transaction.
+func (transaction *Transaction) deletes(opts *ReadOptions, cf *ColumnFamilyHandle, key []byte) (bool, error) {
+	var (
+		value *Slice
+		err   error
+	)
+	if cf == nil {
+		value, err = transaction.Get(opts, key)
+	} else {
+		value, err = transaction.GetWithCF(opts, cf, key)
+	}
+	if err != nil {
+		return false, err
+	}
+	defer value.Free()
+	return !value.Exists(), nil
+}
@@ -0,0 +1,125 @@
+package grocksdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptimisticTransactionGetRangeForUpdate(t *testing.T) {
+	t.Parallel()
+
+	opts := NewDefaultOptions()
+	opts.SetCreateIfMissing(true)
+	db, err := OpenOptimisticTransactionDb(opts, t.TempDir())
+	require.Nil(t, err)
+	defer db.Close()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+	to := NewDefaultOptimisticTransactionOptions()
+	defer to.Destroy()
+
+	b := NewWriteBatch()
+	b.Put([]byte("slot/1"), []byte("a"))
+	b.Put([]byte("slot/3"), []byte("b"))
+	b.Put([]byte("task"), []byte("c"))
+	require.Nil(t, db.Write(wo, b))
+	b.Destroy()
+
+	// a key inserted in the range by another transaction is a phantom
+	txn := db.TransactionBegin(wo, to, nil)
+	keys, values, err := txn.GetRangeForUpdate(ro, []byte("slot/"), []byte("slot0"))
+	require.Nil(t, err)
+	require.Equal(t, [][]byte{[]byte("slot/1"), []byte("slot/3")}, keys)
+	require.Equal(t, [][]byte{[]byte("a"), []byte("b")}, values)
+	require.Nil(t, txn.Put([]byte("slot/4"), []byte("d")))
+
+	other := db.TransactionBegin(wo, to, nil)
+	require.Nil(t, other.Put([]byte("slot/2"), []byte("e")))
+	require.Nil(t, other.Commit())
+	other.Destroy()
+
+	err = txn.Commit()
+	require.Equal(t, ErrPhantomKey, err)
+	require.Equal(t, ConflictBusy, ClassifyTransactionError(err))
+	require.Nil(t, txn.Rollback())
+
+	// keys written out of the range, or by the transaction, are not phantoms
+	txn = db.TransactionBegin(wo, to, txn)
+	keys, _, err = txn.GetRangeForUpdate(ro, []byte("slot/"), []byte("slot0"))
+	require.Nil(t, err)
+	require.Len(t, keys, 3)
+	require.Nil(t, txn.Put([]byte("slot/4"), []byte("d")))
+
+	other = db.TransactionBegin(wo, to, nil)
+	require.Nil(t, other.Put([]byte("task"), []byte("f")))
+	require.Nil(t, other.Commit())
+	other.Destroy()
+
+	require.Nil(t, txn.Commit())
+
+	// ranges read after a save point are not validated once rolled back to it
+	txn = db.TransactionBegin(wo, to, txn)
+	defer txn.Destroy()
+	txn.SetSavePoint()
+	_, _, err = txn.GetRangeForUpdate(ro, []byte("slot/"), nil)
+	require.Nil(t, err)
+	require.Nil(t, txn.RollbackToSavePoint())
+
+	b = NewWriteBatch()
+	b.Put([]byte("slot/5"), []byte("g"))
+	require.Nil(t, db.Write(wo, b))
+	b.Destroy()
+	require.Nil(t, txn.Put([]byte("task"), []byte("h")))
+	require.Nil(t, txn.Commit())
+}
+
+func TestTransactionDBGetRangeForUpdate(t *testing.T) {
+	t.Parallel()
+
+	db := newTestTransactionDB(t, nil)
+	defer db.Close()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+	to := NewDefaultTransactionOptions()
+	defer to.Destroy()
+
+	require.Nil(t, db.Put(wo, []byte("slot/1"), []byte("a")))
+
+	txn := db.TransactionBegin(wo, to, nil)
+	defer txn.Destroy()
+	keys, _, err := txn.GetRangeForUpdate(ro, []byte("slot/"), []byte("slot0"))
+	require.Nil(t, err)
+	require.Len(t, keys, 1)
+
+	other := db.TransactionBegin(wo, to, nil)
+	defer other.Destroy()
+	require.Nil(t, other.Put([]byte("slot/2"), []byte("b")))
+	require.Nil(t, other.Commit())
+
+	require.Equal(t, ErrPhantomKey, txn.Commit())
+	require.Nil(t, txn.Rollback())
+
+	// keys deleted by the transaction before reading the range are not phantoms
+	txn = db.TransactionBegin(wo, to, txn)
+	require.Nil(t, txn.Delete([]byte("slot/1")))
+	keys, _, err = txn.GetRangeForUpdate(ro, []byte("slot/"), []byte("slot0"))
+	require.Nil(t, err)
+	require.Equal(t, [][]byte{[]byte("slot/2")}, keys)
+	require.Nil(t, txn.Commit())
+
+	// nor are keys written by the transaction without tracking them
+	txn = db.TransactionBegin(wo, to, txn)
+	require.Nil(t, txn.DeleteUntracked([]byte("slot/2")))
+	keys, _, err = txn.GetRangeForUpdate(ro, []byte("slot/"), []byte("slot0"))
+	require.Nil(t, err)
+	require.Empty(t, keys)
+	require.Nil(t, txn.PutUntracked([]byte("slot/3"), []byte("c")))
+	require.Nil(t, txn.Commit())
+}
@@ -0,0 +1,251 @@
+package grocksdb
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// TransactionConflict classifies the errors which make a transaction fail
+// because of concurrent transactions. Such transactions can be retried.
+type TransactionConflict int
+
+const (
+	// NoConflict is the class of errors unrelated to concurrent transactions.
+	NoConflict TransactionConflict = iota
+	// ConflictBusy is the class of write conflicts, such as those detected at
+	// commit by optimistic transactions, and of ErrPhantomKey.
+	ConflictBusy
+	// ConflictTryAgain is the class of conflicts which could not be checked,
+	// e.g. when the memtable history is too short for optimistic transactions.
+	ConflictTryAgain
+	// ConflictTimedOut is the class of lock timeouts.
+	ConflictTimedOut
+	// ConflictDeadlock is the class of deadlocks detected by transactions
+	// having TransactionOptions.SetDeadlockDetect.
+	ConflictDeadlock
+)
+
+// Prefixes of the messages of the RocksDB statuses of conflicts.
+const (
+	busyStatusPrefix     = "Resource busy: "
+	tryAgainStatusPrefix = "Operation failed. Try again.: "
+	timedOutStatusPrefix = "Operation timed out: "
+	deadlockStatusPrefix = busyStatusPrefix + "Deadlock"
+)
+
+// ClassifyTransactionError returns the conflict class of err, a RocksDB error
+// possibly wrapped.
+func ClassifyTransactionError(err error) TransactionConflict {
+	if errors.Is(err, ErrPhantomKey) {
+		return ConflictBusy
+	}
+	for ; err != nil; err = errors.Unwrap(err) {
+		msg := err.Error()
+		switch {
+		case strings.HasPrefix(msg, deadlockStatusPrefix):
+			return ConflictDeadlock
+		case strings.HasPrefix(msg, busyStatusPrefix):
+			return ConflictBusy
+		case strings.HasPrefix(msg, tryAgainStatusPrefix):
+			return ConflictTryAgain
+		case strings.HasPrefix(msg, timedOutStatusPrefix):
+			return ConflictTimedOut
+		}
+	}
+	return NoConflict
+}
+
+// IsRetryableTransactionError reports whether err is a conflict with
+// concurrent transactions, after which a transaction can be retried.
+func IsRetryableTransactionError(err error) bool {
+	return ClassifyTransactionError(err) != NoConflict
+}
+
+// RetryPolicy defines how transactions failing with a retryable error are retried.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of retries. 0 means no retry.
+	MaxRetries int
+	// InitialBackoff is the upper bound of the first wait before retrying,
+	// doubled on each retry. Waits are randomly chosen between the half of
+	// their bound and their bound.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the bound of waits. 0 means no cap.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy used when none is given.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:     10,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     100 * time.Millisecond,
+}
+
+// backoff returns the wait before the given retry, counting from 1.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	bound := p.InitialBackoff
+	for i := 1; i < retry && (p.MaxBackoff <= 0 || bound < p.MaxBackoff) && bound <= math.MaxInt64/2; i++ {
+		bound *= 2
+	}
+	if p.MaxBackoff > 0 && bound > p.MaxBackoff {
+		bound = p.MaxBackoff
+	}
+	if bound <= 1 {
+		return bound
+	}
+	return bound/2 + time.Duration(rand.Int63n(int64(bound-bound/2)))
+}
+
+// TransactionRunOptions configures TransactionDB.Update and TransactionDB.View.
+type TransactionRunOptions struct {
+	// WriteOptions of transactions. Default options are used when nil.
+	WriteOptions *WriteOptions
+	// TransactionOptions of transactions. Default options are used when nil,
+	// setting a snapshot for View.
+	TransactionOptions *TransactionOptions
+	// RetryPolicy of transactions. The zero RetryPolicy doesn't retry: use
+	// DefaultRetryPolicy to retry as when the options are nil.
+	RetryPolicy RetryPolicy
+}
+
+// OptimisticTransactionRunOptions configures OptimisticTransactionDB.Update and
+// OptimisticTransactionDB.View.
+type OptimisticTransactionRunOptions struct {
+	// WriteOptions of transactions. Default options are used when nil.
+	WriteOptions *WriteOptions
+	// TransactionOptions of transactions. Default options are used when nil,
+	// setting a snapshot for View.
+	TransactionOptions *OptimisticTransactionOptions
+	// RetryPolicy of transactions. The zero RetryPolicy doesn't retry: use
+	// DefaultRetryPolicy to retry as when the options are nil.
+	RetryPolicy RetryPolicy
+}
+
+// Update runs fn in a transaction and commits it, retrying with a new
+// transaction when fn or the commit fail with a retryable error, as defined by
+// IsRetryableTransactionError. The transaction is rolled back when fn fails,
+// and always destroyed: it must not be used once fn returns.
+//
+// DefaultRetryPolicy is used when opts is nil. Update returns the number of
+// retries done, and the error of the last attempt, if any, or the error of ctx
+// when it is done before the transaction succeeds.
+func (db *TransactionDB) Update(ctx context.Context, opts *TransactionRunOptions, fn func(txn *Transaction) error) (retries int, err error) {
+	return db.run(ctx, opts, fn, true)
+}
+
+// View runs fn in a transaction which is rolled back, retrying as Update does.
+// Reads of fn are consistent when using the snapshot of the transaction,
+// returned by Transaction.GetSnapshot.
+func (db *TransactionDB) View(ctx context.Context, opts *TransactionRunOptions, fn func(txn *Transaction) error) (retries int, err error) {
+	return db.run(ctx, opts, fn, false)
+}
+
+func (db *TransactionDB) run(ctx context.Context, opts *TransactionRunOptions, fn func(txn *Transaction) error, commit bool) (retries int, err error) {
+	if opts == nil {
+		opts = &TransactionRunOptions{RetryPolicy: DefaultRetryPolicy}
+	}
+
+	wo := opts.WriteOptions
+	if wo == nil {
+		wo = NewDefaultWriteOptions()
+		defer wo.Destroy()
+	}
+
+	to := opts.TransactionOptions
+	if to == nil {
+		to = NewDefaultTransactionOptions()
+		to.SetSetSnapshot(!commit)
+		defer to.Destroy()
+	}
+
+	begin := func() *Transaction { return db.TransactionBegin(wo, to, nil) }
+	return runTransaction(ctx, opts.RetryPolicy, begin, fn, commit)
+}
+
+// Update runs fn in a transaction and commits it, retrying with a new
+// transaction when fn or the commit fail with a retryable error, as defined by
+// IsRetryableTransactionError. The transaction is rolled back when fn fails,
+// and always destroyed: it must not be used once fn returns.
+//
+// DefaultRetryPolicy is used when opts is nil. Update returns the number of
+// retries done, and the error of the last attempt, if any, or the error of ctx
+// when it is done before the transaction succeeds.
+func (db *OptimisticTransactionDB) Update(ctx context.Context, opts *OptimisticTransactionRunOptions, fn func(txn *Transaction) error) (retries int, err error) {
+	return db.run(ctx, opts, fn, true)
+}
+
+// View runs fn in a transaction which is rolled back, retrying as Update does.
+// Reads of fn are consistent when using the snapshot of the transaction,
+// returned by Transaction.GetSnapshot.
+func (db *OptimisticTransactionDB) View(ctx context.Context, opts *OptimisticTransactionRunOptions, fn func(txn *Transaction) error) (retries int, err error) {
+	return db.run(ctx, opts, fn, false)
+}
+
+func (db *OptimisticTransactionDB) run(ctx context.Context, opts *OptimisticTransactionRunOptions, fn func(txn *Transaction) error, commit bool) (retries int, err error) {
+	if opts == nil {
+		opts = &OptimisticTransactionRunOptions{RetryPolicy: DefaultRetryPolicy}
+	}
+
+	wo := opts.WriteOptions
+	if wo == nil {
+		wo = NewDefaultWriteOptions()
+		defer wo.Destroy()
+	}
+
+	to := opts.TransactionOptions
+	if to == nil {
+		to = NewDefaultOptimisticTransactionOptions()
+		to.SetSetSnapshot(!commit)
+		defer to.Destroy()
+	}
+
+	begin := func() *Transaction { return db.TransactionBegin(wo, to, nil) }
+	return runTransaction(ctx, opts.RetryPolicy, begin, fn, commit)
+}
+
+// runTransaction runs fn in transactions returned by begin, until it succeeds,
+// fails with an error which is not retryable, or policy gives up.
+func runTransaction(ctx context.Context, policy RetryPolicy, begin func() *Transaction, fn func(txn *Transaction) error, commit bool) (retries int, err error) {
+	for {
+		if err = ctx.Err(); err != nil {
+			return retries, err
+		}
+
+		err = runAttempt(begin(), fn, commit)
+		if err == nil || !IsRetryableTransactionError(err) || retries >= policy.MaxRetries {
+			return retries, err
+		}
+
+		retries++
+		timer := time.NewTimer(policy.backoff(retries))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return retries, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// runAttempt runs fn in txn, and commits or rolls it back before destroying
+// it, even when fn panics.
+func runAttempt(txn *Transaction, fn func(txn *Transaction) error, commit bool) (err error) {
+	committed := false
+	defer func() {
+		if !committed {
+			_ = txn.Rollback()
+		}
+		txn.Destroy()
+	}()
+
+	if err = fn(txn); err != nil || !commit {
+		return err
+	}
+	if err = txn.Commit(); err == nil {
+		committed = true
+	}
+	return err
+}
@@ -0,0 +1,197 @@
+package grocksdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyTransactionError(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		err      error
+		conflict TransactionConflict
+	}{
+		{nil, NoConflict},
+		{errors.New("Corruption: bad block"), NoConflict},
+		{errors.New("Resource busy: "), ConflictBusy},
+		{errors.New("Resource busy: Deadlock"), ConflictDeadlock},
+		{errors.New("Operation failed. Try again.: Transaction could not check for conflicts"), ConflictTryAgain},
+		{errors.New("Operation timed out: Timeout waiting to lock key"), ConflictTimedOut},
+		{fmt.Errorf("updating: %w", errors.New("Resource busy: ")), ConflictBusy},
+		{ErrPhantomKey, ConflictBusy},
+		{fmt.Errorf("committing: %w", ErrPhantomKey), ConflictBusy},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.conflict, ClassifyTransactionError(c.err), "%v", c.err)
+		require.Equal(t, c.conflict != NoConflict, IsRetryableTransactionError(c.err))
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	t.Parallel()
+
+	policy := RetryPolicy{InitialBackoff: 8 * time.Millisecond, MaxBackoff: 20 * time.Millisecond}
+	for i := 0; i < 100; i++ {
+		d := policy.backoff(1)
+		require.True(t, d >= 4*time.Millisecond && d < 8*time.Millisecond, d)
+		d = policy.backoff(2)
+		require.True(t, d >= 8*time.Millisecond && d < 16*time.Millisecond, d)
+		d = policy.backoff(10)
+		require.True(t, d >= 10*time.Millisecond && d < 20*time.Millisecond, d)
+	}
+	require.Zero(t, RetryPolicy{}.backoff(3))
+
+	// bounds are doubled without cap when MaxBackoff is 0
+	policy = RetryPolicy{InitialBackoff: 8 * time.Millisecond}
+	d := policy.backoff(3)
+	require.True(t, d >= 16*time.Millisecond && d < 32*time.Millisecond, d)
+	require.Positive(t, policy.backoff(100))
+}
+
+func TestTransactionDBUpdate(t *testing.T) {
+	t.Parallel()
+
+	db := newTestTransactionDB(t, nil)
+	defer db.Close()
+
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+	ctx := context.Background()
+	opts := &TransactionRunOptions{RetryPolicy: RetryPolicy{MaxRetries: 3}}
+
+	// retryable failures are retried
+	attempts := 0
+	retries, err := db.Update(ctx, opts, func(txn *Transaction) error {
+		attempts++
+		if err := txn.Put([]byte("key"), []byte(fmt.Sprint(attempts))); err != nil {
+			return err
+		}
+		if attempts < 3 {
+			return errors.New("Resource busy: ")
+		}
+		return nil
+	})
+	require.Nil(t, err)
+	require.Equal(t, 2, retries)
+
+	v, err := db.Get(ro, []byte("key"))
+	require.Nil(t, err)
+	require.EqualValues(t, "3", v.Data())
+	v.Free()
+
+	// up to the policy limit
+	attempts = 0
+	retries, err = db.Update(ctx, opts, func(txn *Transaction) error {
+		attempts++
+		return errors.New("Operation timed out: Timeout waiting to lock key")
+	})
+	require.Equal(t, ConflictTimedOut, ClassifyTransactionError(err))
+	require.Equal(t, 3, retries)
+	require.Equal(t, 4, attempts)
+
+	// other failures are not retried, and roll back the transaction
+	failure := errors.New("failure")
+	retries, err = db.Update(ctx, nil, func(txn *Transaction) error {
+		require.Nil(t, txn.Put([]byte("key"), []byte("failed")))
+		return failure
+	})
+	require.Equal(t, failure, err)
+	require.Zero(t, retries)
+
+	// views are rolled back
+	retries, err = db.View(ctx, nil, func(txn *Transaction) error {
+		v, err := txn.Get(ro, []byte("key"))
+		require.Nil(t, err)
+		require.EqualValues(t, "3", v.Data())
+		v.Free()
+		return txn.Put([]byte("key"), []byte("view"))
+	})
+	require.Nil(t, err)
+	require.Zero(t, retries)
+
+	v, err = db.Get(ro, []byte("key"))
+	require.Nil(t, err)
+	require.EqualValues(t, "3", v.Data())
+	v.Free()
+
+	// a panicking transaction is rolled back, releasing its locks
+	require.Panics(t, func() {
+		_, _ = db.Update(ctx, nil, func(txn *Transaction) error {
+			require.Nil(t, txn.Put([]byte("key"), []byte("panic")))
+			panic("failure")
+		})
+	})
+	retries, err = db.Update(ctx, nil, func(txn *Transaction) error {
+		v, err := txn.GetForUpdate(ro, []byte("key"))
+		require.Nil(t, err)
+		require.EqualValues(t, "3", v.Data())
+		v.Free()
+		return nil
+	})
+	require.Nil(t, err)
+	require.Zero(t, retries)
+
+	// no attempt is made once the context is done
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = db.Update(canceled, nil, func(txn *Transaction) error {
+		t.Fatal("unexpected attempt")
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestOptimisticTransactionDBUpdate(t *testing.T) {
+	t.Parallel()
+
+	opts := NewDefaultOptions()
+	opts.SetCreateIfMissing(true)
+	db, err := OpenOptimisticTransactionDb(opts, t.TempDir())
+	require.Nil(t, err)
+	defer db.Close()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+
+	// a concurrent write makes the first commit fail
+	attempts := 0
+	retries, err := db.Update(context.Background(), nil, func(txn *Transaction) error {
+		attempts++
+		v, err := txn.GetForUpdate(ro, []byte("counter"))
+		if err != nil {
+			return err
+		}
+		v.Free()
+
+		if attempts == 1 {
+			b := NewWriteBatch()
+			defer b.Destroy()
+			b.Put([]byte("counter"), []byte("concurrent"))
+			require.Nil(t, db.Write(wo, b))
+		}
+		return txn.Put([]byte("counter"), []byte("updated"))
+	})
+	require.Nil(t, err)
+	require.Equal(t, 1, retries)
+	require.Equal(t, 2, attempts)
+
+	retries, err = db.View(context.Background(), nil, func(txn *Transaction) error {
+		v, err := txn.Get(ro, []byte("counter"))
+		if err != nil {
+			return err
+		}
+		defer v.Free()
+		require.EqualValues(t, "updated", v.Data())
+		return nil
+	})
+	require.Nil(t, err)
+	require.Zero(t, retries)
+}
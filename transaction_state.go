@@ -0,0 +1,50 @@
+package grocksdb
+
+// TransactionState is the state of a transaction.
+type TransactionState int32
+
+const (
+	// TransactionStarted is the state of a transaction which is neither prepared,
+	// committed nor rolled back.
+	TransactionStarted TransactionState = iota
+	// TransactionAwaitingPrepare is the state of a transaction being prepared.
+	TransactionAwaitingPrepare
+	// TransactionPrepared is the state of a prepared transaction, waiting to
+	// be committed or rolled back.
+	TransactionPrepared
+	// TransactionAwaitingCommit is the state of a transaction being committed.
+	TransactionAwaitingCommit
+	// TransactionCommitted is the state of a committed transaction.
+	TransactionCommitted
+	// TransactionAwaitingRollback is the state of a transaction being rolled back.
+	TransactionAwaitingRollback
+	// TransactionRolledBack is the state of a rolled back transaction.
+	TransactionRolledBack
+	// TransactionLocksStolen is the state of an expired transaction, whose locks
+	// may have been taken by other transactions.
+	TransactionLocksStolen
+)
+
+// String returns the name of the state, as RocksDB does.
+func (s TransactionState) String() string {
+	switch s {
+	case TransactionStarted:
+		return "STARTED"
+	case TransactionAwaitingPrepare:
+		return "AWAITING_PREPARE"
+	case TransactionPrepared:
+		return "PREPARED"
+	case TransactionAwaitingCommit:
+		return "AWAITING_COMMIT"
+	case TransactionCommitted:
+		return "COMMITTED"
+	case TransactionAwaitingRollback:
+		return "AWAITING_ROLLBACK"
+	case TransactionRolledBack:
+		return "ROLLEDBACK"
+	case TransactionLocksStolen:
+		return "LOCKS_STOLEN"
+	default:
+		return "UNKNOWN"
+	}
+}
@@ -0,0 +1,186 @@
+package grocksdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionDBPutGetWithTS(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	givenNames := []string{"default", "guide"}
+	opts := NewDefaultOptions()
+	opts.SetCreateIfMissingColumnFamilies(true)
+	opts.SetCreateIfMissing(true)
+	opts.SetComparator(newDefaultComparatorWithTS())
+	transactionDBOpts := NewDefaultTransactionDBOptions()
+	defer transactionDBOpts.Destroy()
+	db, cfh, err := OpenTransactionDbColumnFamilies(opts, transactionDBOpts, dir, givenNames, []*Options{opts, opts})
+	require.Nil(t, err)
+	defer db.Close()
+	require.EqualValues(t, len(cfh), 2)
+	defer cfh[0].Destroy()
+	defer cfh[1].Destroy()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+	ro.SetTimestamp(marshalTimestamp(10))
+	to := NewDefaultTransactionOptions()
+	defer to.Destroy()
+
+	givenKey0 := []byte("hello0")
+	givenKey1 := []byte("hello1")
+	givenVal0 := []byte("world0")
+	givenVal1 := []byte("world1")
+
+	// writes of the transaction are read without timestamp
+	txn := db.TransactionBegin(wo, to, nil)
+	require.Nil(t, txn.Put(givenKey0, givenVal0))
+	require.Nil(t, txn.PutCF(cfh[1], givenKey1, givenVal1))
+
+	actualVal, actualTs, err := txn.GetWithTS(ro, givenKey0)
+	require.Nil(t, err)
+	require.EqualValues(t, givenVal0, actualVal.Data())
+	require.EqualValues(t, 0, actualTs.Size())
+	actualVal.Free()
+	actualTs.Free()
+
+	actualVal, actualTs, err = txn.GetCFWithTS(ro, cfh[1], givenKey1)
+	require.Nil(t, err)
+	require.EqualValues(t, givenVal1, actualVal.Data())
+	require.EqualValues(t, 0, actualTs.Size())
+	actualVal.Free()
+	actualTs.Free()
+
+	// and are assigned the commit timestamp
+	require.Error(t, txn.Commit())
+	txn.SetCommitTimestamp(5)
+	require.Nil(t, txn.Commit())
+	txn.Destroy()
+
+	actualVal, actualTs, err = db.GetWithTS(ro, givenKey0)
+	require.Nil(t, err)
+	require.EqualValues(t, givenVal0, actualVal.Data())
+	require.EqualValues(t, marshalTimestamp(5), actualTs.Data())
+	actualVal.Free()
+	actualTs.Free()
+
+	actualVal, actualTs, err = db.GetCFWithTS(ro, cfh[0], givenKey1)
+	require.Nil(t, err)
+	require.False(t, actualVal.Exists())
+	require.EqualValues(t, 0, actualTs.Size())
+	actualVal.Free()
+	actualTs.Free()
+
+	// committed writes are read with their timestamp by transactions, from a
+	// snapshot
+	txn = db.TransactionBegin(wo, to, nil)
+	_, _, err = txn.GetCFWithTS(ro, cfh[1], givenKey1)
+	require.Equal(t, ErrTransactionSnapshotRequired, err)
+	txn.Destroy()
+
+	to.SetSetSnapshot(true)
+	txn = db.TransactionBegin(wo, to, nil)
+	defer txn.Destroy()
+
+	actualVal, actualTs, err = txn.GetCFWithTS(ro, cfh[1], givenKey1)
+	require.Nil(t, err)
+	require.EqualValues(t, givenVal1, actualVal.Data())
+	require.EqualValues(t, marshalTimestamp(5), actualTs.Data())
+	actualVal.Free()
+	actualTs.Free()
+	// the read options given are left as is
+	require.Nil(t, ro.snapshot)
+
+	iter := txn.NewIteratorCF(ro, cfh[1])
+	defer iter.Close()
+	iter.SeekToFirst()
+	require.True(t, iter.Valid())
+	require.EqualValues(t, givenKey1, iter.Key().Data())
+	require.EqualValues(t, marshalTimestamp(5), iter.Timestamp().Data())
+	iter.Next()
+	require.False(t, iter.Valid())
+	require.Nil(t, iter.Err())
+
+	// keys deleted by the transaction are not found
+	require.Nil(t, txn.Put([]byte("other"), []byte("value")))
+	require.Nil(t, txn.Delete(givenKey0))
+	actualVal, actualTs, err = txn.GetWithTS(ro, givenKey0)
+	require.Nil(t, err)
+	require.False(t, actualVal.Exists())
+	require.EqualValues(t, 0, actualTs.Size())
+	actualVal.Free()
+	actualTs.Free()
+
+	actualVal, actualTs, err = txn.GetCFWithTS(ro, cfh[1], givenKey1)
+	require.Nil(t, err)
+	require.EqualValues(t, givenVal1, actualVal.Data())
+	require.EqualValues(t, marshalTimestamp(5), actualTs.Data())
+	actualVal.Free()
+	actualTs.Free()
+
+	require.Nil(t, txn.Rollback())
+}
+
+func TestTransactionDBGetForUpdateWithTS(t *testing.T) {
+	t.Parallel()
+
+	db := newTestTransactionDB(t, func(opts *Options, _ *TransactionDBOptions) {
+		opts.SetComparator(newDefaultComparatorWithTS())
+	})
+	defer db.Close()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	to := NewDefaultTransactionOptions()
+	defer to.Destroy()
+
+	givenKey := []byte("hello")
+
+	txn1 := db.TransactionBegin(wo, to, nil)
+	defer txn1.Destroy()
+	txn1.SetReadTimestampForValidation(6)
+
+	// another transaction commits the key after the read timestamp of txn1
+	txn2 := db.TransactionBegin(wo, to, nil)
+	defer txn2.Destroy()
+	require.Nil(t, txn2.Put(givenKey, []byte("world2")))
+	txn2.SetCommitTimestamp(7)
+	require.Nil(t, txn2.Commit())
+
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+	ro.SetTimestamp(marshalTimestamp(6))
+
+	_, err := txn1.GetForUpdate(ro, givenKey)
+	require.Equal(t, ConflictBusy, ClassifyTransactionError(err), "%v", err)
+	require.Nil(t, txn1.Rollback())
+
+	// which is validated by a transaction reading after it
+	txn3 := db.TransactionBegin(wo, to, nil)
+	defer txn3.Destroy()
+	txn3.SetReadTimestampForValidation(8)
+	ro.SetTimestamp(marshalTimestamp(8))
+
+	v, err := txn3.GetForUpdate(ro, givenKey)
+	require.Nil(t, err)
+	require.EqualValues(t, "world2", v.Data())
+	v.Free()
+
+	require.Nil(t, txn3.Put(givenKey, []byte("world3")))
+	txn3.SetCommitTimestamp(9)
+	require.Nil(t, txn3.Commit())
+
+	ro.SetTimestamp(marshalTimestamp(9))
+	actualVal, actualTs, err := db.GetWithTS(ro, givenKey)
+	require.Nil(t, err)
+	require.EqualValues(t, "world3", actualVal.Data())
+	require.EqualValues(t, marshalTimestamp(9), actualTs.Data())
+	actualVal.Free()
+	actualTs.Free()
+}
@@ -6,6 +6,7 @@ import "C"
 
 import (
 	"fmt"
+	"sync"
 	"unsafe"
 )
 
@@ -15,6 +16,22 @@ type TransactionDB struct {
 	name              string
 	opts              *Options
 	transactionDBOpts *TransactionDBOptions
+	// base is the base database, serving reads of committed data with their
+	// timestamp.
+	base *DB
+	// batchOpts are the options of the reads of the write batches of
+	// transactions.
+	batchOpts *Options
+
+	mu sync.Mutex
+	// named transactions, by name
+	named map[string]*Transaction
+
+	// commitMu serializes the commits of transactions having ranges read for
+	// update with the other commits and writes of the database.
+	commitMu sync.RWMutex
+
+	snapshots snapshotRegistry
 }
 
 // OpenTransactionDb opens a database with the specified options.
@@ -36,7 +53,9 @@ func OpenTransactionDb(
 			c:                 db,
 			opts:              opts,
 			transactionDBOpts: transactionDBOpts,
+			batchOpts:         NewDefaultOptions(),
 		}
+		tdb.base = tdb.GetBaseDB()
 	}
 
 	C.free(unsafe.Pointer(cName))
@@ -87,7 +106,9 @@ func OpenTransactionDbColumnFamilies(
 			c:                 _db,
 			opts:              opts,
 			transactionDBOpts: transactionDBOpts,
+			batchOpts:         NewDefaultOptions(),
 		}
+		db.base = db.GetBaseDB()
 		cfHandles = make([]*ColumnFamilyHandle, numColumnFamilies)
 		for i, c := range cHandles {
 			cfHandles[i] = newNativeColumnFamilyHandle(c)
@@ -103,15 +124,24 @@ func OpenTransactionDbColumnFamilies(
 
 // NewSnapshot creates a new snapshot of the database.
 func (db *TransactionDB) NewSnapshot() *Snapshot {
-	return newNativeSnapshot(C.rocksdb_transactiondb_create_snapshot(db.c))
+	snapshot := newNativeSnapshot(C.rocksdb_transactiondb_create_snapshot(db.c))
+	db.snapshots.add(snapshot)
+	return snapshot
 }
 
 // ReleaseSnapshot releases the snapshot and its resources.
 func (db *TransactionDB) ReleaseSnapshot(snapshot *Snapshot) {
+	db.snapshots.remove(snapshot)
 	C.rocksdb_transactiondb_release_snapshot(db.c, snapshot.c)
 	snapshot.c = nil
 }
 
+// GetSnapshots returns the live snapshots created by NewSnapshot, oldest first.
+// Snapshots taken by transactions are not listed.
+func (db *TransactionDB) GetSnapshots() []SnapshotInfo {
+	return db.snapshots.list()
+}
+
 // GetProperty returns the value of a database property.
 func (db *TransactionDB) GetProperty(propName string) (value string) {
 	cprop := C.CString(propName)
@@ -153,18 +183,110 @@ func (db *TransactionDB) TransactionBegin(
 	transactionOpts *TransactionOptions,
 	oldTransaction *Transaction,
 ) *Transaction {
+	var cTx *C.rocksdb_transaction_t
 	if oldTransaction != nil {
-		cTx := C.rocksdb_transaction_begin(
+		cTx = C.rocksdb_transaction_begin(
 			db.c,
 			opts.c,
 			transactionOpts.c,
 			oldTransaction.c,
 		)
-		return newNativeTransaction(cTx)
+		// reusing the transaction resets it
+		oldTransaction.clear()
+	} else {
+		cTx = C.rocksdb_transaction_begin(db.c, opts.c, transactionOpts.c, nil)
 	}
 
-	cTx := C.rocksdb_transaction_begin(db.c, opts.c, transactionOpts.c, nil)
-	return newNativeTransaction(cTx)
+	txn := db.newTransaction(cTx)
+	txn.hasSnapshot = transactionOpts.setSnapshot
+	return txn
+}
+
+func (db *TransactionDB) newTransaction(cTx *C.rocksdb_transaction_t) *Transaction {
+	txn := newNativeTransaction(cTx)
+	txn.db = db
+	txn.commitMu = &db.commitMu
+	return txn
+}
+
+// GetPreparedTransactions returns the transactions which are prepared but neither
+// committed nor rolled back, among which the ones recovered when opening the
+// database. Recovered transactions are meant to be committed or rolled back,
+// then destroyed.
+//
+// The same Transaction is returned by successive calls, and by
+// GetTransactionByName, for a given prepared transaction.
+func (db *TransactionDB) GetPreparedTransactions() []*Transaction {
+	var cnt C.size_t
+	cTxns := C.rocksdb_transactiondb_get_prepared_transactions(db.c, &cnt)
+	if cTxns == nil {
+		return nil
+	}
+	defer C.rocksdb_free(unsafe.Pointer(cTxns))
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	txns := make([]*Transaction, 0, int(cnt))
+	for _, cTx := range unsafe.Slice(cTxns, int(cnt)) {
+		var cLen C.size_t
+		cName := C.rocksdb_transaction_get_name(cTx, &cLen)
+		name := toString(cName, C.int(cLen))
+
+		// prepared transactions are named, and wrapped once: the returned
+		// wrapper of a known transaction is freed, as destroying it would
+		// destroy the transaction itself.
+		txn, ok := db.named[name]
+		if ok {
+			C.free(unsafe.Pointer(cTx))
+		} else {
+			txn = db.newTransaction(cTx)
+			txn.name = name
+			txn.state = int32(TransactionPrepared)
+			db.registerTransactionLocked(txn)
+		}
+		txns = append(txns, txn)
+	}
+	return txns
+}
+
+// GetTransactionByName returns the transaction named name, which is prepared or
+// neither committed nor rolled back yet. It returns nil if there is none.
+func (db *TransactionDB) GetTransactionByName(name string) *Transaction {
+	db.mu.Lock()
+	txn := db.named[name]
+	db.mu.Unlock()
+	if txn != nil {
+		return txn
+	}
+
+	for _, txn := range db.GetPreparedTransactions() {
+		if txn.name == name {
+			return txn
+		}
+	}
+	return nil
+}
+
+func (db *TransactionDB) registerTransaction(txn *Transaction) {
+	db.mu.Lock()
+	db.registerTransactionLocked(txn)
+	db.mu.Unlock()
+}
+
+func (db *TransactionDB) registerTransactionLocked(txn *Transaction) {
+	if db.named == nil {
+		db.named = make(map[string]*Transaction)
+	}
+	db.named[txn.name] = txn
+}
+
+func (db *TransactionDB) unregisterTransaction(txn *Transaction) {
+	db.mu.Lock()
+	if db.named[txn.name] == txn {
+		delete(db.named, txn.name)
+	}
+	db.mu.Unlock()
 }
 
 // Get returns the data associated with the key from the database.
@@ -218,6 +340,18 @@ func (db *TransactionDB) GetCF(opts *ReadOptions, cf *ColumnFamilyHandle, key []
 	return slice, err
 }
 
+// GetWithTS returns the data and timestamp associated with the key from the
+// database, whose comparator has user-defined timestamps.
+func (db *TransactionDB) GetWithTS(opts *ReadOptions, key []byte) (value, timestamp *Slice, err error) {
+	return db.base.GetWithTS(opts, key)
+}
+
+// GetCFWithTS returns the data and timestamp associated with the key from the
+// database and column family, whose comparator has user-defined timestamps.
+func (db *TransactionDB) GetCFWithTS(opts *ReadOptions, cf *ColumnFamilyHandle, key []byte) (value, timestamp *Slice, err error) {
+	return db.base.GetCFWithTS(opts, cf, key)
+}
+
 // GetPinnedWithCF returns the data associated with the key from the database.
 func (db *TransactionDB) GetPinnedWithCF(opts *ReadOptions, cf *ColumnFamilyHandle, key []byte) (handle *PinnableSlice, err error) {
 	var (
@@ -233,6 +367,12 @@ func (db *TransactionDB) GetPinnedWithCF(opts *ReadOptions, cf *ColumnFamilyHand
 	return handle, err
 }
 
+// GetPinnedCF returns the data associated with the key from the database and
+// column family, as GetPinnedWithCF does.
+func (db *TransactionDB) GetPinnedCF(opts *ReadOptions, cf *ColumnFamilyHandle, key []byte) (handle *PinnableSlice, err error) {
+	return db.GetPinnedWithCF(opts, cf, key)
+}
+
 // MultiGet returns the data associated with the passed keys from the database.
 func (db *TransactionDB) MultiGet(opts *ReadOptions, keys ...[]byte) (Slices, error) {
 	// will destroy `cKeys` before return
@@ -323,6 +463,12 @@ func (db *TransactionDB) MultiGetWithCF(opts *ReadOptions, cf *ColumnFamilyHandl
 	return slices, nil
 }
 
+// MultiGetCF returns the data associated with the passed keys from the
+// database and column family, as MultiGetWithCF does.
+func (db *TransactionDB) MultiGetCF(opts *ReadOptions, cf *ColumnFamilyHandle, keys ...[]byte) (Slices, error) {
+	return db.MultiGetWithCF(opts, cf, keys...)
+}
+
 // Put writes data associated with a key to the database.
 func (db *TransactionDB) Put(opts *WriteOptions, key, value []byte) (err error) {
 	var (
@@ -331,6 +477,9 @@ func (db *TransactionDB) Put(opts *WriteOptions, key, value []byte) (err error)
 		cValue = refGoBytes(value)
 	)
 
+	db.commitMu.RLock()
+	defer db.commitMu.RUnlock()
+
 	C.rocksdb_transactiondb_put(
 		db.c, opts.c, cKey, C.size_t(len(key)), cValue, C.size_t(len(value)), &cErr,
 	)
@@ -347,6 +496,9 @@ func (db *TransactionDB) PutCF(opts *WriteOptions, cf *ColumnFamilyHandle, key,
 		cValue = refGoBytes(value)
 	)
 
+	db.commitMu.RLock()
+	defer db.commitMu.RUnlock()
+
 	C.rocksdb_transactiondb_put_cf(
 		db.c, opts.c, cf.c, cKey, C.size_t(len(key)), cValue, C.size_t(len(value)), &cErr,
 	)
@@ -363,6 +515,9 @@ func (db *TransactionDB) Merge(opts *WriteOptions, key, value []byte) (err error
 		cValue = refGoBytes(value)
 	)
 
+	db.commitMu.RLock()
+	defer db.commitMu.RUnlock()
+
 	C.rocksdb_transactiondb_merge(
 		db.c, opts.c, cKey, C.size_t(len(key)), cValue, C.size_t(len(value)), &cErr,
 	)
@@ -379,6 +534,9 @@ func (db *TransactionDB) MergeCF(opts *WriteOptions, cf *ColumnFamilyHandle, key
 		cValue = refGoBytes(value)
 	)
 
+	db.commitMu.RLock()
+	defer db.commitMu.RUnlock()
+
 	C.rocksdb_transactiondb_merge_cf(
 		db.c, opts.c, cf.c, cKey, C.size_t(len(key)), cValue, C.size_t(len(value)), &cErr,
 	)
@@ -394,6 +552,9 @@ func (db *TransactionDB) Delete(opts *WriteOptions, key []byte) (err error) {
 		cKey = refGoBytes(key)
 	)
 
+	db.commitMu.RLock()
+	defer db.commitMu.RUnlock()
+
 	C.rocksdb_transactiondb_delete(db.c, opts.c, cKey, C.size_t(len(key)), &cErr)
 	err = fromCError(cErr)
 
@@ -407,12 +568,68 @@ func (db *TransactionDB) DeleteCF(opts *WriteOptions, cf *ColumnFamilyHandle, ke
 		cKey = refGoBytes(key)
 	)
 
+	db.commitMu.RLock()
+	defer db.commitMu.RUnlock()
+
 	C.rocksdb_transactiondb_delete_cf(db.c, opts.c, cf.c, cKey, C.size_t(len(key)), &cErr)
 	err = fromCError(cErr)
 
 	return err
 }
 
+// SingleDelete removes the database entry for "key", which must have been
+// written once only since it was last deleted, as DB.SingleDelete does.
+func (db *TransactionDB) SingleDelete(opts *WriteOptions, key []byte) (err error) {
+	batch := NewWriteBatch()
+	batch.SingleDelete(key)
+	err = db.Write(opts, batch)
+	batch.Destroy()
+	return err
+}
+
+// SingleDeleteCF removes the database entry for "key" of the column family,
+// which must have been written once only since it was last deleted, as
+// DB.SingleDeleteCF does.
+func (db *TransactionDB) SingleDeleteCF(opts *WriteOptions, cf *ColumnFamilyHandle, key []byte) (err error) {
+	batch := NewWriteBatch()
+	batch.SingleDeleteCF(cf, key)
+	err = db.Write(opts, batch)
+	batch.Destroy()
+	return err
+}
+
+// DeleteRangeCF deletes keys that are between [startKey, endKey).
+//
+// Unlike the other writes of TransactionDB, it takes no lock: as range
+// deletions cannot be locked by transactions, the range is deleted in the
+// base database, bypassing the locks of the keys of the range. Keys locked by
+// transactions in progress are deleted regardless of the locks, and no
+// conflict is reported to the transactions: callers must make sure that no
+// transaction in progress reads for update or writes keys of the range.
+func (db *TransactionDB) DeleteRangeCF(opts *WriteOptions, cf *ColumnFamilyHandle, startKey, endKey []byte) (err error) {
+	db.commitMu.RLock()
+	defer db.commitMu.RUnlock()
+	return db.base.DeleteRangeCF(opts, cf, startKey, endKey)
+}
+
+// GetApproximateSizes returns the approximate number of bytes of file system
+// space used by one or more key ranges.
+//
+// The keys counted will begin at Range.Start and end on the key before
+// Range.Limit.
+func (db *TransactionDB) GetApproximateSizes(ranges []Range) ([]uint64, error) {
+	return db.base.GetApproximateSizes(ranges)
+}
+
+// GetApproximateSizesCF returns the approximate number of bytes of file system
+// space used by one or more key ranges in the column family.
+//
+// The keys counted will begin at Range.Start and end on the key before
+// Range.Limit.
+func (db *TransactionDB) GetApproximateSizesCF(cf *ColumnFamilyHandle, ranges []Range) ([]uint64, error) {
+	return db.base.GetApproximateSizesCF(cf, ranges)
+}
+
 // NewCheckpoint creates a new Checkpoint for this db.
 func (db *TransactionDB) NewCheckpoint() (cp *Checkpoint, err error) {
 	var cErr *C.char
@@ -421,7 +638,7 @@ func (db *TransactionDB) NewCheckpoint() (cp *Checkpoint, err error) {
 		db.c, &cErr,
 	)
 	if err = fromCError(cErr); err == nil {
-		cp = newNativeCheckpoint(cCheckpoint)
+		cp = newNativeCheckpoint(cCheckpoint, db.name, db.base)
 	}
 
 	return cp, err
@@ -447,6 +664,9 @@ func (db *TransactionDB) CreateColumnFamily(opts *Options, name string) (handle
 func (db *TransactionDB) Write(opts *WriteOptions, batch *WriteBatch) (err error) {
 	var cErr *C.char
 
+	db.commitMu.RLock()
+	defer db.commitMu.RUnlock()
+
 	C.rocksdb_transactiondb_write(db.c, opts.c, batch.c, &cErr)
 	err = fromCError(cErr)
 
@@ -515,6 +735,8 @@ func (db *TransactionDB) NewIteratorCF(opts *ReadOptions, cf *ColumnFamilyHandle
 
 // Close closes the database.
 func (db *TransactionDB) Close() {
+	CloseBaseDBOfTransactionDB(db.base)
+	db.batchOpts.Destroy()
 	C.rocksdb_transactiondb_close(db.c)
 	db.c = nil
 }
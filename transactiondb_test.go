@@ -267,3 +267,242 @@ func TestTransactionDBColumnFamilyBatchPutGet(t *testing.T) {
 	require.Nil(t, db.FlushCF(cfh[0], NewDefaultFlushOptions()))
 	require.Nil(t, db.FlushCFs(cfh, NewDefaultFlushOptions()))
 }
+
+func TestTransactionDBPreparedRecovery(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	opts := NewDefaultOptions()
+	opts.SetCreateIfMissing(true)
+	transactionDBOpts := NewDefaultTransactionDBOptions()
+
+	db, err := OpenTransactionDb(opts, transactionDBOpts, dir)
+	require.Nil(t, err)
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+	to := NewDefaultTransactionOptions()
+	defer to.Destroy()
+
+	for _, name := range []string{"xid1", "xid2"} {
+		txn := db.TransactionBegin(wo, to, nil)
+		require.Equal(t, TransactionStarted, txn.GetState())
+		require.Nil(t, txn.SetName(name))
+		require.Nil(t, txn.Put([]byte(name), []byte("value")))
+		require.True(t, db.GetTransactionByName(name) == txn)
+
+		require.Nil(t, txn.Prepare())
+		require.Equal(t, TransactionPrepared, txn.GetState())
+
+		// destroying prepared transactions leaves them as after a crash
+		txn.Destroy()
+	}
+
+	uncommitted := db.TransactionBegin(wo, to, nil)
+	require.Nil(t, uncommitted.SetName("xid3"))
+	require.Nil(t, uncommitted.Put([]byte("xid3"), []byte("value")))
+	require.Nil(t, uncommitted.Rollback())
+	require.Equal(t, TransactionRolledBack, uncommitted.GetState())
+	require.Nil(t, db.GetTransactionByName("xid3"))
+	uncommitted.Destroy()
+	db.Close()
+
+	db, err = OpenTransactionDb(opts, transactionDBOpts, dir)
+	require.Nil(t, err)
+	defer db.Close()
+
+	prepared := db.GetPreparedTransactions()
+	require.Len(t, prepared, 2)
+	names := []string{prepared[0].GetName(), prepared[1].GetName()}
+	require.ElementsMatch(t, []string{"xid1", "xid2"}, names)
+	for _, txn := range prepared {
+		require.Equal(t, TransactionPrepared, txn.GetState())
+	}
+
+	// successive calls return the same transactions
+	xid1 := db.GetTransactionByName("xid1")
+	require.NotNil(t, xid1)
+	require.Contains(t, db.GetPreparedTransactions(), xid1)
+	xid2 := db.GetTransactionByName("xid2")
+	require.NotNil(t, xid2)
+
+	require.Nil(t, xid1.Commit())
+	require.Equal(t, TransactionCommitted, xid1.GetState())
+	xid1.Destroy()
+	require.Nil(t, xid2.Rollback())
+	xid2.Destroy()
+
+	require.Empty(t, db.GetPreparedTransactions())
+	require.Nil(t, db.GetTransactionByName("xid1"))
+
+	v, err := db.Get(ro, []byte("xid1"))
+	require.Nil(t, err)
+	require.EqualValues(t, "value", v.Data())
+	v.Free()
+
+	v, err = db.Get(ro, []byte("xid2"))
+	require.Nil(t, err)
+	require.False(t, v.Exists())
+	v.Free()
+}
+
+func TestTransactionWriteCounters(t *testing.T) {
+	t.Parallel()
+
+	db := newTestTransactionDB(t, nil)
+	defer db.Close()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+	to := NewDefaultTransactionOptions()
+	defer to.Destroy()
+
+	txn := db.TransactionBegin(wo, to, nil)
+	defer txn.Destroy()
+
+	require.Nil(t, txn.Put([]byte("a"), []byte("1")))
+	require.Nil(t, txn.Put([]byte("a"), []byte("2")))
+	require.Nil(t, txn.Merge([]byte("b"), []byte("1")))
+	v, err := txn.GetForUpdate(ro, []byte("c"))
+	require.Nil(t, err)
+	v.Free()
+
+	require.EqualValues(t, 2, txn.GetNumPuts())
+	require.EqualValues(t, 1, txn.GetNumMerges())
+	require.EqualValues(t, 0, txn.GetNumDeletes())
+
+	txn.SetSavePoint()
+	require.Nil(t, txn.Delete([]byte("d")))
+	require.EqualValues(t, 1, txn.GetNumDeletes())
+	require.Nil(t, txn.RollbackToSavePoint())
+	require.EqualValues(t, 0, txn.GetNumDeletes())
+
+	require.Nil(t, txn.Commit())
+	require.EqualValues(t, 0, txn.GetNumPuts())
+}
+
+func TestTransactionMultiGetForUpdateAndUntracked(t *testing.T) {
+	t.Parallel()
+
+	db := newTestTransactionDB(t, nil)
+	defer db.Close()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+	to := NewDefaultTransactionOptions()
+	defer to.Destroy()
+
+	require.Nil(t, db.Put(wo, []byte("a"), []byte("1")))
+
+	txn := db.TransactionBegin(wo, to, nil)
+	defer txn.Destroy()
+
+	values, err := txn.MultiGetForUpdate(ro, []byte("a"), []byte("b"))
+	require.Nil(t, err)
+	require.EqualValues(t, "1", values[0].Data())
+	require.False(t, values[1].Exists())
+	values.Destroy()
+
+	// untracked writes are counted
+	require.Nil(t, txn.PutUntracked([]byte("c"), []byte("3")))
+	require.Nil(t, txn.MergeUntracked([]byte("d"), []byte("4")))
+	require.Nil(t, txn.DeleteUntracked([]byte("a")))
+	require.EqualValues(t, 1, txn.GetNumPuts())
+	require.EqualValues(t, 1, txn.GetNumMerges())
+	require.EqualValues(t, 1, txn.GetNumDeletes())
+
+	v, err := txn.Get(ro, []byte("c"))
+	require.Nil(t, err)
+	require.EqualValues(t, "3", v.Data())
+	v.Free()
+
+	require.Positive(t, txn.GetElapsedTime())
+	require.Nil(t, txn.Commit())
+
+	v, err = db.Get(ro, []byte("a"))
+	require.Nil(t, err)
+	require.False(t, v.Exists())
+	v.Free()
+
+	v, err = db.Get(ro, []byte("c"))
+	require.Nil(t, err)
+	require.EqualValues(t, "3", v.Data())
+	v.Free()
+
+	// untracked writes exceeding the max write batch size are rejected
+	to.SetMaxWriteBatchSize(16 << 10)
+	txn = db.TransactionBegin(wo, to, txn)
+	value := make([]byte, 1<<10)
+	for i := 0; i < 32 && err == nil; i++ {
+		err = txn.PutUntracked([]byte{byte(i)}, value)
+	}
+	require.Equal(t, ErrTransactionWriteBatchFull, err)
+	require.Nil(t, txn.Rollback())
+}
+
+func TestTransactionUntrackedSavePoint(t *testing.T) {
+	t.Parallel()
+
+	db := newTestTransactionDB(t, nil)
+	defer db.Close()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+	ro := NewDefaultReadOptions()
+	defer ro.Destroy()
+	to := NewDefaultTransactionOptions()
+	defer to.Destroy()
+
+	require.Nil(t, db.Put(wo, []byte("a"), []byte("1")))
+
+	txn := db.TransactionBegin(wo, to, nil)
+	defer txn.Destroy()
+	require.Nil(t, txn.PutUntracked([]byte("b"), []byte("2")))
+
+	// untracked writes after a save point are undone by rolling back to it
+	txn.SetSavePoint()
+	require.Nil(t, txn.PutUntracked([]byte("c"), []byte("3")))
+	require.Nil(t, txn.DeleteUntracked([]byte("a")))
+	require.EqualValues(t, 2, txn.GetNumPuts())
+	require.EqualValues(t, 1, txn.GetNumDeletes())
+	require.Nil(t, txn.RollbackToSavePoint())
+	require.EqualValues(t, 1, txn.GetNumPuts())
+	require.EqualValues(t, 0, txn.GetNumDeletes())
+
+	for key, value := range map[string]string{"a": "1", "b": "2", "c": ""} {
+		v, err := txn.Get(ro, []byte(key))
+		require.Nil(t, err)
+		require.Equal(t, value, string(v.Data()))
+		v.Free()
+	}
+
+	// and by rolling back the transaction
+	require.Nil(t, txn.Rollback())
+	require.EqualValues(t, 0, txn.GetNumPuts())
+
+	v, err := db.Get(ro, []byte("b"))
+	require.Nil(t, err)
+	require.False(t, v.Exists())
+	v.Free()
+
+	// untracked writes kept by the save point are committed
+	txn = db.TransactionBegin(wo, to, txn)
+	require.Nil(t, txn.PutUntracked([]byte("b"), []byte("2")))
+	txn.SetSavePoint()
+	require.Nil(t, txn.MergeUntracked([]byte("c"), []byte("3")))
+	require.Nil(t, txn.RollbackToSavePoint())
+	require.Nil(t, txn.Commit())
+
+	for key, value := range map[string]string{"a": "1", "b": "2", "c": ""} {
+		v, err := db.Get(ro, []byte(key))
+		require.Nil(t, err)
+		require.Equal(t, value, string(v.Data()))
+		v.Free()
+	}
+}
@@ -0,0 +1,149 @@
+package grocksdb
+
+import "sync"
+
+// View is a consistent view of a database, bound to a snapshot. Reads made
+// with nil read options use the options of the view; read options given
+// explicitly are copied by the view, which reads with the copy bound to its
+// snapshot, so that they are not modified and can be shared by goroutines.
+//
+// A View is only valid within the function given to WithSnapshot.
+type View struct {
+	kv       KV
+	snapshot *Snapshot
+	opts     *ReadOptions
+
+	mu sync.Mutex
+	// iterOpts are the copies of the read options of iterators, destroyed
+	// when the view ends.
+	iterOpts []*ReadOptions
+}
+
+// withSnapshot calls fn with a view bound to a new snapshot of kv, releasing
+// the snapshot when fn returns.
+func withSnapshot(kv KV, fn func(view *View) error) error {
+	snapshot := kv.NewSnapshot()
+	opts := NewDefaultReadOptions()
+	opts.SetSnapshot(snapshot)
+	view := &View{kv: kv, snapshot: snapshot, opts: opts}
+	defer func() {
+		view.mu.Lock()
+		for _, iterOpts := range view.iterOpts {
+			iterOpts.Destroy()
+		}
+		view.iterOpts = nil
+		view.mu.Unlock()
+		opts.Destroy()
+		kv.ReleaseSnapshot(snapshot)
+	}()
+
+	return fn(view)
+}
+
+// WithSnapshot calls fn with a view bound to a new snapshot of the database.
+// The snapshot is released when fn returns, so iterators created from the
+// view must be closed before.
+func (db *DB) WithSnapshot(fn func(view *View) error) error {
+	return withSnapshot(db, fn)
+}
+
+// WithSnapshot calls fn with a view bound to a new snapshot of the database.
+// The snapshot is released when fn returns, so iterators created from the
+// view must be closed before.
+func (db *TransactionDB) WithSnapshot(fn func(view *View) error) error {
+	return withSnapshot(db, fn)
+}
+
+// WithSnapshot calls fn with a view bound to a new snapshot of the database.
+// The snapshot is released when fn returns, so iterators created from the
+// view must be closed before.
+func (db *OptimisticTransactionDB) WithSnapshot(fn func(view *View) error) error {
+	return withSnapshot(db, fn)
+}
+
+// Snapshot returns the snapshot of the view.
+func (v *View) Snapshot() *Snapshot {
+	return v.snapshot
+}
+
+// readOptions returns the options of reads of the view given opts, and the
+// function releasing them: a copy of opts bound to the snapshot of the view,
+// or the options of the view when opts is nil.
+func (v *View) readOptions(opts *ReadOptions) (*ReadOptions, func()) {
+	if opts == nil {
+		return v.opts, func() {}
+	}
+	cloned := opts.clone()
+	cloned.SetSnapshot(v.snapshot)
+	return cloned, cloned.Destroy
+}
+
+// iteratorOptions returns the options of iterators of the view given opts,
+// which the view keeps until it ends, as iterators read them until closed.
+func (v *View) iteratorOptions(opts *ReadOptions) *ReadOptions {
+	if opts == nil {
+		return v.opts
+	}
+	cloned := opts.clone()
+	cloned.SetSnapshot(v.snapshot)
+
+	v.mu.Lock()
+	v.iterOpts = append(v.iterOpts, cloned)
+	v.mu.Unlock()
+	return cloned
+}
+
+// Get returns the data associated with the key in the view.
+func (v *View) Get(opts *ReadOptions, key []byte) (*Slice, error) {
+	opts, release := v.readOptions(opts)
+	defer release()
+	return v.kv.Get(opts, key)
+}
+
+// GetCF returns the data associated with the key of the column family in the view.
+func (v *View) GetCF(opts *ReadOptions, cf *ColumnFamilyHandle, key []byte) (*Slice, error) {
+	opts, release := v.readOptions(opts)
+	defer release()
+	return v.kv.GetCF(opts, cf, key)
+}
+
+// GetPinned returns the data associated with the key in the view.
+func (v *View) GetPinned(opts *ReadOptions, key []byte) (*PinnableSlice, error) {
+	opts, release := v.readOptions(opts)
+	defer release()
+	return v.kv.GetPinned(opts, key)
+}
+
+// GetPinnedCF returns the data associated with the key of the column family in the view.
+func (v *View) GetPinnedCF(opts *ReadOptions, cf *ColumnFamilyHandle, key []byte) (*PinnableSlice, error) {
+	opts, release := v.readOptions(opts)
+	defer release()
+	return v.kv.GetPinnedCF(opts, cf, key)
+}
+
+// MultiGet returns the data associated with the passed keys in the view.
+func (v *View) MultiGet(opts *ReadOptions, keys ...[]byte) (Slices, error) {
+	opts, release := v.readOptions(opts)
+	defer release()
+	return v.kv.MultiGet(opts, keys...)
+}
+
+// MultiGetCF returns the data associated with the passed keys of the column
+// family in the view.
+func (v *View) MultiGetCF(opts *ReadOptions, cf *ColumnFamilyHandle, keys ...[]byte) (Slices, error) {
+	opts, release := v.readOptions(opts)
+	defer release()
+	return v.kv.MultiGetCF(opts, cf, keys...)
+}
+
+// NewIterator returns an iterator over the view. It must be closed before the
+// view ends.
+func (v *View) NewIterator(opts *ReadOptions) *Iterator {
+	return v.kv.NewIterator(v.iteratorOptions(opts))
+}
+
+// NewIteratorCF returns an iterator over the column family in the view. It
+// must be closed before the view ends.
+func (v *View) NewIteratorCF(opts *ReadOptions, cf *ColumnFamilyHandle) *Iterator {
+	return v.kv.NewIteratorCF(v.iteratorOptions(opts), cf)
+}
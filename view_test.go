@@ -0,0 +1,117 @@
+package grocksdb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSnapshot(t *testing.T) {
+	t.Parallel()
+
+	db := newTestDB(t, nil)
+	defer db.Close()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+
+	require.Nil(t, db.Put(wo, []byte("a"), []byte("1")))
+
+	errDone := fmt.Errorf("done")
+	err := db.WithSnapshot(func(view *View) error {
+		require.Nil(t, db.Put(wo, []byte("a"), []byte("2")))
+		require.Nil(t, db.Put(wo, []byte("b"), []byte("3")))
+
+		snapshots := db.GetSnapshots()
+		require.Len(t, snapshots, 1)
+		require.Equal(t, view.Snapshot().GetSequenceNumber(), snapshots[0].SequenceNumber)
+		require.True(t, snapshots[0].Age >= 0)
+
+		v, err := view.Get(nil, []byte("a"))
+		require.Nil(t, err)
+		require.Equal(t, []byte("1"), v.Data())
+		v.Free()
+
+		// explicit read options read from the snapshot too
+		ro := NewDefaultReadOptions()
+		defer ro.Destroy()
+		values, err := view.MultiGet(ro, []byte("a"), []byte("b"))
+		require.Nil(t, err)
+		require.Equal(t, []byte("1"), values[0].Data())
+		require.False(t, values[1].Exists())
+		values.Destroy()
+		// explicit read options are not modified
+		require.Nil(t, ro.snapshot)
+		own := db.NewSnapshot()
+		ro.SetSnapshot(own)
+		v, err = view.Get(ro, []byte("a"))
+		require.Nil(t, err)
+		require.Equal(t, []byte("1"), v.Data())
+		v.Free()
+		require.Equal(t, own, ro.snapshot)
+		ro.SetSnapshot(nil)
+		db.ReleaseSnapshot(own)
+
+		iter := view.NewIterator(nil)
+		var keys []string
+		for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+			keys = append(keys, string(iter.Key().Data()))
+		}
+		require.Nil(t, iter.Err())
+		iter.Close()
+		require.Equal(t, []string{"a"}, keys)
+
+		// iterators keep the copy of explicit read options
+		ro.SetIterateUpperBound([]byte("a"))
+		iter = view.NewIterator(ro)
+		ro.SetIterateUpperBound([]byte("z"))
+		keys = nil
+		for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+			keys = append(keys, string(iter.Key().Data()))
+		}
+		require.Nil(t, iter.Err())
+		iter.Close()
+		require.Empty(t, keys)
+		require.Nil(t, ro.snapshot)
+
+		return errDone
+	})
+	require.Equal(t, errDone, err)
+	require.Empty(t, db.GetSnapshots())
+
+	// snapshots are listed oldest first
+	s1 := db.NewSnapshot()
+	require.Nil(t, db.Put(wo, []byte("c"), []byte("4")))
+	s2 := db.NewSnapshot()
+	snapshots := db.GetSnapshots()
+	require.Len(t, snapshots, 2)
+	require.Equal(t, s1.GetSequenceNumber(), snapshots[0].SequenceNumber)
+	require.Equal(t, s2.GetSequenceNumber(), snapshots[1].SequenceNumber)
+	db.ReleaseSnapshot(s1)
+	db.ReleaseSnapshot(s2)
+	require.Empty(t, db.GetSnapshots())
+}
+
+func TestTransactionDBWithSnapshot(t *testing.T) {
+	t.Parallel()
+
+	db := newTestTransactionDB(t, nil)
+	defer db.Close()
+
+	wo := NewDefaultWriteOptions()
+	defer wo.Destroy()
+
+	require.Nil(t, db.Put(wo, []byte("a"), []byte("1")))
+	require.Nil(t, db.WithSnapshot(func(view *View) error {
+		require.Nil(t, db.Put(wo, []byte("a"), []byte("2")))
+		require.Len(t, db.GetSnapshots(), 1)
+
+		v, err := view.GetPinned(nil, []byte("a"))
+		require.Nil(t, err)
+		defer v.Destroy()
+		require.Equal(t, []byte("1"), v.Data())
+		return nil
+	}))
+	require.Empty(t, db.GetSnapshots())
+}
@@ -0,0 +1,201 @@
+package grocksdb
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WalFileType is the type of a WAL file.
+type WalFileType int
+
+const (
+	// WalFileArchived is a WAL file no longer needed for recovery, moved to
+	// the archive directory to be kept, see SetWALTtlSeconds and
+	// SetWalSizeLimitMb.
+	WalFileArchived WalFileType = iota
+	// WalFileLive is a WAL file of the WAL directory.
+	WalFileLive
+)
+
+// WalFile is a WAL file of a database.
+type WalFile struct {
+	// PathName is the path of the file relative to the WAL directory, such
+	// as /000012.log for live files and /archive/000010.log for archived
+	// files.
+	PathName  string
+	LogNumber uint64
+	Type      WalFileType
+	// Size is the size of the file, in bytes.
+	Size uint64
+}
+
+// walDir returns the directory of the WAL files of the database: the
+// directory set with SetWalDir on the options the database was opened with,
+// or else the wal_dir of its newest OPTIONS file, the directory of the
+// database when empty. It fails when neither is known, as with options
+// loaded from OPTIONS files or strings of databases not persisting their
+// options.
+func (db *DB) walDir() (string, error) {
+	if db.opts != nil && db.opts.walDir != "" {
+		return db.opts.walDir, nil
+	}
+
+	options, _, err := newestOptionsFile(db.name)
+	if err != nil {
+		return "", err
+	}
+	if options == "" {
+		return "", fmt.Errorf("NotFound: WAL directory of %s unknown, no OPTIONS file", db.name)
+	}
+	dir, ok, err := optionsWalDir(filepath.Join(db.name, options))
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("Corruption: WAL directory of %s unknown, no wal_dir in %s", db.name, options)
+	}
+	if dir == "" {
+		return db.name, nil
+	}
+	return dir, nil
+}
+
+// optionsWalDir returns the wal_dir of the DBOptions section of the OPTIONS
+// file, and whether it has one.
+func optionsWalDir(name string) (dir string, ok bool, err error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return
+	}
+
+	var section string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			section = line
+			continue
+		}
+		if section != "[DBOptions]" {
+			continue
+		}
+		if value := strings.TrimPrefix(line, "wal_dir="); value != line {
+			return value, true, nil
+		}
+	}
+	return
+}
+
+// GetSortedWalFiles returns the live and the archived WAL files of the
+// database, sorted by log number.
+//
+// The C API of RocksDB lacks GetSortedWalFiles, which this approximates on a
+// best-effort basis by listing the WAL directory: the files listed are those
+// of the directory, which the database may be about to delete or archive,
+// and without the sequence number of their first batch.
+//
+// The WAL files are listed from the WAL directory set with SetWalDir on the
+// options the database was opened with, or else from the WAL directory of
+// the newest OPTIONS file of the database. An error is returned when it is
+// unknown.
+func (db *DB) GetSortedWalFiles() ([]WalFile, error) {
+	dir, err := db.walDir()
+	if err != nil {
+		return nil, err
+	}
+
+	// live files are listed first, as they are moved to the archive
+	live, err := listWalFiles(dir, "", WalFileLive)
+	if err != nil {
+		return nil, err
+	}
+	archived, err := listWalFiles(dir, "archive", WalFileArchived)
+	if err != nil {
+		return nil, err
+	}
+
+	numbers := make(map[uint64]struct{}, len(archived))
+	for _, file := range archived {
+		numbers[file.LogNumber] = struct{}{}
+	}
+	files := archived
+	for _, file := range live {
+		if _, ok := numbers[file.LogNumber]; !ok {
+			files = append(files, file)
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].LogNumber < files[j].LogNumber })
+	return files, nil
+}
+
+// GetCurrentWalFile returns the WAL file being written.
+func (db *DB) GetCurrentWalFile() (file WalFile, err error) {
+	dir, err := db.walDir()
+	if err != nil {
+		return
+	}
+	files, err := listWalFiles(dir, "", WalFileLive)
+	if err != nil {
+		return
+	}
+	if len(files) == 0 {
+		err = fmt.Errorf("NotFound: No WAL file")
+		return
+	}
+
+	file = files[0]
+	for _, f := range files[1:] {
+		if f.LogNumber > file.LogNumber {
+			file = f
+		}
+	}
+	return
+}
+
+// listWalFiles lists the WAL files of the sub directory of the WAL directory.
+func listWalFiles(dir, sub string, typ WalFileType) (files []WalFile, err error) {
+	entries, err := os.ReadDir(filepath.Join(dir, sub))
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".log") {
+			continue
+		}
+		number, perr := strconv.ParseUint(strings.TrimSuffix(name, ".log"), 10, 64)
+		if perr != nil {
+			continue
+		}
+
+		file := WalFile{
+			PathName:  "/" + path.Join(sub, name),
+			LogNumber: number,
+			Type:      typ,
+		}
+
+		// live files are archived or deleted while listed
+		fi, err := entry.Info()
+		if err == nil {
+			file.Size = uint64(fi.Size())
+		}
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, file)
+	}
+	return
+}